@@ -12,6 +12,7 @@ import (
 	"github.com/bulk-import-export-api/internal/database"
 	"github.com/bulk-import-export-api/internal/repository"
 	"github.com/bulk-import-export-api/internal/service"
+	"github.com/bulk-import-export-api/internal/storage"
 	"github.com/bulk-import-export-api/pkg/logger"
 )
 
@@ -46,12 +47,33 @@ func main() {
 	repos := repository.New(db)
 
 	// Initialize services
-	services := service.NewServices(repos, cfg, log)
+	services := service.NewServices(repos, storage.New(cfg.Storage), cfg, log)
+
+	// Load validation rules; SIGHUP reloads them
+	if _, err := services.Rules.Reload(); err != nil {
+		log.Fatal().Err(err).Msg("Failed to load validation rules")
+	}
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if _, err := services.Rules.Reload(); err != nil {
+				log.Error().Err(err).Msg("Validation rules reload rejected")
+				continue
+			}
+			log.Info().Str("file", services.Rules.Path()).Msg("Validation rules reloaded")
+		}
+	}()
 
 	// Start background job processor
 	go services.Job.StartProcessor(context.Background())
 	log.Info().Msg("Background job processor started")
 
+	// Remove abandoned upload sessions and expired job files in the background
+	sweepCtx, stopSweeper := context.WithCancel(context.Background())
+	go services.Upload.StartSweeper(sweepCtx)
+	go services.Janitor.Start(sweepCtx)
+
 	// Initialize router
 	router := api.NewRouter(services, cfg, log)
 
@@ -81,8 +103,9 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
-	// Stop job processor
+	// Stop job processor, upload sweeper and janitor
 	services.Job.StopProcessor()
+	stopSweeper()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal().Err(err).Msg("Server forced to shutdown")
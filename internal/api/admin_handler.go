@@ -0,0 +1,80 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/bulk-import-export-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// AdminHandler handles operator endpoints
+type AdminHandler struct {
+	services *service.Services
+	log      zerolog.Logger
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(services *service.Services, log zerolog.Logger) *AdminHandler {
+	return &AdminHandler{
+		services: services,
+		log:      log.With().Str("handler", "admin").Logger(),
+	}
+}
+
+// RunCleanup handles POST /v1/admin/cleanup
+// Applies the retention rules now and reports what was removed
+func (h *AdminHandler) RunCleanup(c *gin.Context) {
+	report, err := h.services.Janitor.Run(c.Request.Context())
+	if err != nil {
+		h.log.Error().Err(err).Msg("Cleanup failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cleanup failed"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetRules handles GET /v1/admin/rules
+// Shows the validation rules in effect, defaults included
+func (h *AdminHandler) GetRules(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"file":  h.services.Rules.Path(),
+		"rules": h.services.Rules.Rules().Set(),
+	})
+}
+
+// ReloadRules handles POST /v1/admin/rules/reload
+// Reads the rules file again; imports started afterwards use the new
+// rules. An invalid file is rejected and the rules in effect are kept.
+func (h *AdminHandler) ReloadRules(c *gin.Context) {
+	rules, err := h.services.Rules.Reload()
+	if err != nil {
+		h.log.Warn().Err(err).Str("file", h.services.Rules.Path()).Msg("Validation rules reload rejected")
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	h.log.Info().Str("file", h.services.Rules.Path()).Msg("Validation rules reloaded")
+	c.JSON(http.StatusOK, gin.H{
+		"file":  h.services.Rules.Path(),
+		"rules": rules.Set(),
+	})
+}
+
+// adminAuthMiddleware requires "Authorization: Bearer <token>". Without a
+// configured token the admin endpoints are disabled rather than open.
+func adminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "admin endpoints are disabled; set ADMIN_TOKEN to enable them"})
+			return
+		}
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin token required"})
+			return
+		}
+		c.Next()
+	}
+}
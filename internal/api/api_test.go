@@ -4,9 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +22,8 @@ import (
 	"github.com/bulk-import-export-api/internal/mocks"
 	"github.com/bulk-import-export-api/internal/models"
 	"github.com/bulk-import-export-api/internal/service"
+	"github.com/bulk-import-export-api/internal/storage"
+	"github.com/bulk-import-export-api/internal/validation"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 )
@@ -27,9 +36,11 @@ func setupTestRouter() (*gin.Engine, *mocks.MockImportService, *mocks.MockExport
 	mockJob := mocks.NewMockJobService()
 
 	services := &service.Services{
-		Import: mockImport,
-		Export: mockExport,
-		Job:    mockJob,
+		Import:  mockImport,
+		Export:  mockExport,
+		Job:     mockJob,
+		Upload:  mocks.NewMockUploadService(),
+		Storage: storage.NewMemory(),
 	}
 
 	cfg := &config.Config{
@@ -47,6 +58,23 @@ func setupTestRouter() (*gin.Engine, *mocks.MockImportService, *mocks.MockExport
 	return router, mockImport, mockExport, mockJob
 }
 
+// setupUploadRouter builds a router whose upload service is returned for
+// inspection
+func setupUploadRouter() (*gin.Engine, *mocks.MockUploadService) {
+	gin.SetMode(gin.TestMode)
+
+	mockUpload := mocks.NewMockUploadService()
+	services := &service.Services{
+		Import: mocks.NewMockImportService(),
+		Export: mocks.NewMockExportService(),
+		Job:    mocks.NewMockJobService(),
+		Upload: mockUpload,
+	}
+
+	router := api.NewRouter(services, &config.Config{}, zerolog.Nop())
+	return router, mockUpload
+}
+
 func TestHealthEndpoint(t *testing.T) {
 	router, _, _, _ := setupTestRouter()
 
@@ -178,6 +206,34 @@ func TestGetImportErrors(t *testing.T) {
 	}
 }
 
+func TestGetImportErrors_CountsBySeverity(t *testing.T) {
+	router, _, _, mockJob := setupTestRouter()
+
+	mockJob.Errors["job-1"] = []models.ValidationError{
+		{Line: 1, Field: "email", Message: "invalid email format", Severity: models.SeverityError, Code: "pattern"},
+		{Line: 2, Field: "updated_at", Message: "invalid ISO 8601 date format, import time used instead", Severity: models.SeverityWarning, Code: "timestamp"},
+		{Line: 3, Field: "name", Message: "name is short", Severity: models.SeverityInfo, Code: "min_length"},
+	}
+
+	req := httptest.NewRequest("GET", "/v1/imports/job-1/errors", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response["error_count"].(float64) != 1 || response["warning_count"].(float64) != 1 {
+		t.Errorf("Expected 1 error and 1 warning, got %v and %v", response["error_count"], response["warning_count"])
+	}
+
+	req = httptest.NewRequest("GET", "/v1/imports/job-1/errors?format=csv", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "line,field,message,value,severity,code\n") ||
+		!strings.Contains(w.Body.String(), ",warning,timestamp\n") {
+		t.Errorf("Expected severity and code columns, got %s", w.Body.String())
+	}
+}
+
 func TestGetImportErrors_CSV(t *testing.T) {
 	router, _, _, mockJob := setupTestRouter()
 
@@ -207,6 +263,119 @@ func TestGetImportErrors_CSV(t *testing.T) {
 	}
 }
 
+func TestGetImportErrors_FiltersAndPages(t *testing.T) {
+	router, _, _, mockJob := setupTestRouter()
+
+	mockJob.Errors["job-1"] = []models.ValidationError{
+		{Line: 1, Field: "email", Message: "invalid email format", Code: "pattern"},
+		{Line: 4, Field: "role", Message: "invalid role", Code: "enum"},
+		{Line: 6, Field: "email", Message: "invalid email format", Code: "pattern"},
+		{Line: 8, Field: "email", Message: "invalid email format", Code: "pattern"},
+	}
+
+	var lines []float64
+	url := "/v1/imports/job-1/errors?field=email&limit=2"
+	for url != "" {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var page models.ErrorPage
+		json.Unmarshal(w.Body.Bytes(), &page)
+		if page.ErrorCount != 3 {
+			t.Errorf("Expected the 3 email errors counted, got %d", page.ErrorCount)
+		}
+		for _, e := range page.Errors {
+			lines = append(lines, float64(e.Line))
+		}
+		url = ""
+		if page.NextCursor != "" {
+			url = "/v1/imports/job-1/errors?field=email&limit=2&cursor=" + page.NextCursor
+		}
+	}
+	if len(lines) != 3 || lines[0] != 1 || lines[2] != 8 {
+		t.Errorf("Expected the email errors of lines 1, 6 and 8, got %v", lines)
+	}
+
+	// Streamed as NDJSON, within a line range
+	req := httptest.NewRequest("GET", "/v1/imports/job-1/errors?format=ndjson&from_line=2&to_line=6", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if got := w.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Expected application/x-ndjson, got %s", got)
+	}
+	rows := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(rows) != 2 || !strings.Contains(rows[0], `"line":4`) || !strings.Contains(rows[1], `"line":6`) {
+		t.Errorf("Expected the issues of lines 4 and 6, got %s", w.Body.String())
+	}
+}
+
+func TestGetImportErrors_InvalidQuery(t *testing.T) {
+	router, _, _, _ := setupTestRouter()
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedError string
+	}{
+		{"bad cursor", "cursor=not-a-cursor", "invalid cursor"},
+		{"bad limit", "limit=0", "limit must be between"},
+		{"bad line", "from_line=abc", "from_line must be a positive line number"},
+		{"reversed lines", "format=csv&from_line=9&to_line=2", "from_line must not be after to_line"},
+		{"bad severity", "severity=fatal", "severity must be"},
+		{"bad format", "format=xml", "format must be json, csv or ndjson"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/imports/job-1/errors?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %s", tt.expectedError, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetImportErrorSummary(t *testing.T) {
+	router, _, _, mockJob := setupTestRouter()
+
+	mockJob.Errors["job-1"] = []models.ValidationError{
+		{Line: 1, Field: "email", Message: "invalid email format", Code: "pattern"},
+		{Line: 2, Field: "updated_at", Message: "import time used instead", Severity: models.SeverityWarning, Code: "timestamp"},
+		{Line: 3, Field: "email", Message: "invalid email format", Code: "pattern"},
+	}
+
+	req := httptest.NewRequest("GET", "/v1/imports/job-1/errors/summary", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var summary models.ErrorSummary
+	json.Unmarshal(w.Body.Bytes(), &summary)
+	if summary.ErrorCount != 2 || summary.WarningCount != 1 {
+		t.Errorf("Expected 2 errors and 1 warning, got %+v", summary)
+	}
+	if len(summary.Groups) != 2 || summary.Groups[0].Field != "email" || summary.Groups[0].Count != 2 {
+		t.Errorf("Expected the email errors grouped, got %+v", summary.Groups)
+	}
+
+	req = httptest.NewRequest("GET", "/v1/imports/job-1/errors/summary?severity=fatal", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown severity, got %d", w.Code)
+	}
+}
+
 func TestExportStream_ValidationErrors(t *testing.T) {
 	router, _, _, _ := setupTestRouter()
 
@@ -235,10 +404,10 @@ func TestExportStream_ValidationErrors(t *testing.T) {
 			expectedError:  "format must be one of",
 		},
 		{
-			name:           "csv not supported for articles",
-			url:            "/v1/exports?resource=articles&format=csv",
+			name:           "unknown format",
+			url:            "/v1/exports?resource=articles&format=yaml",
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "CSV format only supported for users",
+			expectedError:  "format must be one of: ndjson, json, csv, parquet, arrow, xlsx",
 		},
 	}
 
@@ -259,6 +428,183 @@ func TestExportStream_ValidationErrors(t *testing.T) {
 	}
 }
 
+func TestExportStream_Incremental(t *testing.T) {
+	cursor := service.EncodeChangeCursor(models.ChangeCursor{UpdatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), ID: "u1"})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedError  string
+		expectedAfter  models.ChangeCursor
+	}{
+		{"updated_since", "&updated_since=2024-03-01T00:00:00Z", http.StatusOK, "", models.ChangeCursor{UpdatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}},
+		{"cursor", "&cursor=" + cursor, http.StatusOK, "", models.ChangeCursor{UpdatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), ID: "u1"}},
+		{"invalid updated_since", "&updated_since=yesterday", http.StatusBadRequest, "updated_since must be an RFC3339 timestamp", models.ChangeCursor{}},
+		{"invalid cursor", "&cursor=garbage!", http.StatusBadRequest, "invalid cursor", models.ChangeCursor{}},
+		{"both", "&updated_since=2024-03-01T00:00:00Z&cursor=" + cursor, http.StatusBadRequest, "not both", models.ChangeCursor{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _, mockExport, _ := setupTestRouter()
+
+			called := false
+			var gotAfter models.ChangeCursor
+			mockExport.StreamChangesFunc = func(ctx context.Context, w http.ResponseWriter, resource, format string, after models.ChangeCursor) error {
+				called, gotAfter = true, after
+				return nil
+			}
+
+			req := httptest.NewRequest("GET", "/v1/exports?resource=users"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" && !strings.Contains(w.Body.String(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %s", tt.expectedError, w.Body.String())
+			}
+			if called != (tt.expectedStatus == http.StatusOK) {
+				t.Errorf("Expected StreamChanges called: %v, got %v", tt.expectedStatus == http.StatusOK, called)
+			}
+			if called && (!gotAfter.UpdatedAt.Equal(tt.expectedAfter.UpdatedAt) || gotAfter.ID != tt.expectedAfter.ID) {
+				t.Errorf("Expected cursor %+v, got %+v", tt.expectedAfter, gotAfter)
+			}
+		})
+	}
+}
+
+func TestExportStream_Resume(t *testing.T) {
+	token := service.EncodePageCursor(models.PageCursor{CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), ID: "u2"})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"valid token", "&resume=" + token, http.StatusOK, ""},
+		{"invalid token", "&resume=garbage!", http.StatusBadRequest, "invalid resume token"},
+		{"with updated_since", "&resume=" + token + "&updated_since=2024-03-01T00:00:00Z", http.StatusBadRequest, "resume cannot be combined"},
+		{"csv", "&format=csv&resume=" + token, http.StatusBadRequest, "only supported for ndjson"},
+		{"parquet", "&format=parquet&resume=" + token, http.StatusBadRequest, "only supported for ndjson"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _, mockExport, _ := setupTestRouter()
+
+			var got *models.PageCursor
+			mockExport.StreamFromFunc = func(ctx context.Context, w http.ResponseWriter, resource, format string, after models.PageCursor) error {
+				got = &after
+				return nil
+			}
+
+			req := httptest.NewRequest("GET", "/v1/exports?resource=articles"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" && !strings.Contains(w.Body.String(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %s", tt.expectedError, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK && (got == nil || got.ID != "u2") {
+				t.Errorf("Expected StreamFrom called after u2, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestListResources(t *testing.T) {
+	cursor := service.EncodePageCursor(models.PageCursor{CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), ID: "u2"})
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedError  string
+		expectedLimit  int
+	}{
+		{"default limit", "/v1/resources/users", http.StatusOK, "", service.DefaultPageLimit},
+		{"limit and cursor", "/v1/resources/articles?limit=10000&cursor=" + cursor, http.StatusOK, "", 10000},
+		{"invalid resource", "/v1/resources/tags", http.StatusBadRequest, "resource must be one of", 0},
+		{"limit too large", "/v1/resources/users?limit=10001", http.StatusBadRequest, "limit must be between 1 and 10000", 0},
+		{"limit not a number", "/v1/resources/users?limit=ten", http.StatusBadRequest, "limit must be between 1 and 10000", 0},
+		{"invalid cursor", "/v1/resources/users?cursor=garbage!", http.StatusBadRequest, "invalid cursor", 0},
+		{"invalid updated_since", "/v1/resources/users?updated_since=today", http.StatusBadRequest, "updated_since must be an RFC3339 timestamp", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _, mockExport, _ := setupTestRouter()
+
+			var got *models.ExportFilter
+			mockExport.ListPageFunc = func(ctx context.Context, resource string, filter models.ExportFilter) (*models.ResourcePage, error) {
+				got = &filter
+				return &models.ResourcePage{Resource: resource, Data: []byte(`[{"id":"u3"}]`), Count: 1, Limit: filter.Limit, NextCursor: "next"}, nil
+			}
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" {
+				if !strings.Contains(w.Body.String(), tt.expectedError) {
+					t.Errorf("Expected error containing %q, got %s", tt.expectedError, w.Body.String())
+				}
+				return
+			}
+
+			if got == nil || got.Limit != tt.expectedLimit {
+				t.Fatalf("Expected limit %d passed to the service, got %+v", tt.expectedLimit, got)
+			}
+			if strings.Contains(tt.url, "cursor=") && (got.After == nil || got.After.ID != "u2") {
+				t.Errorf("Expected cursor position u2, got %+v", got.After)
+			}
+
+			var page map[string]any
+			json.Unmarshal(w.Body.Bytes(), &page)
+			if page["next_cursor"] != "next" || len(page["data"].([]any)) != 1 {
+				t.Errorf("Expected page with data and next_cursor, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestExportBundle(t *testing.T) {
+	router, _, mockExport, _ := setupTestRouter()
+
+	var gotFormat string
+	mockExport.StreamBundleFunc = func(ctx context.Context, w http.ResponseWriter, format string) error {
+		gotFormat = format
+		w.Header().Set("Content-Type", "application/zip")
+		return nil
+	}
+
+	req := httptest.NewRequest("GET", "/v1/exports/bundle", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || gotFormat != "ndjson" {
+		t.Errorf("Expected bundle export in ndjson, got status %d format %q", w.Code, gotFormat)
+	}
+
+	req = httptest.NewRequest("GET", "/v1/exports/bundle?format=xml", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "format must be one of") {
+		t.Errorf("Expected 400 for unknown format, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
 func TestImportValidation(t *testing.T) {
 	router, _, _, _ := setupTestRouter()
 
@@ -359,25 +705,25 @@ func TestImportWithWrongFileExtension(t *testing.T) {
 		expectedError  string
 	}{
 		{
-			name:           "users with ndjson file",
+			name:           "users with txt file",
 			resource:       "users",
-			filename:       "users.ndjson",
+			filename:       "users.txt",
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "users import requires CSV file",
+			expectedError:  "unsupported file format, must be one of: ndjson, json, csv, parquet, arrow, xlsx",
 		},
 		{
-			name:           "articles with csv file",
+			name:           "articles with xml file",
 			resource:       "articles",
-			filename:       "articles.csv",
+			filename:       "articles.xml",
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "articles/comments import requires NDJSON file",
+			expectedError:  "unsupported file format",
 		},
 		{
-			name:           "comments with csv file",
+			name:           "comments without extension",
 			resource:       "comments",
-			filename:       "comments.csv",
+			filename:       "comments",
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "articles/comments import requires NDJSON file",
+			expectedError:  "unsupported file format",
 		},
 	}
 
@@ -405,6 +751,540 @@ func TestImportWithWrongFileExtension(t *testing.T) {
 	}
 }
 
+func TestImportAcceptsParquet(t *testing.T) {
+	router, mockImport, _, _ := setupTestRouter()
+
+	for _, resource := range []string{"users", "articles", "comments"} {
+		t.Run(resource, func(t *testing.T) {
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			writer.WriteField("resource", resource)
+			part, _ := writer.CreateFormFile("file", resource+".parquet")
+			part.Write([]byte("PAR1"))
+			writer.Close()
+
+			req := httptest.NewRequest("POST", "/v1/imports", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusAccepted {
+				t.Errorf("Expected status 202, got %d. Body: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if len(mockImport.CreatedJobs) != 3 {
+		t.Errorf("Expected 3 import jobs, got %d", len(mockImport.CreatedJobs))
+	}
+}
+
+func TestImportFormatSelection(t *testing.T) {
+	router, mockImport, _, _ := setupTestRouter()
+
+	var capturedPath string
+	mockImport.CreateJobFunc = func(ctx context.Context, req *models.ImportRequest, filePath string) (*models.Job, error) {
+		capturedPath = filePath
+		return &models.Job{ID: "job", Resource: req.Resource, Status: models.JobStatusPending}, nil
+	}
+
+	tests := []struct {
+		name        string
+		resource    string
+		filename    string
+		contentType string
+		format      string
+		expectedExt string
+	}{
+		{name: "csv for articles", resource: "articles", filename: "articles.csv", expectedExt: ".csv"},
+		{name: "ndjson for users", resource: "users", filename: "users.ndjson", expectedExt: ".ndjson"},
+		{name: "jsonl alias", resource: "comments", filename: "comments.jsonl", expectedExt: ".ndjson"},
+		{name: "explicit format", resource: "users", filename: "export.bin", format: "parquet", expectedExt: ".parquet"},
+		{name: "mime type fallback", resource: "comments", filename: "upload", contentType: "application/x-ndjson; charset=utf-8", expectedExt: ".ndjson"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			writer.WriteField("resource", tt.resource)
+			if tt.format != "" {
+				writer.WriteField("format", tt.format)
+			}
+			partHeader := textproto.MIMEHeader{}
+			partHeader.Set("Content-Disposition", `form-data; name="file"; filename="`+tt.filename+`"`)
+			if tt.contentType != "" {
+				partHeader.Set("Content-Type", tt.contentType)
+			}
+			part, _ := writer.CreatePart(partHeader)
+			part.Write([]byte("data\n"))
+			writer.Close()
+
+			req := httptest.NewRequest("POST", "/v1/imports", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusAccepted {
+				t.Fatalf("Expected status 202, got %d. Body: %s", w.Code, w.Body.String())
+			}
+			if !strings.HasSuffix(capturedPath, tt.expectedExt) {
+				t.Errorf("Expected stored file to end in %s, got %s", tt.expectedExt, capturedPath)
+			}
+		})
+	}
+}
+
+func TestImportXLSX_SheetOption(t *testing.T) {
+	router, mockImport, _, _ := setupTestRouter()
+
+	var captured *models.ImportRequest
+	mockImport.CreateJobFunc = func(ctx context.Context, req *models.ImportRequest, filePath string) (*models.Job, error) {
+		captured = req
+		return &models.Job{ID: "xlsx-job", Resource: req.Resource, Status: models.JobStatusPending}, nil
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("resource", "users")
+	writer.WriteField("sheet", "Team")
+	part, _ := writer.CreateFormFile("file", "users.xlsx")
+	part.Write([]byte("PK"))
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/v1/imports", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if captured == nil || captured.Options == nil || captured.Options.Sheet != "Team" {
+		t.Errorf("Expected sheet option 'Team' to be passed to the import job, got %+v", captured)
+	}
+}
+
+func TestImportBundle(t *testing.T) {
+	tests := []struct {
+		name           string
+		filename       string
+		bundleErr      error
+		expectedStatus int
+		expectedError  string
+	}{
+		{"zip archive", "data.zip", nil, http.StatusAccepted, ""},
+		{"tar.gz archive", "data.tar.gz", nil, http.StatusAccepted, ""},
+		{"not an archive", "users.csv", nil, http.StatusBadRequest, "bundle import requires"},
+		{"invalid bundle", "data.zip", fmt.Errorf("%w: no users, articles or comments file found", service.ErrInvalidBundle), http.StatusBadRequest, "no users, articles or comments file found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockImport, _, _ := setupTestRouter()
+
+			var archivePath string
+			mockImport.CreateBundleFunc = func(ctx context.Context, req *models.ImportRequest, path string) (*models.Job, error) {
+				archivePath = path
+				if tt.bundleErr != nil {
+					return nil, tt.bundleErr
+				}
+				return &models.Job{ID: "bundle-job", Resource: req.Resource, Status: models.JobStatusPending}, nil
+			}
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			writer.WriteField("resource", "bundle")
+			part, _ := writer.CreateFormFile("file", tt.filename)
+			part.Write([]byte("PK"))
+			writer.Close()
+
+			req := httptest.NewRequest("POST", "/v1/imports", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" && !strings.Contains(w.Body.String(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %s", tt.expectedError, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusAccepted {
+				if !strings.HasSuffix(archivePath, tt.filename[strings.Index(tt.filename, "."):]) {
+					t.Errorf("Expected archive saved with its extension, got %s", archivePath)
+				}
+			}
+		})
+	}
+}
+
+func TestImportStreamMode(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		contentType    string
+		accept         string
+		streamErr      error
+		expectedStatus int
+		expectedFormat string
+		expectedBody   string
+	}{
+		{"ndjson by content type", "resource=users&mode=stream", "application/x-ndjson", "", nil, http.StatusOK, "ndjson", `"job_id":"stream-job"`},
+		{"csv by format param", "resource=users&mode=stream&format=csv", "application/octet-stream", "", nil, http.StatusOK, "csv", `"job_id":"stream-job"`},
+		{"per-line results", "resource=users&mode=stream", "text/csv", "application/x-ndjson", nil, http.StatusOK, "csv", `{"line":2,"status":"failed"}`},
+		{"unsupported format", "resource=users&mode=stream&format=parquet", "", "", nil, http.StatusBadRequest, "", "requires an ndjson or csv body"},
+		{"bundle", "resource=bundle&mode=stream", "text/csv", "", nil, http.StatusBadRequest, "", "bundles cannot be imported"},
+		{"unknown mode", "resource=users&mode=later", "text/csv", "", nil, http.StatusBadRequest, "", "mode must be one of"},
+		{"body too large", "resource=users&mode=stream", "text/csv", "", &http.MaxBytesError{Limit: 10}, http.StatusRequestEntityTooLarge, "csv", "body too large"},
+		{"fatal read error", "resource=users&mode=stream", "text/csv", "", fmt.Errorf("missing header"), http.StatusBadRequest, "csv", "missing header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockImport, _, _ := setupTestRouter()
+
+			var gotFormat string
+			mockImport.StreamFunc = func(ctx context.Context, req *models.ImportRequest, format string, body io.Reader, report func(models.LineResult) error) (*models.Job, error) {
+				gotFormat = format
+				if report != nil {
+					report(models.LineResult{Line: 1, Status: models.LineImported})
+					report(models.LineResult{Line: 2, Status: models.LineFailed})
+				}
+				return &models.Job{ID: "stream-job", Resource: req.Resource, Status: models.JobStatusCompleted}, tt.streamErr
+			}
+
+			req := httptest.NewRequest("POST", "/v1/imports?"+tt.query, strings.NewReader("id,email\n"))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if gotFormat != tt.expectedFormat {
+				t.Errorf("Expected format %q, got %q", tt.expectedFormat, gotFormat)
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body containing %s, got %s", tt.expectedBody, w.Body.String())
+			}
+			if tt.accept != "" {
+				lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+				if len(lines) != 3 || !strings.HasPrefix(lines[2], `{"summary":`) {
+					t.Errorf("Expected 2 result lines and a summary line, got %q", lines)
+				}
+			}
+		})
+	}
+}
+
+func TestImportTransformsParam(t *testing.T) {
+	tests := []struct {
+		name           string
+		transforms     string
+		streamErr      error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"passed to the import", `[{"name":"trim"},{"name":"lowercase","field":"email"}]`, nil, http.StatusOK, `"job_id":"stream-job"`},
+		{"not json", `trim`, nil, http.StatusBadRequest, "transforms must be a JSON array"},
+		{"rejected by the service", `[{"name":"uppercase","field":"email"}]`, fmt.Errorf("%w: transform 1 (uppercase): unknown transform", service.ErrInvalidTransform), http.StatusBadRequest, "unknown transform"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockImport, _, _ := setupTestRouter()
+
+			var got []models.Transform
+			mockImport.StreamFunc = func(ctx context.Context, req *models.ImportRequest, format string, body io.Reader, report func(models.LineResult) error) (*models.Job, error) {
+				got = req.Options.Transforms
+				if tt.streamErr != nil {
+					return nil, tt.streamErr
+				}
+				return &models.Job{ID: "stream-job", Resource: req.Resource, Status: models.JobStatusCompleted}, nil
+			}
+
+			query := "resource=users&mode=stream&transforms=" + url.QueryEscape(tt.transforms)
+			req := httptest.NewRequest("POST", "/v1/imports?"+query, strings.NewReader("id,email\n"))
+			req.Header.Set("Content-Type", "text/csv")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body containing %s, got %s", tt.expectedBody, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK && (len(got) != 2 || got[1].Field != "email") {
+				t.Errorf("Expected 2 transforms passed on, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestImportDateOptionsParams(t *testing.T) {
+	router, mockImport, _, _ := setupTestRouter()
+
+	var got *models.ImportOptions
+	mockImport.StreamFunc = func(ctx context.Context, req *models.ImportRequest, format string, body io.Reader, report func(models.LineResult) error) (*models.Job, error) {
+		got = req.Options
+		if got.Timezone == "Nowhere/Special" {
+			return nil, fmt.Errorf("%w: unknown timezone %q", service.ErrInvalidDateOptions, got.Timezone)
+		}
+		return &models.Job{ID: "stream-job", Resource: req.Resource, Status: models.JobStatusCompleted}, nil
+	}
+
+	req := httptest.NewRequest("POST", "/v1/imports?resource=users&mode=stream&date_layouts=datetime&date_layouts=unix&timezone=Europe/Berlin",
+		strings.NewReader("id,email\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if got == nil || len(got.DateLayouts) != 2 || got.DateLayouts[1] != "unix" || got.Timezone != "Europe/Berlin" {
+		t.Errorf("Expected the date options passed on, got %+v", got)
+	}
+
+	req = httptest.NewRequest("POST", "/v1/imports?resource=users&mode=stream&timezone=Nowhere/Special", strings.NewReader("id,email\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "unknown timezone") {
+		t.Errorf("Expected 400 for an unknown timezone, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetImportTransforms(t *testing.T) {
+	router, _, _, mockJob := setupTestRouter()
+
+	mockJob.Transforms["job-1"] = []models.TransformRecord{
+		{Line: 1, Field: "email", Transform: "lowercase", Before: "ADA@X.COM", After: "ada@x.com"},
+		{Line: 3, Field: "slug", Transform: "slugify", Before: "", After: "hello-world"},
+	}
+
+	req := httptest.NewRequest("GET", "/v1/imports/job-1/transforms", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response struct {
+		TransformCount int                      `json:"transform_count"`
+		Transforms     []models.TransformRecord `json:"transforms"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.TransformCount != 2 || response.Transforms[1].After != "hello-world" {
+		t.Errorf("Expected 2 transforms, got %+v", response)
+	}
+
+	req = httptest.NewRequest("GET", "/v1/imports/job-1/transforms?format=csv", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Expected text/csv, got %s", ct)
+	}
+	if !strings.HasPrefix(w.Body.String(), "line,field,transform,before,after\n1,email,lowercase,ADA@X.COM,ada@x.com\n") {
+		t.Errorf("Expected CSV audit rows, got %s", w.Body.String())
+	}
+}
+
+func TestGetImportRejects(t *testing.T) {
+	router, _, _, mockJob := setupTestRouter()
+
+	mockJob.Jobs["job-csv"] = &models.JobResponse{Job: models.Job{ID: "job-csv", Type: models.JobTypeImport, Resource: "users", SourceHeader: []string{"Email", "Name"}}}
+	mockJob.Jobs["job-bundle"] = &models.JobResponse{Job: models.Job{ID: "job-bundle", Type: models.JobTypeImport, Resource: models.ResourceBundle}}
+	mockJob.Rejects["job-csv"] = "Email,Name,_errors\nnot-an-email,Bob,email: invalid email format\n"
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		contentType string
+	}{
+		{"source format", "/v1/imports/job-csv/rejects", http.StatusOK, "text/csv"},
+		{"requested format", "/v1/imports/job-csv/rejects?format=ndjson", http.StatusOK, "application/x-ndjson"},
+		{"unknown format", "/v1/imports/job-csv/rejects?format=xlsx", http.StatusBadRequest, ""},
+		{"bundle", "/v1/imports/job-bundle/rejects", http.StatusBadRequest, ""},
+		{"unknown job", "/v1/imports/missing/rejects", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.contentType == "" {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Expected %s, got %s", tt.contentType, ct)
+			}
+			if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment; filename=rejects_job-csv.") {
+				t.Errorf("Expected a rejects attachment, got %q", cd)
+			}
+			if w.Body.String() != mockJob.Rejects["job-csv"] {
+				t.Errorf("Expected the rejects file, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRetryImport(t *testing.T) {
+	router, mockImport, _, _ := setupTestRouter()
+
+	var gotJobID, gotPath string
+	var gotOptions *models.ImportOptions
+	mockImport.RetryFunc = func(ctx context.Context, jobID string, req *models.ImportRequest, filePath string) (*models.Job, error) {
+		gotJobID, gotPath, gotOptions = jobID, filePath, req.Options
+		switch jobID {
+		case "missing":
+			return nil, service.ErrJobNotFound
+		case "running":
+			return nil, fmt.Errorf("%w: job is processing, only finished imports can be retried", service.ErrInvalidRetry)
+		}
+		return &models.Job{ID: "retry-1", ParentJobID: jobID, Resource: "users", Status: models.JobStatusPending}, nil
+	}
+
+	// Without a file the stored rejects are retried
+	req := httptest.NewRequest("POST", "/v1/imports/job-1/retry", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if gotJobID != "job-1" || gotPath != "" || gotOptions != nil {
+		t.Errorf("Expected a retry of job-1 without a file, got %q %q %+v", gotJobID, gotPath, gotOptions)
+	}
+	var response map[string]any
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response["job_id"] != "retry-1" || response["parent_job_id"] != "job-1" {
+		t.Errorf("Expected the retry job linked to its parent, got %v", response)
+	}
+
+	// A fixed file is stored and imported, with options
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("timezone", "Europe/Berlin")
+	part, _ := writer.CreateFormFile("file", "rejects_job-1.csv")
+	part.Write([]byte("email,_errors\nbob@example.com,email: invalid email format\n"))
+	writer.Close()
+
+	req = httptest.NewRequest("POST", "/v1/imports/job-1/retry", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if !strings.HasSuffix(gotPath, ".csv") || gotOptions == nil || gotOptions.Timezone != "Europe/Berlin" {
+		t.Errorf("Expected the CSV file and its options passed on, got %q %+v", gotPath, gotOptions)
+	}
+
+	for path, want := range map[string]int{
+		"/v1/imports/missing/retry": http.StatusNotFound,
+		"/v1/imports/running/retry": http.StatusBadRequest,
+	} {
+		req = httptest.NewRequest("POST", path, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%s: expected status %d, got %d. Body: %s", path, want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestUploadSession(t *testing.T) {
+	router, mockUpload := setupUploadRouter()
+
+	// Create
+	req := httptest.NewRequest("POST", "/v1/uploads", strings.NewReader(`{"resource":"users","format":"csv","size":10}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); loc != "/v1/uploads/test-upload-id" {
+		t.Errorf("Expected Location of the session, got %q", loc)
+	}
+
+	steps := []struct {
+		name           string
+		method         string
+		path           string
+		offset         string
+		body           string
+		expectedStatus int
+		expectedOffset string
+	}{
+		{"first chunk", "PATCH", "/v1/uploads/test-upload-id", "0", "users", http.StatusNoContent, "5"},
+		{"stale offset", "PATCH", "/v1/uploads/test-upload-id", "0", "users", http.StatusConflict, "5"},
+		{"missing offset", "PATCH", "/v1/uploads/test-upload-id", "", "users", http.StatusBadRequest, ""},
+		{"query offset", "HEAD", "/v1/uploads/test-upload-id", "", "", http.StatusOK, "5"},
+		{"complete too early", "POST", "/v1/uploads/test-upload-id/complete", "", "", http.StatusConflict, ""},
+		{"last chunk", "PATCH", "/v1/uploads/test-upload-id", "5", "12345", http.StatusNoContent, "10"},
+		{"complete", "POST", "/v1/uploads/test-upload-id/complete", "", "", http.StatusAccepted, ""},
+		{"unknown session", "HEAD", "/v1/uploads/nope", "", "", http.StatusNotFound, ""},
+		{"delete", "DELETE", "/v1/uploads/test-upload-id", "", "", http.StatusNoContent, ""},
+		{"deleted", "GET", "/v1/uploads/test-upload-id", "", "", http.StatusNotFound, ""},
+	}
+
+	for _, step := range steps {
+		req := httptest.NewRequest(step.method, step.path, strings.NewReader(step.body))
+		if step.offset != "" {
+			req.Header.Set("Upload-Offset", step.offset)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != step.expectedStatus {
+			t.Fatalf("%s: expected status %d, got %d. Body: %s", step.name, step.expectedStatus, w.Code, w.Body.String())
+		}
+		if step.expectedOffset != "" && w.Header().Get("Upload-Offset") != step.expectedOffset {
+			t.Errorf("%s: expected Upload-Offset %s, got %q", step.name, step.expectedOffset, w.Header().Get("Upload-Offset"))
+		}
+	}
+
+	if len(mockUpload.Uploads) != 0 {
+		t.Errorf("Expected the session to be deleted, got %d", len(mockUpload.Uploads))
+	}
+}
+
+func TestCreateUpload_Errors(t *testing.T) {
+	router, mockUpload := setupUploadRouter()
+	mockUpload.CreateFunc = func(ctx context.Context, req *models.UploadRequest) (*models.Upload, error) {
+		return nil, fmt.Errorf("%w: unsupported file format", service.ErrInvalidUpload)
+	}
+
+	for _, body := range []string{`not json`, `{"resource":"users","format":"txt","size":10}`} {
+		req := httptest.NewRequest("POST", "/v1/uploads", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
+
 func TestCreateExport_Validation(t *testing.T) {
 	router, _, _, _ := setupTestRouter()
 
@@ -445,6 +1325,235 @@ func TestCreateExport_Validation(t *testing.T) {
 	}
 }
 
+func TestImportFromObjectStore(t *testing.T) {
+	router, mockImport, _, _ := setupTestRouter()
+
+	var gotPath string
+	var gotOpts *models.ImportOptions
+	mockImport.CreateJobFunc = func(ctx context.Context, req *models.ImportRequest, filePath string) (*models.Job, error) {
+		gotPath, gotOpts = filePath, req.Options
+		return &models.Job{ID: "s3-job", Resource: req.Resource, Status: models.JobStatusPending}, nil
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedFormat string
+	}{
+		{"format from key", `{"resource":"users","file_url":"s3://imports/users.ndjson"}`, http.StatusAccepted, "ndjson"},
+		{"explicit format", `{"resource":"users","file_url":"s3://imports/dump","format":"csv"}`, http.StatusAccepted, "csv"},
+		{"unknown format", `{"resource":"users","file_url":"s3://imports/users.txt"}`, http.StatusBadRequest, ""},
+		{"no key", `{"resource":"users","file_url":"s3://imports"}`, http.StatusBadRequest, ""},
+		{"bundle", `{"resource":"bundle","file_url":"s3://imports/backup.zip"}`, http.StatusBadRequest, ""},
+		{"http url", `{"resource":"users","file_url":"https://example.com/users.csv"}`, http.StatusNotImplemented, ""},
+		{"no file_url", `{"resource":"users"}`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPath, gotOpts = "", nil
+			req := httptest.NewRequest("POST", "/v1/imports", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedFormat == "" {
+				return
+			}
+			if !strings.HasPrefix(gotPath, "s3://imports/") {
+				t.Errorf("Expected the job to read the object URL, got %q", gotPath)
+			}
+			if gotOpts == nil || gotOpts.Format != tt.expectedFormat {
+				t.Errorf("Expected format %s in job options, got %+v", tt.expectedFormat, gotOpts)
+			}
+		})
+	}
+}
+
+func TestCreateExport_Destination(t *testing.T) {
+	router, _, mockExport, _ := setupTestRouter()
+
+	var got *models.ExportRequest
+	mockExport.CreateJobFunc = func(ctx context.Context, req *models.ExportRequest) (*models.Job, error) {
+		got = req
+		if req.Destination == "s3://exports" {
+			return nil, fmt.Errorf("%w: needs a key", service.ErrInvalidExport)
+		}
+		return &models.Job{ID: "export-job", Type: models.JobTypeExport, Resource: req.Resource, Status: models.JobStatusPending, FilePath: req.Destination}, nil
+	}
+
+	body := `{"resource":"users","format":"parquet","destination":"s3://exports/users.parquet"}`
+	req := httptest.NewRequest("POST", "/v1/exports", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "nightly")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if got == nil || got.Format != "parquet" || got.IdempotencyKey != "nightly" {
+		t.Errorf("Expected parquet export job with idempotency key, got %+v", got)
+	}
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["job_id"] != "export-job" || resp["destination"] != "s3://exports/users.parquet" {
+		t.Errorf("Expected job id and destination in response, got %v", resp)
+	}
+
+	req = httptest.NewRequest("POST", "/v1/exports", bytes.NewBufferString(`{"resource":"users","destination":"s3://exports"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid destination, got %d", w.Code)
+	}
+}
+
+// setupAdminRouter builds a router with the given admin token whose
+// janitor is returned for inspection
+func setupAdminRouter(token string) (*gin.Engine, *mocks.MockJanitorService) {
+	gin.SetMode(gin.TestMode)
+
+	mockJanitor := mocks.NewMockJanitorService()
+	services := &service.Services{
+		Import:  mocks.NewMockImportService(),
+		Export:  mocks.NewMockExportService(),
+		Job:     mocks.NewMockJobService(),
+		Upload:  mocks.NewMockUploadService(),
+		Janitor: mockJanitor,
+	}
+	cfg := &config.Config{Retention: config.RetentionConfig{AdminToken: token}}
+
+	router := api.NewRouter(services, cfg, zerolog.Nop())
+	return router, mockJanitor
+}
+
+func TestAdminCleanup(t *testing.T) {
+	router, mockJanitor := setupAdminRouter("secret")
+	mockJanitor.RunFunc = func(ctx context.Context) (*models.CleanupReport, error) {
+		return &models.CleanupReport{
+			UploadsDeleted: 1,
+			JobsDeleted:    2,
+			BytesFreed:     42,
+			Removed:        []models.RemovedArtifact{{JobID: "job-1", Location: "users.csv", Size: 42}},
+		}, nil
+	}
+
+	req := httptest.NewRequest("POST", "/v1/admin/cleanup", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var report models.CleanupReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if report.UploadsDeleted != 1 || report.JobsDeleted != 2 || report.BytesFreed != 42 || len(report.Removed) != 1 {
+		t.Errorf("Expected the cleanup report, got %+v", report)
+	}
+}
+
+func TestAdminCleanup_RequiresToken(t *testing.T) {
+	router, mockJanitor := setupAdminRouter("secret")
+
+	for _, auth := range []string{"", "Bearer wrong", "secret"} {
+		req := httptest.NewRequest("POST", "/v1/admin/cleanup", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for Authorization %q, got %d", auth, w.Code)
+		}
+	}
+	if mockJanitor.Runs != 0 {
+		t.Errorf("Expected no cleanup without the token, got %d runs", mockJanitor.Runs)
+	}
+
+	req := httptest.NewRequest("POST", "/v1/admin/cleanup", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || mockJanitor.Runs != 1 {
+		t.Errorf("Expected status 200 and one run with the token, got %d and %d runs", w.Code, mockJanitor.Runs)
+	}
+}
+
+func TestAdmin_DisabledWithoutToken(t *testing.T) {
+	router, mockJanitor := setupAdminRouter("")
+
+	for _, path := range []string{"/v1/admin/cleanup", "/v1/admin/rules/reload"} {
+		req := httptest.NewRequest("POST", path, nil)
+		req.Header.Set("Authorization", "Bearer ")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503 for %s without a configured token, got %d", path, w.Code)
+		}
+	}
+	if mockJanitor.Runs != 0 {
+		t.Errorf("Expected no cleanup without a configured token, got %d runs", mockJanitor.Runs)
+	}
+}
+
+func TestAdminReloadRules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte("comments: [{field: body, max_words: 50}]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules := validation.NewRuleStore(path)
+	services := &service.Services{
+		Import: mocks.NewMockImportService(),
+		Export: mocks.NewMockExportService(),
+		Job:    mocks.NewMockJobService(),
+		Upload: mocks.NewMockUploadService(),
+		Rules:  rules,
+	}
+	router := api.NewRouter(services, &config.Config{Retention: config.RetentionConfig{AdminToken: "secret"}}, zerolog.Nop())
+	admin := func(method, path string) *http.Request {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, admin("POST", "/v1/admin/rules/reload"))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"max_words":50`) {
+		t.Fatalf("Expected status 200 with the reloaded rules, got %d: %s", w.Code, w.Body.String())
+	}
+	loaded := rules.Rules()
+
+	if err := os.WriteFile(path, []byte("comments: [{field: mood}]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, admin("POST", "/v1/admin/rules/reload"))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for an invalid file, got %d", w.Code)
+	}
+	if rules.Rules() != loaded {
+		t.Error("Expected the previous rules kept")
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, admin("GET", "/v1/admin/rules"))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"max_words":50`) {
+		t.Errorf("Expected the rules in effect, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
 func TestGetExportStatus_NotFound(t *testing.T) {
 	router, _, _, _ := setupTestRouter()
 
@@ -479,6 +1588,56 @@ func TestGetImportErrors_EmptyErrors(t *testing.T) {
 	}
 }
 
+func TestListExternalIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockIDs := mocks.NewMockExternalIDService()
+	mockIDs.ListFunc = func(ctx context.Context, filter models.ExternalIDFilter) (*models.ExternalIDPage, error) {
+		if filter.Source == "" && filter.InternalID == "" {
+			return nil, fmt.Errorf("%w: source or internal_id is required", service.ErrInvalidExternalIDQuery)
+		}
+		return &models.ExternalIDPage{
+			Resource: filter.Resource,
+			Data:     []models.ExternalID{{Resource: filter.Resource, Source: filter.Source, ExternalID: "u-1", InternalID: "550e8400-e29b-41d4-a716-446655440000"}},
+			Count:    1,
+			Limit:    filter.Limit,
+		}, nil
+	}
+	services := &service.Services{
+		Import:      mocks.NewMockImportService(),
+		Export:      mocks.NewMockExportService(),
+		Job:         mocks.NewMockJobService(),
+		Upload:      mocks.NewMockUploadService(),
+		ExternalIDs: mockIDs,
+	}
+	router := api.NewRouter(services, &config.Config{}, zerolog.Nop())
+
+	cursor := service.EncodeExternalIDCursor("u-0")
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+	}{
+		{"by source", "/v1/external-ids/users?source=crm&limit=10&cursor=" + cursor, http.StatusOK},
+		{"missing source", "/v1/external-ids/users", http.StatusBadRequest},
+		{"invalid limit", "/v1/external-ids/users?source=crm&limit=0", http.StatusBadRequest},
+		{"invalid cursor", "/v1/external-ids/users?source=crm&cursor=%21", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	first := mockIDs.Filters[0]
+	if first.Resource != "users" || first.Source != "crm" || first.Limit != 10 || first.After != "u-0" {
+		t.Errorf("Expected filter for users/crm after u-0 limit 10, got %+v", first)
+	}
+}
+
 // Placeholder for unused imports
 var _ context.Context
 var _ api.ImportHandler
@@ -1,8 +1,15 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	formats "github.com/bulk-import-export-api/internal/format"
+	"github.com/bulk-import-export-api/internal/models"
 	"github.com/bulk-import-export-api/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
@@ -22,7 +29,7 @@ func NewExportHandler(services *service.Services, log zerolog.Logger) *ExportHan
 	}
 }
 
-// StreamExport handles GET /v1/exports?resource=...&format=...
+// StreamExport handles GET /v1/exports?resource=...&format=...[&updated_since=...|&cursor=...|&resume=...]
 // Streams the export directly to the response
 func (h *ExportHandler) StreamExport(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -41,29 +48,73 @@ func (h *ExportHandler) StreamExport(c *gin.Context) {
 	if format == "" {
 		format = "ndjson" // Default to NDJSON for streaming
 	}
-	if format != "ndjson" && format != "json" && format != "csv" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of: ndjson, json, csv"})
+	if !isExportFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of: " + strings.Join(formats.Encodable(), ", ")})
 		return
 	}
 
-	// CSV only supported for users
-	if format == "csv" && resource != "users" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV format only supported for users export"})
+	// Incremental exports: rows changed since a time or a previous export's
+	// X-Next-Cursor
+	updatedSince, cursor := c.Query("updated_since"), c.Query("cursor")
+	incremental := updatedSince != "" || cursor != ""
+	if updatedSince != "" && cursor != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "use either updated_since or cursor, not both"})
 		return
 	}
 
+	// Resumed exports continue after the X-Resume-Token of an interrupted one
+	resume := c.Query("resume")
+	if resume != "" && incremental {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resume cannot be combined with updated_since or cursor"})
+		return
+	}
+	if resume != "" && !service.Resumable(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resume is only supported for ndjson exports"})
+		return
+	}
+	var resumeAfter models.PageCursor
+	if resume != "" {
+		var err error
+		if resumeAfter, err = service.DecodePageCursor(resume); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resume token"})
+			return
+		}
+	}
+	var after models.ChangeCursor
+	if updatedSince != "" {
+		t, err := time.Parse(time.RFC3339, updatedSince)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "updated_since must be an RFC3339 timestamp"})
+			return
+		}
+		after.UpdatedAt = t
+	}
+	if cursor != "" {
+		var err error
+		if after, err = service.DecodeChangeCursor(cursor); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+	}
+
 	h.log.Info().
 		Str("resource", resource).
 		Str("format", format).
+		Bool("incremental", incremental).
+		Bool("resumed", resume != "").
 		Msg("Starting streaming export")
 
 	var err error
-	switch resource {
-	case "users":
+	switch {
+	case incremental:
+		err = h.services.Export.StreamChanges(ctx, c.Writer, resource, format, after)
+	case resume != "":
+		err = h.services.Export.StreamFrom(ctx, c.Writer, resource, format, resumeAfter)
+	case resource == "users":
 		err = h.services.Export.StreamUsers(ctx, c.Writer, format)
-	case "articles":
+	case resource == "articles":
 		err = h.services.Export.StreamArticles(ctx, c.Writer, format)
-	case "comments":
+	case resource == "comments":
 		err = h.services.Export.StreamComments(ctx, c.Writer, format)
 	}
 
@@ -74,14 +125,82 @@ func (h *ExportHandler) StreamExport(c *gin.Context) {
 	}
 }
 
+// StreamBundle handles GET /v1/exports/bundle?format=...
+// Streams a zip backup of every resource taken from one database snapshot,
+// restorable with POST /v1/imports resource=bundle
+func (h *ExportHandler) StreamBundle(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	format := c.Query("format")
+	if format == "" {
+		format = "ndjson"
+	}
+	if !isExportFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of: " + strings.Join(formats.Encodable(), ", ")})
+		return
+	}
+
+	h.log.Info().Str("format", format).Msg("Starting bundle export")
+
+	if err := h.services.Export.StreamBundle(ctx, c.Writer, format); err != nil {
+		h.log.Error().Err(err).Msg("Bundle export failed")
+		// Can't return error JSON after streaming has started
+		return
+	}
+}
+
+// ListResources handles GET /v1/resources/:resource?limit=...&cursor=...[&updated_since=...]
+// Returns one keyset page of a resource; follow next_cursor for the next one
+func (h *ExportHandler) ListResources(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	resource := c.Param("resource")
+	if resource != "users" && resource != "articles" && resource != "comments" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resource must be one of: users, articles, comments"})
+		return
+	}
+
+	filter := models.ExportFilter{Limit: service.DefaultPageLimit}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > service.MaxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", service.MaxPageLimit)})
+			return
+		}
+		filter.Limit = n
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		after, err := service.DecodePageCursor(cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+		filter.After = &after
+	}
+	if updatedSince := c.Query("updated_since"); updatedSince != "" {
+		t, err := time.Parse(time.RFC3339, updatedSince)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "updated_since must be an RFC3339 timestamp"})
+			return
+		}
+		filter.UpdatedSince = t
+	}
+
+	page, err := h.services.Export.ListPage(ctx, resource, filter)
+	if err != nil {
+		h.log.Error().Err(err).Str("resource", resource).Msg("Failed to list resource page")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list " + resource})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
 // CreateExport handles POST /v1/exports
-// Creates an async export job (for large exports with filters)
+// With an s3:// destination, queues an export job that writes the resource
+// to the object store; otherwise points at the streaming export
 func (h *ExportHandler) CreateExport(c *gin.Context) {
-	var req struct {
-		Resource string            `json:"resource"`
-		Format   string            `json:"format"`
-		Filters  map[string]string `json:"filters,omitempty"`
-	}
+	var req models.ExportRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
@@ -100,9 +219,17 @@ func (h *ExportHandler) CreateExport(c *gin.Context) {
 	if req.Format == "" {
 		req.Format = "ndjson"
 	}
+	if !isExportFormat(req.Format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of: " + strings.Join(formats.Encodable(), ", ")})
+		return
+	}
+
+	if req.Destination != "" {
+		h.createExportJob(c, &req)
+		return
+	}
 
-	// For now, redirect to streaming export
-	// In a full implementation, this would create an async job
+	// Without a destination the export is a download: redirect to streaming
 	h.log.Info().
 		Str("resource", req.Resource).
 		Str("format", req.Format).
@@ -117,6 +244,42 @@ func (h *ExportHandler) CreateExport(c *gin.Context) {
 	})
 }
 
+// createExportJob queues an export to the object store
+func (h *ExportHandler) createExportJob(c *gin.Context, req *models.ExportRequest) {
+	ctx := c.Request.Context()
+	req.IdempotencyKey = c.GetHeader("Idempotency-Key")
+
+	if req.IdempotencyKey != "" {
+		existingJob, err := h.services.Job.GetJobByIdempotencyKey(ctx, req.IdempotencyKey)
+		if err != nil {
+			h.log.Error().Err(err).Msg("Failed to check idempotency key")
+		}
+		if existingJob != nil {
+			c.JSON(http.StatusOK, existingJob)
+			return
+		}
+	}
+
+	job, err := h.services.Export.CreateExportJob(ctx, req)
+	if errors.Is(err, service.ErrInvalidExport) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to create export job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create export job"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":      job.ID,
+		"status":      job.Status,
+		"resource":    job.Resource,
+		"destination": job.FilePath,
+		"message":     "Export job created and queued for processing",
+	})
+}
+
 // GetExportStatus handles GET /v1/exports/:job_id
 func (h *ExportHandler) GetExportStatus(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -139,3 +302,9 @@ func (h *ExportHandler) GetExportStatus(c *gin.Context) {
 
 	c.JSON(http.StatusOK, job)
 }
+
+// isExportFormat reports whether a format with an encoder is registered
+func isExportFormat(name string) bool {
+	f, ok := formats.Lookup(name)
+	return ok && f.NewEncoder != nil
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// ExternalIDHandler handles queries of external ID mappings
+type ExternalIDHandler struct {
+	services *service.Services
+	log      zerolog.Logger
+}
+
+// NewExternalIDHandler creates a new ExternalIDHandler
+func NewExternalIDHandler(services *service.Services, log zerolog.Logger) *ExternalIDHandler {
+	return &ExternalIDHandler{
+		services: services,
+		log:      log.With().Str("handler", "external_ids").Logger(),
+	}
+}
+
+// ListExternalIDs handles GET /v1/external-ids/:resource
+// Looks up which UUID a foreign system's ID was stored under, or which
+// foreign IDs map to a stored row
+func (h *ExternalIDHandler) ListExternalIDs(c *gin.Context) {
+	filter := models.ExternalIDFilter{
+		Resource:   c.Param("resource"),
+		Source:     c.Query("source"),
+		ExternalID: c.Query("external_id"),
+		InternalID: c.Query("internal_id"),
+		Limit:      service.DefaultPageLimit,
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > service.MaxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", service.MaxPageLimit)})
+			return
+		}
+		filter.Limit = n
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		after, err := service.DecodeExternalIDCursor(cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+		filter.After = after
+	}
+
+	page, err := h.services.ExternalIDs.List(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidExternalIDQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error().Err(err).Str("resource", filter.Resource).Msg("Failed to list external IDs")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list external IDs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -2,17 +2,21 @@ package api
 
 import (
 	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"mime/multipart"
 	"net/http"
-	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/bulk-import-export-api/internal/config"
+	formats "github.com/bulk-import-export-api/internal/format"
 	"github.com/bulk-import-export-api/internal/models"
 	"github.com/bulk-import-export-api/internal/service"
+	"github.com/bulk-import-export-api/internal/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -35,7 +39,7 @@ func NewImportHandler(services *service.Services, cfg *config.Config, log zerolo
 }
 
 // CreateImport handles POST /v1/imports
-// Accepts file upload (multipart) or JSON body with file URL
+// Accepts file upload (multipart) or JSON body with an s3:// file_url
 func (h *ImportHandler) CreateImport(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -55,35 +59,54 @@ func (h *ImportHandler) CreateImport(c *gin.Context) {
 		}
 	}
 
+	// A JSON body names a file already in the object store instead of
+	// uploading one
+	var remote remoteImportRequest
+	isRemote := c.ContentType() == "application/json" && c.Query("mode") != "stream"
+	if isRemote {
+		if err := c.ShouldBindJSON(&remote); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+
 	// Get resource type
 	resource := c.PostForm("resource")
 	if resource == "" {
 		resource = c.Query("resource")
 	}
 	if resource == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "resource parameter is required (users, articles, comments)"})
+		resource = remote.Resource
+	}
+	if resource == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resource parameter is required (users, articles, comments, bundle)"})
+		return
+	}
+	if resource != "users" && resource != "articles" && resource != "comments" && resource != models.ResourceBundle {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resource must be one of: users, articles, comments, bundle"})
+		return
+	}
+
+	// Synchronous imports read the request body itself
+	switch c.Query("mode") {
+	case "", "async":
+	case "stream":
+		h.streamImport(c, resource, idempotencyKey)
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be one of: async, stream"})
 		return
 	}
-	if resource != "users" && resource != "articles" && resource != "comments" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "resource must be one of: users, articles, comments"})
+
+	if isRemote {
+		h.remoteImport(c, resource, idempotencyKey, &remote)
 		return
 	}
 
 	// Handle file upload
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
-		// Try JSON body with file URL
-		var req struct {
-			FileURL  string `json:"file_url"`
-			Resource string `json:"resource"`
-		}
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "file upload or file_url is required"})
-			return
-		}
-
-		// TODO: Implement URL download
-		c.JSON(http.StatusNotImplemented, gin.H{"error": "file_url not yet implemented, please use file upload"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file upload or file_url is required"})
 		return
 	}
 	defer file.Close()
@@ -96,49 +119,255 @@ func (h *ImportHandler) CreateImport(c *gin.Context) {
 		return
 	}
 
-	// Determine file format from extension
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if resource == "users" && ext != ".csv" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "users import requires CSV file"})
-		return
+	// Determine file format: bundles are archives of per-resource files;
+	// otherwise an explicit format name wins, then the file extension, then
+	// the part's Content-Type
+	var ext, formatName string
+	if resource == models.ResourceBundle {
+		archiveExt, ok := service.BundleArchiveExt(header.Filename)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bundle import requires a .zip, .tar, .tar.gz or .tgz archive"})
+			return
+		}
+		ext, formatName = archiveExt, "bundle"
+	} else {
+		f, ok := h.uploadFormat(c, header)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "unsupported file format, must be one of: " + strings.Join(formats.Decodable(), ", "),
+			})
+			return
+		}
+		ext, formatName = f.Extension(), f.Name
 	}
-	if (resource == "articles" || resource == "comments") && ext != ".ndjson" && ext != ".json" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "articles/comments import requires NDJSON file"})
+
+	opts, err := formOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Save uploaded file
-	uploadDir := h.cfg.Import.UploadDir
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		h.log.Error().Err(err).Msg("Failed to create upload directory")
+	// Save uploaded file under the format's canonical extension, which
+	// selects the decoder when the job is processed
+	filename := fmt.Sprintf("%s_%s%s", resource, uuid.New().String()[:8], ext)
+	filePath := filepath.Join(h.cfg.Import.UploadDir, filename)
+
+	if err := h.services.Storage.Put(ctx, filePath, file); err != nil {
+		h.log.Error().Err(err).Msg("Failed to store file")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
 		return
 	}
 
-	filename := fmt.Sprintf("%s_%s%s", resource, uuid.New().String()[:8], ext)
-	filePath := filepath.Join(uploadDir, filename)
+	// Create import job
+	req := &models.ImportRequest{
+		Resource:       resource,
+		IdempotencyKey: idempotencyKey,
+		Options:        opts,
+	}
 
-	dst, err := os.Create(filePath)
+	var job *models.Job
+	if resource == models.ResourceBundle {
+		job, err = h.services.Import.CreateBundleImportJob(ctx, req, filePath)
+	} else {
+		job, err = h.services.Import.CreateImportJob(ctx, req, filePath)
+	}
+	if errors.Is(err, service.ErrInvalidBundle) || invalidOptions(err) {
+		h.services.Storage.Delete(ctx, filePath)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		h.log.Error().Err(err).Msg("Failed to create file")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+		// No job refers to the file, so nothing would ever remove it
+		h.services.Storage.Delete(ctx, filePath)
+		h.log.Error().Err(err).Msg("Failed to create import job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create import job"})
 		return
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, file); err != nil {
-		h.log.Error().Err(err).Msg("Failed to copy file")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+	h.log.Info().
+		Str("job_id", job.ID).
+		Str("resource", resource).
+		Str("file", header.Filename).
+		Str("format", formatName).
+		Int64("size_bytes", header.Size).
+		Msg("Import job created")
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":   job.ID,
+		"status":   job.Status,
+		"resource": job.Resource,
+		"message":  "Import job created and queued for processing",
+	})
+}
+
+// streamImport handles POST /v1/imports?mode=stream
+// The body (NDJSON or CSV, chosen by `format` or Content-Type) is imported
+// before the response is written, and nothing is stored in the upload
+// directory. The response is the job summary, or with
+// `Accept: application/x-ndjson` one result line per record followed by a
+// summary line.
+func (h *ImportHandler) streamImport(c *gin.Context, resource, idempotencyKey string) {
+	ctx := c.Request.Context()
+
+	if resource == models.ResourceBundle {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bundles cannot be imported with mode=stream"})
 		return
 	}
 
-	// Create import job
+	name := c.Query("format")
+	if name == "" {
+		if f, ok := formats.ForMIMEType(c.ContentType()); ok {
+			name = f.Name
+		}
+	}
+	if name != "ndjson" && name != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode=stream requires an ndjson or csv body (format or Content-Type)"})
+		return
+	}
+
+	transforms, err := transformsParam(c.Query("transforms"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req := &models.ImportRequest{Resource: resource, IdempotencyKey: idempotencyKey}
+	opts := models.ImportOptions{
+		Source:      c.Query("source"),
+		Transforms:  transforms,
+		DateLayouts: c.QueryArray("date_layouts"),
+		Timezone:    c.Query("timezone"),
+	}
+	if opts.Source != "" || opts.Transforms != nil || len(opts.DateLayouts) > 0 || opts.Timezone != "" {
+		req.Options = &opts
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.Import.MaxUploadSize)
+
+	// Per-record results: headers go out with the first line, after which
+	// failures can only be reported in the body
+	var report func(models.LineResult) error
+	var enc *json.Encoder
+	if c.GetHeader("Accept") == "application/x-ndjson" {
+		enc = json.NewEncoder(c.Writer)
+		report = func(r models.LineResult) error {
+			if !c.Writer.Written() {
+				c.Header("Content-Type", "application/x-ndjson")
+				c.Status(http.StatusOK)
+			}
+			return enc.Encode(r)
+		}
+	}
+
+	job, err := h.services.Import.StreamImport(ctx, req, name, body, report)
+	if invalidOptions(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if job == nil {
+		h.log.Error().Err(err).Msg("Failed to create stream import job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create import job"})
+		return
+	}
+
+	summary, _ := h.services.Job.GetJob(ctx, job.ID)
+	if summary == nil {
+		summary = &models.JobResponse{Job: *job, ErrorCount: job.FailedCount}
+	}
+
+	h.log.Info().
+		Str("job_id", job.ID).
+		Str("resource", resource).
+		Str("format", name).
+		Int("total", job.TotalRecords).
+		Int("failed", job.FailedCount).
+		Msg("Stream import finished")
+
+	if enc != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+		}
+		line := gin.H{"summary": summary}
+		if err != nil {
+			line["error"] = err.Error()
+		}
+		enc.Encode(line)
+		return
+	}
+
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   fmt.Sprintf("body too large, max size is %d MB", h.cfg.Import.MaxUploadSize/(1024*1024)),
+			"summary": summary,
+		})
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "summary": summary})
+	default:
+		c.JSON(http.StatusOK, summary)
+	}
+}
+
+// remoteImportRequest is the JSON body of an import from the object store
+type remoteImportRequest struct {
+	FileURL  string                `json:"file_url"`
+	Resource string                `json:"resource"`
+	Format   string                `json:"format"`
+	Options  *models.ImportOptions `json:"options,omitempty"`
+}
+
+// remoteImport queues an import of an s3://bucket/key file_url. Nothing is
+// copied: the worker reads the object from the store when the job runs.
+func (h *ImportHandler) remoteImport(c *gin.Context, resource, idempotencyKey string, remote *remoteImportRequest) {
+	if remote.FileURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file upload or file_url is required"})
+		return
+	}
+	if !storage.IsS3(remote.FileURL) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "only s3:// file_url is supported, please use file upload"})
+		return
+	}
+	_, key, err := storage.ParseS3(remote.FileURL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if resource == models.ResourceBundle {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bundle imports require a file upload"})
+		return
+	}
+
+	// An explicit format wins over the object's extension
+	var f *formats.Format
+	var ok bool
+	if remote.Format != "" {
+		f, ok = formats.Lookup(remote.Format)
+	} else {
+		f, ok = formats.ForExtension(path.Ext(key))
+	}
+	if !ok || f.NewDecoder == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "unsupported file format, must be one of: " + strings.Join(formats.Decodable(), ", "),
+		})
+		return
+	}
+
+	opts := models.ImportOptions{}
+	if remote.Options != nil {
+		opts = *remote.Options
+	}
+	opts.Format = f.Name
+
 	req := &models.ImportRequest{
 		Resource:       resource,
 		IdempotencyKey: idempotencyKey,
+		Options:        &opts,
+	}
+	job, err := h.services.Import.CreateImportJob(c.Request.Context(), req, remote.FileURL)
+	if invalidOptions(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	job, err := h.services.Import.CreateImportJob(ctx, req, filePath)
 	if err != nil {
 		h.log.Error().Err(err).Msg("Failed to create import job")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create import job"})
@@ -148,8 +377,8 @@ func (h *ImportHandler) CreateImport(c *gin.Context) {
 	h.log.Info().
 		Str("job_id", job.ID).
 		Str("resource", resource).
-		Str("file", header.Filename).
-		Int64("size_bytes", header.Size).
+		Str("file_url", remote.FileURL).
+		Str("format", f.Name).
 		Msg("Import job created")
 
 	c.JSON(http.StatusAccepted, gin.H{
@@ -160,6 +389,77 @@ func (h *ImportHandler) CreateImport(c *gin.Context) {
 	})
 }
 
+// uploadFormat resolves the decoder for an upload from the `format` field,
+// the file extension or the part's Content-Type, in that order
+func (h *ImportHandler) uploadFormat(c *gin.Context, header *multipart.FileHeader) (*formats.Format, bool) {
+	name := c.PostForm("format")
+	if name == "" {
+		name = c.Query("format")
+	}
+
+	var f *formats.Format
+	var ok bool
+	if name != "" {
+		f, ok = formats.Lookup(name)
+	} else if f, ok = formats.ForExtension(filepath.Ext(header.Filename)); !ok {
+		f, ok = formats.ForMIMEType(header.Header.Get("Content-Type"))
+	}
+	return f, ok && f.NewDecoder != nil
+}
+
+// formOptions reads the import options of a multipart upload from its form
+// fields or query parameters, nil when none is set
+func formOptions(c *gin.Context) (*models.ImportOptions, error) {
+	param := func(name string) string {
+		if v := c.PostForm(name); v != "" {
+			return v
+		}
+		return c.Query(name)
+	}
+
+	// Optional transforms, a JSON array applied to each record before validation
+	transforms, err := transformsParam(param("transforms"))
+	if err != nil {
+		return nil, err
+	}
+	// Accepted date layouts (repeatable) and the timezone of naive timestamps
+	layouts := c.PostFormArray("date_layouts")
+	if len(layouts) == 0 {
+		layouts = c.QueryArray("date_layouts")
+	}
+
+	opts := &models.ImportOptions{
+		Sheet:       param("sheet"),  // Worksheet of XLSX uploads
+		Source:      param("source"), // Source system whose IDs the file uses, for external ID mapping
+		Transforms:  transforms,
+		DateLayouts: layouts,
+		Timezone:    param("timezone"),
+	}
+	if opts.Sheet == "" && opts.Source == "" && transforms == nil && len(layouts) == 0 && opts.Timezone == "" {
+		return nil, nil
+	}
+	return opts, nil
+}
+
+// transformsParam parses the JSON array of transforms given as a form
+// field or query parameter
+func transformsParam(raw string) ([]models.Transform, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var transforms []models.Transform
+	if err := json.Unmarshal([]byte(raw), &transforms); err != nil {
+		return nil, fmt.Errorf("transforms must be a JSON array: %w", err)
+	}
+	return transforms, nil
+}
+
+// invalidOptions reports whether err rejects the import options of a
+// request, a client error
+func invalidOptions(err error) bool {
+	return errors.Is(err, service.ErrInvalidTransform) || errors.Is(err, service.ErrInvalidDateOptions)
+}
+
 // GetImportStatus handles GET /v1/imports/:job_id
 func (h *ImportHandler) GetImportStatus(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -184,6 +484,9 @@ func (h *ImportHandler) GetImportStatus(c *gin.Context) {
 }
 
 // GetImportErrors handles GET /v1/imports/:job_id/errors
+// Lists a job's issues in line order, filtered by field, message, severity,
+// code and line range. JSON is paged by cursor; format=csv and
+// format=ndjson stream every matching issue.
 func (h *ImportHandler) GetImportErrors(c *gin.Context) {
 	ctx := c.Request.Context()
 	jobID := c.Param("job_id")
@@ -192,12 +495,18 @@ func (h *ImportHandler) GetImportErrors(c *gin.Context) {
 		return
 	}
 
-	errors, err := h.services.Job.GetJobErrors(ctx, jobID)
-	if err != nil {
-		h.log.Error().Err(err).Str("job_id", jobID).Msg("Failed to get job errors")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get errors"})
+	filter, ok := errorFilter(c, jobID)
+	if !ok {
 		return
 	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		after, err := service.DecodeErrorCursor(cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+		filter.After = &after
+	}
 
 	// Determine format from query param
 	format := c.Query("format")
@@ -205,25 +514,303 @@ func (h *ImportHandler) GetImportErrors(c *gin.Context) {
 		format = "json"
 	}
 
+	switch format {
+	case "json":
+		page, err := h.services.Job.ListJobErrors(ctx, filter)
+		if err != nil {
+			h.errorQueryFailed(c, jobID, err)
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	case "csv", "ndjson":
+		// Checked up front, as a failure once streaming starts cannot be reported
+		if err := service.CheckErrorFilter(filter); err != nil {
+			h.errorQueryFailed(c, jobID, err)
+			return
+		}
+		h.streamErrors(c, filter, format)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv or ndjson"})
+	}
+}
+
+// streamErrors writes every issue a filter selects as CSV or NDJSON, as it
+// is read
+func (h *ImportHandler) streamErrors(c *gin.Context, filter models.ErrorFilter, format string) {
+	var write func(e *models.ValidationError) error
+	var flush func() error
 	if format == "csv" {
 		c.Header("Content-Type", "text/csv")
-		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=errors_%s.csv", jobID))
 		writer := csv.NewWriter(c.Writer)
-		writer.Write([]string{"line", "field", "message", "value"})
-		for _, e := range errors {
+		writer.Write([]string{"line", "field", "message", "value", "severity", "code"})
+		write = func(e *models.ValidationError) error {
 			value := ""
 			if e.Value != nil {
 				value = fmt.Sprintf("%v", e.Value)
 			}
-			writer.Write([]string{strconv.Itoa(e.Line), e.Field, e.Message, value})
+			return writer.Write([]string{strconv.Itoa(e.Line), e.Field, e.Message, value, e.Severity, e.Code})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	} else {
+		f, _ := formats.Lookup(format)
+		c.Header("Content-Type", f.MIMEType)
+		encoder := json.NewEncoder(c.Writer)
+		write = func(e *models.ValidationError) error { return encoder.Encode(e) }
+		flush = func() error { return nil }
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=errors_%s.%s", filter.JobID, format))
+	c.Status(http.StatusOK)
+
+	// Headers are sent; a failure now can only cut the file short
+	err := h.services.Job.StreamJobErrors(c.Request.Context(), filter, write)
+	if flushErr := flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		h.log.Error().Err(err).Str("job_id", filter.JobID).Msg("Failed to stream job errors")
+	}
+}
+
+// GetImportErrorSummary handles GET /v1/imports/:job_id/errors/summary
+// Counts a job's issues by severity and groups them by field and message,
+// most frequent first. Takes the filters of the error report.
+func (h *ImportHandler) GetImportErrorSummary(c *gin.Context) {
+	jobID := c.Param("job_id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "job_id is required"})
+		return
+	}
+
+	filter, ok := errorFilter(c, jobID)
+	if !ok {
+		return
+	}
+	summary, err := h.services.Job.SummarizeJobErrors(c.Request.Context(), filter)
+	if err != nil {
+		h.errorQueryFailed(c, jobID, err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// errorFilter reads the filters and limit of the error report endpoints,
+// responding 400 when one is malformed
+func errorFilter(c *gin.Context, jobID string) (models.ErrorFilter, bool) {
+	filter := models.ErrorFilter{
+		JobID:    jobID,
+		Field:    c.Query("field"),
+		Message:  c.Query("message"),
+		Severity: c.Query("severity"),
+		Code:     c.Query("code"),
+	}
+	lines := []struct {
+		param string
+		dst   *int
+	}{{"from_line", &filter.FromLine}, {"to_line", &filter.ToLine}}
+	for _, line := range lines {
+		if v := c.Query(line.param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": line.param + " must be a positive line number"})
+				return filter, false
+			}
+			*line.dst = n
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > service.MaxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", service.MaxPageLimit)})
+			return filter, false
+		}
+		filter.Limit = n
+	}
+	return filter, true
+}
+
+// errorQueryFailed responds to a failed error report query
+func (h *ImportHandler) errorQueryFailed(c *gin.Context, jobID string, err error) {
+	if errors.Is(err, service.ErrInvalidErrorQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.log.Error().Err(err).Str("job_id", jobID).Msg("Failed to get job errors")
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get errors"})
+}
+
+// GetImportTransforms handles GET /v1/imports/:job_id/transforms
+// Lists every change the job's transforms made, in line order
+func (h *ImportHandler) GetImportTransforms(c *gin.Context) {
+	ctx := c.Request.Context()
+	jobID := c.Param("job_id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "job_id is required"})
+		return
+	}
+
+	transforms, err := h.services.Job.GetJobTransforms(ctx, jobID)
+	if err != nil {
+		h.log.Error().Err(err).Str("job_id", jobID).Msg("Failed to get job transforms")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get transforms"})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=transforms_%s.csv", jobID))
+		writer := csv.NewWriter(c.Writer)
+		writer.Write([]string{"line", "field", "transform", "before", "after"})
+		for _, t := range transforms {
+			writer.Write([]string{strconv.Itoa(t.Line), t.Field, t.Transform, t.Before, t.After})
 		}
 		writer.Flush()
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"job_id":      jobID,
-		"error_count": len(errors),
-		"errors":      errors,
+		"job_id":          jobID,
+		"transform_count": len(transforms),
+		"transforms":      transforms,
+	})
+}
+
+// GetImportRejects handles GET /v1/imports/:job_id/rejects
+// Returns the rejected records in the source format (CSV under the original
+// header, or NDJSON) with an _errors column; ?format=csv|ndjson overrides.
+// The file can be fixed and uploaded again as it is.
+func (h *ImportHandler) GetImportRejects(c *gin.Context) {
+	ctx := c.Request.Context()
+	jobID := c.Param("job_id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "job_id is required"})
+		return
+	}
+
+	job, err := h.services.Job.GetJob(ctx, jobID)
+	if err != nil {
+		h.log.Error().Err(err).Str("job_id", jobID).Msg("Failed to get job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get job"})
+		return
+	}
+	if job == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	formatName, err := service.RejectsFormat(&job.Job, c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	f, _ := formats.Lookup(formatName)
+	c.Header("Content-Type", f.MIMEType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=rejects_%s.%s", jobID, formatName))
+	c.Status(http.StatusOK)
+
+	// Headers are sent; a failure now can only cut the file short
+	if err := h.services.Job.WriteRejects(ctx, &job.Job, formatName, c.Writer); err != nil {
+		h.log.Error().Err(err).Str("job_id", jobID).Msg("Failed to write rejects")
+	}
+}
+
+// RetryImport handles POST /v1/imports/:job_id/retry
+// Creates a retry of a finished import as its child job. An uploaded file
+// (typically the fixed rejects file) is imported; without one, the rows
+// rejected for the state of the database are retried unchanged. Rows the
+// retry imports are rolled up into the parent's counts.
+func (h *ImportHandler) RetryImport(c *gin.Context) {
+	ctx := c.Request.Context()
+	jobID := c.Param("job_id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "job_id is required"})
+		return
+	}
+
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey != "" {
+		existingJob, err := h.services.Job.GetJobByIdempotencyKey(ctx, idempotencyKey)
+		if err != nil {
+			h.log.Error().Err(err).Msg("Failed to check idempotency key")
+		}
+		if existingJob != nil {
+			h.log.Info().Str("job_id", existingJob.ID).Msg("Returning existing job for idempotency key")
+			c.JSON(http.StatusOK, existingJob)
+			return
+		}
+	}
+
+	opts, err := formOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var filePath string
+	file, header, err := c.Request.FormFile("file")
+	switch {
+	case err == nil:
+		defer file.Close()
+		if header.Size > h.cfg.Import.MaxUploadSize {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("file too large, max size is %d MB", h.cfg.Import.MaxUploadSize/(1024*1024)),
+			})
+			return
+		}
+		f, ok := h.uploadFormat(c, header)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "unsupported file format, must be one of: " + strings.Join(formats.Decodable(), ", "),
+			})
+			return
+		}
+		filename := fmt.Sprintf("retry_%s%s", uuid.New().String()[:8], f.Extension())
+		filePath = filepath.Join(h.cfg.Import.UploadDir, filename)
+		if err := h.services.Storage.Put(ctx, filePath, file); err != nil {
+			h.log.Error().Err(err).Msg("Failed to store file")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+			return
+		}
+	case errors.Is(err, http.ErrMissingFile), errors.Is(err, http.ErrNotMultipart):
+		// No file: the service retries the stored rejects
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file upload"})
+		return
+	}
+
+	req := &models.ImportRequest{IdempotencyKey: idempotencyKey, Options: opts}
+	job, err := h.services.Import.RetryImport(ctx, jobID, req, filePath)
+	if err != nil && filePath != "" {
+		h.services.Storage.Delete(ctx, filePath)
+	}
+	if errors.Is(err, service.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidRetry) || errors.Is(err, service.ErrInvalidRejects) || invalidOptions(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.log.Error().Err(err).Str("job_id", jobID).Msg("Failed to create retry job")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create retry job"})
+		return
+	}
+
+	h.log.Info().
+		Str("job_id", job.ID).
+		Str("parent_job_id", job.ParentJobID).
+		Bool("with_file", filePath != "").
+		Msg("Retry job created")
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":        job.ID,
+		"parent_job_id": job.ParentJobID,
+		"status":        job.Status,
+		"resource":      job.Resource,
+		"message":       "Retry job created and queued for processing",
 	})
 }
@@ -26,6 +26,9 @@ func NewRouter(services *service.Services, cfg *config.Config, log zerolog.Logge
 	// Handlers
 	importHandler := NewImportHandler(services, cfg, log)
 	exportHandler := NewExportHandler(services, log)
+	uploadHandler := NewUploadHandler(services, log)
+	adminHandler := NewAdminHandler(services, log)
+	externalIDHandler := NewExternalIDHandler(services, log)
 
 	// Health check
 	router.GET("/health", healthCheck)
@@ -40,6 +43,21 @@ func NewRouter(services *service.Services, cfg *config.Config, log zerolog.Logge
 			imports.POST("", importHandler.CreateImport)
 			imports.GET("/:job_id", importHandler.GetImportStatus)
 			imports.GET("/:job_id/errors", importHandler.GetImportErrors)
+			imports.GET("/:job_id/errors/summary", importHandler.GetImportErrorSummary)
+			imports.GET("/:job_id/transforms", importHandler.GetImportTransforms)
+			imports.GET("/:job_id/rejects", importHandler.GetImportRejects)
+			imports.POST("/:job_id/retry", importHandler.RetryImport)
+		}
+
+		// Resumable chunked uploads, completed into import jobs
+		uploads := v1.Group("/uploads")
+		{
+			uploads.POST("", uploadHandler.CreateUpload)
+			uploads.HEAD("/:upload_id", uploadHandler.GetUpload)
+			uploads.GET("/:upload_id", uploadHandler.GetUpload)
+			uploads.PATCH("/:upload_id", uploadHandler.AppendChunk)
+			uploads.POST("/:upload_id/complete", uploadHandler.CompleteUpload)
+			uploads.DELETE("/:upload_id", uploadHandler.DeleteUpload)
 		}
 
 		// Export endpoints
@@ -47,8 +65,23 @@ func NewRouter(services *service.Services, cfg *config.Config, log zerolog.Logge
 		{
 			exports.GET("", exportHandler.StreamExport)
 			exports.POST("", exportHandler.CreateExport)
+			exports.GET("/bundle", exportHandler.StreamBundle)
 			exports.GET("/:job_id", exportHandler.GetExportStatus)
 		}
+
+		// Keyset-paginated reads
+		v1.GET("/resources/:resource", exportHandler.ListResources)
+
+		// Foreign systems' IDs mapped onto stored rows
+		v1.GET("/external-ids/:resource", externalIDHandler.ListExternalIDs)
+
+		// Operator endpoints
+		admin := v1.Group("/admin", adminAuthMiddleware(cfg.Retention.AdminToken))
+		{
+			admin.POST("/cleanup", adminHandler.RunCleanup)
+			admin.GET("/rules", adminHandler.GetRules)
+			admin.POST("/rules/reload", adminHandler.ReloadRules)
+		}
 	}
 
 	return router
@@ -131,8 +164,10 @@ func loggingMiddleware(log zerolog.Logger) gin.HandlerFunc {
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Idempotency-Key, "+service.UploadOffsetHeader)
+		c.Writer.Header().Set("Access-Control-Expose-Headers", service.NextCursorHeader+", "+service.WatermarkHeader+", "+service.ResumeTokenHeader+", "+
+			service.UploadOffsetHeader+", "+service.UploadLengthHeader+", Location")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// UploadHandler handles resumable upload endpoints
+type UploadHandler struct {
+	services *service.Services
+	log      zerolog.Logger
+}
+
+// NewUploadHandler creates a new UploadHandler
+func NewUploadHandler(services *service.Services, log zerolog.Logger) *UploadHandler {
+	return &UploadHandler{
+		services: services,
+		log:      log.With().Str("handler", "upload").Logger(),
+	}
+}
+
+// CreateUpload handles POST /v1/uploads
+// Opens a session for a file of known size; chunks are then PATCHed to it
+func (h *UploadHandler) CreateUpload(c *gin.Context) {
+	var req models.UploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request body must be JSON with resource and size"})
+		return
+	}
+	req.IdempotencyKey = c.GetHeader("Idempotency-Key")
+
+	upload, err := h.services.Upload.CreateUpload(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrInvalidUpload) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to create upload session")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create upload session"})
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	c.Header("Location", "/v1/uploads/"+upload.ID)
+	c.JSON(http.StatusCreated, upload)
+}
+
+// GetUpload handles GET and HEAD /v1/uploads/:upload_id
+// The Upload-Offset header tells a client where to resume
+func (h *UploadHandler) GetUpload(c *gin.Context) {
+	upload, ok := h.upload(c)
+	if !ok {
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	c.Header("Cache-Control", "no-store")
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.JSON(http.StatusOK, upload)
+}
+
+// AppendChunk handles PATCH /v1/uploads/:upload_id
+// The raw body is written at the Upload-Offset header, which must equal the
+// bytes received so far
+func (h *UploadHandler) AppendChunk(c *gin.Context) {
+	offset, err := strconv.ParseInt(c.GetHeader(service.UploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header must be a non-negative integer"})
+		return
+	}
+
+	uploadID := c.Param("upload_id")
+	upload, err := h.services.Upload.WriteChunk(c.Request.Context(), uploadID, offset, c.Request.Body)
+	if upload != nil {
+		setUploadHeaders(c, upload)
+	}
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+	case errors.Is(err, service.ErrOffsetMismatch):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUploadTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case err != nil:
+		h.log.Error().Err(err).Str("upload_id", uploadID).Msg("Failed to write upload chunk")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to write chunk, resume from Upload-Offset"})
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// CompleteUpload handles POST /v1/uploads/:upload_id/complete
+// Creates the import job once every byte has been received; completing
+// again returns the same job
+func (h *UploadHandler) CompleteUpload(c *gin.Context) {
+	uploadID := c.Param("upload_id")
+	job, err := h.services.Upload.CompleteUpload(c.Request.Context(), uploadID)
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+		return
+	case errors.Is(err, service.ErrUploadIncomplete):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrInvalidBundle):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		h.log.Error().Err(err).Str("upload_id", uploadID).Msg("Failed to complete upload")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create import job"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":    job.ID,
+		"status":    job.Status,
+		"resource":  job.Resource,
+		"upload_id": uploadID,
+		"message":   "Import job created and queued for processing",
+	})
+}
+
+// DeleteUpload handles DELETE /v1/uploads/:upload_id
+func (h *UploadHandler) DeleteUpload(c *gin.Context) {
+	uploadID := c.Param("upload_id")
+	err := h.services.Upload.DeleteUpload(c.Request.Context(), uploadID)
+	if errors.Is(err, service.ErrUploadNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error().Err(err).Str("upload_id", uploadID).Msg("Failed to delete upload")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete upload"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// upload loads the session named in the path, writing the error response
+// when it cannot
+func (h *UploadHandler) upload(c *gin.Context) (*models.Upload, bool) {
+	uploadID := c.Param("upload_id")
+	upload, err := h.services.Upload.GetUpload(c.Request.Context(), uploadID)
+	if errors.Is(err, service.ErrUploadNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+		return nil, false
+	}
+	if err != nil {
+		h.log.Error().Err(err).Str("upload_id", uploadID).Msg("Failed to get upload")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get upload"})
+		return nil, false
+	}
+	return upload, true
+}
+
+// setUploadHeaders reports a session's progress in tus-style headers
+func setUploadHeaders(c *gin.Context, upload *models.Upload) {
+	c.Header(service.UploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+	c.Header(service.UploadLengthHeader, strconv.FormatInt(upload.Size, 10))
+}
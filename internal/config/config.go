@@ -18,6 +18,12 @@ type Config struct {
 	// Import/Export configuration
 	Import ImportConfig
 
+	// Artifact storage configuration
+	Storage StorageConfig
+
+	// Retention of job files and rows
+	Retention RetentionConfig
+
 	// Logging configuration
 	Log LogConfig
 }
@@ -47,7 +53,46 @@ type DatabaseConfig struct {
 type ImportConfig struct {
 	BatchSize     int
 	MaxUploadSize int64 // in bytes
+	MaxBundleSize int64 // total extracted size of a bundle archive, in bytes (0 = unlimited)
 	UploadDir     string
+
+	// UploadSessionTTL is how long a resumable upload session lives after
+	// its last chunk before it and its partial file are removed
+	UploadSessionTTL time.Duration
+
+	// FKCacheLimit is the most parent IDs loaded into memory for FK
+	// validation; above it references are looked up per batch
+	FKCacheLimit int
+
+	// RulesFile is a YAML or JSON file of validation rules applied on top
+	// of the defaults ("" for the defaults only)
+	RulesFile string
+}
+
+// StorageConfig holds settings for the S3-compatible object store used by
+// s3:// import sources and export destinations
+type StorageConfig struct {
+	S3Endpoint  string // host[:port], e.g. localhost:9000 for MinIO
+	S3Region    string
+	S3AccessKey string // empty uses AWS environment or instance credentials
+	S3SecretKey string
+	S3UseSSL    bool
+	S3PartSize  int64 // multipart upload part size in bytes (minimum 5MB)
+}
+
+// RetentionConfig holds how long the janitor keeps job files and rows
+// after a job finishes. A negative duration keeps them forever.
+type RetentionConfig struct {
+	CompletedUploads time.Duration // source files of successful imports (0 = next run)
+	FailedUploads    time.Duration // source files of failed or cancelled imports
+	Jobs             time.Duration // job rows and their errors, once their files are gone
+
+	// Interval between janitor runs (0 disables the background janitor)
+	Interval time.Duration
+
+	// AdminToken is required as a bearer token on /v1/admin; the admin
+	// endpoints are disabled without one
+	AdminToken string
 }
 
 // LogConfig holds logging settings
@@ -78,8 +123,28 @@ func Load() (*Config, error) {
 		},
 		Import: ImportConfig{
 			BatchSize:     getIntEnv("IMPORT_BATCH_SIZE", 1000),
-			MaxUploadSize: getInt64Env("MAX_UPLOAD_SIZE", 500*1024*1024), // 500MB
+			MaxUploadSize: getInt64Env("MAX_UPLOAD_SIZE", 500*1024*1024),    // 500MB
+			MaxBundleSize: getInt64Env("MAX_BUNDLE_SIZE", 2*1024*1024*1024), // 2GB
 			UploadDir:     getEnv("UPLOAD_DIR", "./data/uploads"),
+
+			UploadSessionTTL: getDurationEnv("UPLOAD_SESSION_TTL", 24*time.Hour),
+			FKCacheLimit:     getIntEnv("FK_CACHE_LIMIT", 100000),
+			RulesFile:        getEnv("VALIDATION_RULES_FILE", ""),
+		},
+		Storage: StorageConfig{
+			S3Endpoint:  getEnv("S3_ENDPOINT", "s3.amazonaws.com"),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3AccessKey: getEnv("S3_ACCESS_KEY_ID", ""),
+			S3SecretKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+			S3UseSSL:    getBoolEnv("S3_USE_SSL", true),
+			S3PartSize:  getInt64Env("S3_PART_SIZE", 16*1024*1024), // 16MB
+		},
+		Retention: RetentionConfig{
+			CompletedUploads: getDurationEnv("RETAIN_COMPLETED_UPLOADS", 0),
+			FailedUploads:    getDurationEnv("RETAIN_FAILED_UPLOADS", 7*24*time.Hour),
+			Jobs:             getDurationEnv("RETAIN_JOBS", 30*24*time.Hour),
+			Interval:         getDurationEnv("JANITOR_INTERVAL", time.Hour),
+			AdminToken:       getEnv("ADMIN_TOKEN", ""),
 		},
 		Log: LogConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
@@ -0,0 +1,191 @@
+package format
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+// arrowBatchSize is the number of rows per Arrow record batch. Each batch is
+// written as soon as it is full, so consumers can start decoding before the
+// export finishes.
+const arrowBatchSize = 8192
+
+var arrowFormat = &Format{
+	Name:       "arrow",
+	MIMEType:   "application/vnd.apache.arrow.stream",
+	Extensions: []string{".arrows"},
+	NewDecoder: newArrowDecoder,
+	NewEncoder: newArrowEncoder,
+}
+
+var arrowTimestamp = &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+
+// arrowSchema maps a schema onto Arrow types. Arrow schemas are part of the
+// public contract with downstream services: field order and types must only
+// ever be extended, never changed.
+func arrowSchema(schema *Schema) *arrow.Schema {
+	fields := make([]arrow.Field, len(schema.Fields))
+	for i, f := range schema.Fields {
+		var typ arrow.DataType
+		switch f.Type {
+		case Bool:
+			typ = arrow.FixedWidthTypes.Boolean
+		case Timestamp:
+			typ = arrowTimestamp
+		case StringList:
+			typ = arrow.ListOf(arrow.BinaryTypes.String)
+		default:
+			typ = arrow.BinaryTypes.String
+		}
+		fields[i] = arrow.Field{Name: f.Name, Type: typ, Nullable: f.Nullable}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// arrowEncoder writes an Arrow IPC stream, emitting a record batch every
+// arrowBatchSize rows. The schema message is written up front, so even an
+// empty export is a valid stream.
+type arrowEncoder struct {
+	writer  *ipc.Writer
+	builder *array.RecordBuilder
+	schema  *Schema
+	pending int
+}
+
+func newArrowEncoder(w io.Writer, schema *Schema) (RecordEncoder, error) {
+	mem := memory.NewGoAllocator()
+	as := arrowSchema(schema)
+	return &arrowEncoder{
+		writer:  ipc.NewWriter(w, ipc.WithSchema(as), ipc.WithAllocator(mem)),
+		builder: array.NewRecordBuilder(mem, as),
+		schema:  schema,
+	}, nil
+}
+
+func (e *arrowEncoder) Encode(rec Record) error {
+	for i, f := range e.schema.Fields {
+		v := rec[f.Name]
+		b := e.builder.Field(i)
+		if f.Nullable && isNull(v) {
+			b.AppendNull()
+			continue
+		}
+		switch f.Type {
+		case Bool:
+			b.(*array.BooleanBuilder).Append(boolValue(v))
+		case Timestamp:
+			t, _ := timeValue(v)
+			b.(*array.TimestampBuilder).AppendTime(t)
+		case StringList:
+			lb := b.(*array.ListBuilder)
+			lb.Append(true)
+			values := lb.ValueBuilder().(*array.StringBuilder)
+			for _, s := range listValue(v) {
+				values.Append(s)
+			}
+		default:
+			b.(*array.StringBuilder).Append(stringValue(v))
+		}
+	}
+
+	e.pending++
+	if e.pending >= arrowBatchSize {
+		return e.writeBatch()
+	}
+	return nil
+}
+
+func (e *arrowEncoder) writeBatch() error {
+	if e.pending == 0 {
+		return nil
+	}
+	rec := e.builder.NewRecordBatch()
+	defer rec.Release()
+	e.pending = 0
+	return e.writer.Write(rec)
+}
+
+// Flush is a no-op: rows are only written out a full batch at a time
+func (e *arrowEncoder) Flush() error { return nil }
+
+func (e *arrowEncoder) Close() error {
+	defer e.builder.Release()
+	if err := e.writeBatch(); err != nil {
+		return err
+	}
+	return e.writer.Close()
+}
+
+// arrowDecoder reads an Arrow IPC stream batch by batch. Columns are matched
+// to the schema by name; unknown columns are ignored and other types are
+// read through their string form.
+type arrowDecoder struct {
+	reader *ipc.Reader
+	batch  arrow.RecordBatch
+	pos    int
+	rowNum int
+}
+
+func newArrowDecoder(r io.Reader, _ *Schema, _ DecodeOptions) (RecordDecoder, error) {
+	reader, err := ipc.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid arrow stream: %w", err)
+	}
+	return &arrowDecoder{reader: reader}, nil
+}
+
+func (d *arrowDecoder) Next() (Record, int, error) {
+	for d.batch == nil || d.pos >= int(d.batch.NumRows()) {
+		if !d.reader.Next() {
+			if err := d.reader.Err(); err != nil && err != io.EOF {
+				return nil, d.rowNum, err
+			}
+			return nil, d.rowNum, io.EOF
+		}
+		d.batch = d.reader.RecordBatch()
+		d.pos = 0
+	}
+
+	rec := make(Record, d.batch.NumCols())
+	for i, col := range d.batch.Columns() {
+		rec[d.batch.ColumnName(i)] = arrowValue(col, d.pos)
+	}
+	d.pos++
+	d.rowNum++
+	return rec, d.rowNum, nil
+}
+
+func (d *arrowDecoder) Close() error {
+	d.reader.Release()
+	return nil
+}
+
+func arrowValue(col arrow.Array, i int) any {
+	if col.IsNull(i) {
+		return nil
+	}
+	switch arr := col.(type) {
+	case *array.String:
+		return arr.Value(i)
+	case *array.Boolean:
+		return arr.Value(i)
+	case *array.Timestamp:
+		unit := arr.DataType().(*arrow.TimestampType).Unit
+		return arr.Value(i).ToTime(unit)
+	case *array.List:
+		start, end := arr.ValueOffsets(i)
+		values := arr.ListValues()
+		out := make([]string, 0, end-start)
+		for j := int(start); j < int(end); j++ {
+			out = append(out, values.ValueStr(j))
+		}
+		return out
+	default:
+		return col.ValueStr(i)
+	}
+}
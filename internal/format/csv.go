@@ -0,0 +1,134 @@
+package format
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+)
+
+var csvFormat = &Format{
+	Name:       "csv",
+	MIMEType:   "text/csv",
+	Extensions: []string{".csv"},
+	NewDecoder: newCSVDecoder,
+	NewEncoder: newCSVEncoder,
+}
+
+// csvDecoder maps CSV rows onto records using the header row. Lists are
+// read from JSON arrays or comma-separated cells.
+type csvDecoder struct {
+	reader  *csv.Reader
+	columns []string // as written in the file
+	header  []string
+	lineNum int
+}
+
+func newCSVDecoder(r io.Reader, _ *Schema, _ DecodeOptions) (RecordDecoder, error) {
+	reader := csv.NewReader(r)
+
+	// Read header
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	return &csvDecoder{
+		reader:  reader,
+		columns: slices.Clone(header),
+		header:  NormalizeHeader(header),
+		lineNum: 1, // Start after header
+	}, nil
+}
+
+func (d *csvDecoder) Next() (Record, int, error) {
+	for {
+		row, err := d.reader.Read()
+		if err == io.EOF {
+			return nil, d.lineNum, io.EOF
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// Skip malformed rows (wrong field count, bad quoting)
+			continue
+		}
+		if err != nil {
+			// The underlying reader failed, e.g. a request body cut off
+			return nil, d.lineNum, err
+		}
+		d.lineNum++
+
+		return recordFromRow(d.header, row), d.lineNum, nil
+	}
+}
+
+func (d *csvDecoder) Header() []string { return d.columns }
+
+func (d *csvDecoder) Close() error { return nil }
+
+// NormalizeHeader lower-cases and trims column names into record keys.
+// Shared by the CSV and XLSX decoders so both accept the same headers.
+func NormalizeHeader(header []string) []string {
+	out := make([]string, len(header))
+	for i, h := range header {
+		out[i] = strings.ToLower(strings.TrimSpace(h))
+	}
+	return out
+}
+
+// recordFromRow maps a tabular row onto a record by header name, trimming
+// cell values. Columns missing from a short row are left out.
+func recordFromRow(header, row []string) Record {
+	rec := make(Record, len(header))
+	for i, name := range header {
+		if name == "" || i >= len(row) {
+			continue
+		}
+		rec[name] = strings.TrimSpace(row[i])
+	}
+	return rec
+}
+
+// csvEncoder writes one row per record in schema order. Timestamps are
+// RFC 3339 (UTC) and lists are JSON arrays, so exports re-import unchanged.
+type csvEncoder struct {
+	writer *csv.Writer
+	schema *Schema
+	row    []string
+}
+
+func newCSVEncoder(w io.Writer, schema *Schema) (RecordEncoder, error) {
+	writer := csv.NewWriter(w)
+
+	// Write header
+	header := make([]string, len(schema.Fields))
+	for i, f := range schema.Fields {
+		header[i] = f.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &csvEncoder{
+		writer: writer,
+		schema: schema,
+		row:    make([]string, len(schema.Fields)),
+	}, nil
+}
+
+func (e *csvEncoder) Encode(rec Record) error {
+	for i, f := range e.schema.Fields {
+		e.row[i] = textValue(rec[f.Name])
+	}
+	return e.writer.Write(e.row)
+}
+
+func (e *csvEncoder) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	return e.Flush()
+}
@@ -0,0 +1,213 @@
+// Package format provides the pluggable file formats used by imports and
+// exports. Each format registers a decoder and/or encoder once and works for
+// every resource: codecs only see resource-agnostic Records described by a
+// Schema, and the service layer maps those onto its models.
+package format
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"sync"
+)
+
+// FieldType is the logical type of a schema field. Encoders use it to pick
+// native column types (Parquet, Arrow, XLSX); decoders use it to normalize
+// typed cells back into the values the validators expect.
+type FieldType int
+
+const (
+	String FieldType = iota
+	Bool
+	Timestamp
+	StringList
+)
+
+// Field describes a single column of a resource
+type Field struct {
+	Name     string
+	Type     FieldType
+	Nullable bool
+}
+
+// Schema is the ordered set of fields making up a resource. Field order is
+// the column order of every encoded file.
+type Schema struct {
+	Name   string
+	Fields []Field
+}
+
+// Field returns the named field, if the schema has it
+func (s *Schema) Field(name string) (Field, bool) {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Record is a single row keyed by field name. Encoders expect values of the
+// field's Go type (string, bool, time.Time, []string) or nil for nulls.
+// Decoders may produce any JSON-like value; use Text and List to read them.
+type Record map[string]any
+
+// RecordDecoder yields records from an uploaded file along with the line (or
+// row) number they came from. It returns io.EOF once the source is exhausted.
+// A *RecordError means the current record could not be parsed and should be
+// reported as a validation failure; any other error is fatal.
+type RecordDecoder interface {
+	Next() (Record, int, error)
+	Close() error
+}
+
+// HeaderDecoder is implemented by decoders of tabular formats. Header
+// returns the file's column names as written, in file order, so output
+// meant for re-upload can repeat them.
+type HeaderDecoder interface {
+	Header() []string
+}
+
+// RecordEncoder writes records to an export stream. Flush pushes buffered
+// rows to the underlying writer where the format allows it; Close finishes
+// the file (footer, closing bracket, ...) and must always be called.
+type RecordEncoder interface {
+	Encode(rec Record) error
+	Flush() error
+	Close() error
+}
+
+// DecodeOptions carries per-import settings to decoders
+type DecodeOptions struct {
+	// Sheet selects the XLSX worksheet; empty means the first sheet
+	Sheet string
+}
+
+// RecordError describes a record that could not be decoded (e.g. malformed
+// JSON). Processing continues with the next record.
+type RecordError struct {
+	Line    int
+	Field   string
+	Message string
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Format is a registered file format. A nil NewDecoder or NewEncoder means
+// the format is export-only or import-only respectively.
+type Format struct {
+	Name     string
+	MIMEType string
+	// Extensions lists the file extensions (with dot) handled by the format;
+	// the first one is used for uploaded and exported file names
+	Extensions []string
+
+	NewDecoder func(r io.Reader, schema *Schema, opts DecodeOptions) (RecordDecoder, error)
+	NewEncoder func(w io.Writer, schema *Schema) (RecordEncoder, error)
+}
+
+// Extension returns the canonical file extension of the format
+func (f *Format) Extension() string {
+	if len(f.Extensions) == 0 {
+		return ""
+	}
+	return f.Extensions[0]
+}
+
+var registry = struct {
+	sync.RWMutex
+	formats []*Format
+	byName  map[string]*Format
+	byExt   map[string]*Format
+	byMIME  map[string]*Format
+}{
+	byName: make(map[string]*Format),
+	byExt:  make(map[string]*Format),
+	byMIME: make(map[string]*Format),
+}
+
+// Register makes a format available by name, extension and MIME type.
+// It panics if the name is already registered, like database/sql drivers.
+func Register(f *Format) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, dup := registry.byName[f.Name]; dup {
+		panic("format: Register called twice for " + f.Name)
+	}
+	registry.formats = append(registry.formats, f)
+	registry.byName[f.Name] = f
+	for _, ext := range f.Extensions {
+		registry.byExt[strings.ToLower(ext)] = f
+	}
+	if f.MIMEType != "" {
+		registry.byMIME[f.MIMEType] = f
+	}
+}
+
+// Lookup returns the format registered under name
+func Lookup(name string) (*Format, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	f, ok := registry.byName[strings.ToLower(name)]
+	return f, ok
+}
+
+// ForExtension returns the format handling a file extension such as ".csv"
+func ForExtension(ext string) (*Format, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	f, ok := registry.byExt[strings.ToLower(ext)]
+	return f, ok
+}
+
+// ForMIMEType returns the format registered for a media type. Parameters
+// such as charset are ignored.
+func ForMIMEType(contentType string) (*Format, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	f, ok := registry.byMIME[mediaType]
+	return f, ok
+}
+
+// Decodable returns the names of formats that can be imported, in
+// registration order
+func Decodable() []string {
+	return names(func(f *Format) bool { return f.NewDecoder != nil })
+}
+
+// Encodable returns the names of formats that can be exported, in
+// registration order
+func Encodable() []string {
+	return names(func(f *Format) bool { return f.NewEncoder != nil })
+}
+
+func names(keep func(*Format) bool) []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	var out []string
+	for _, f := range registry.formats {
+		if keep(f) {
+			out = append(out, f.Name)
+		}
+	}
+	return out
+}
+
+// Built-in formats. Registration order is the order formats are listed in
+// API error messages.
+func init() {
+	Register(ndjsonFormat)
+	Register(jsonFormat)
+	Register(csvFormat)
+	Register(parquetFormat)
+	Register(arrowFormat)
+	Register(xlsxFormat)
+}
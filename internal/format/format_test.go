@@ -0,0 +1,290 @@
+package format_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/format"
+)
+
+var testSchema = &format.Schema{
+	Name: "articles",
+	Fields: []format.Field{
+		{Name: "id", Type: format.String},
+		{Name: "active", Type: format.Bool},
+		{Name: "tags", Type: format.StringList},
+		{Name: "published_at", Type: format.Timestamp, Nullable: true},
+		{Name: "created_at", Type: format.Timestamp},
+	},
+}
+
+func TestRegistryLookups(t *testing.T) {
+	tests := []struct {
+		name   string
+		lookup func() (*format.Format, bool)
+		want   string
+	}{
+		{"by name", func() (*format.Format, bool) { return format.Lookup("parquet") }, "parquet"},
+		{"by name case-insensitive", func() (*format.Format, bool) { return format.Lookup("CSV") }, "csv"},
+		{"by extension", func() (*format.Format, bool) { return format.ForExtension(".XLSX") }, "xlsx"},
+		{"by extension alias", func() (*format.Format, bool) { return format.ForExtension(".jsonl") }, "ndjson"},
+		{"by mime type", func() (*format.Format, bool) { return format.ForMIMEType("text/csv; charset=utf-8") }, "csv"},
+		{"arrow stream", func() (*format.Format, bool) { return format.ForMIMEType("application/vnd.apache.arrow.stream") }, "arrow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := tt.lookup()
+			if !ok {
+				t.Fatalf("Expected format %s to be found", tt.want)
+			}
+			if f.Name != tt.want {
+				t.Errorf("Expected format %s, got %s", tt.want, f.Name)
+			}
+		})
+	}
+
+	if _, ok := format.ForExtension(".txt"); ok {
+		t.Error("Expected .txt to be unregistered")
+	}
+
+	want := []string{"ndjson", "json", "csv", "parquet", "arrow", "xlsx"}
+	if got := format.Encodable(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected encodable formats %v, got %v", want, got)
+	}
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Register to panic on a duplicate name")
+		}
+	}()
+	format.Register(&format.Format{Name: "csv"})
+}
+
+// Every registered format must round-trip every field type
+func TestRoundTrip_AllFormats(t *testing.T) {
+	created := time.Date(2024, 1, 5, 10, 0, 0, 123456000, time.UTC)
+	published := created.Add(time.Hour)
+	records := []format.Record{
+		{"id": "a1", "active": true, "tags": []string{"go", "data"}, "published_at": published, "created_at": created},
+		{"id": "a2", "active": false, "tags": []string{}, "published_at": nil, "created_at": created},
+	}
+
+	for _, name := range format.Encodable() {
+		t.Run(name, func(t *testing.T) {
+			f, _ := format.Lookup(name)
+			if f.NewDecoder == nil {
+				t.Skip("export-only format")
+			}
+
+			var buf bytes.Buffer
+			enc, err := f.NewEncoder(&buf, testSchema)
+			if err != nil {
+				t.Fatalf("NewEncoder: %v", err)
+			}
+			for _, rec := range records {
+				if err := enc.Encode(rec); err != nil {
+					t.Fatalf("Encode: %v", err)
+				}
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			dec, err := f.NewDecoder(bytes.NewReader(buf.Bytes()), testSchema, format.DecodeOptions{})
+			if err != nil {
+				t.Fatalf("NewDecoder: %v", err)
+			}
+			defer dec.Close()
+
+			for i, want := range records {
+				got, _, err := dec.Next()
+				if err != nil {
+					t.Fatalf("Next (record %d): %v", i, err)
+				}
+				for _, field := range []string{"id", "active", "published_at", "created_at"} {
+					if got.Text(field) != want.Text(field) && !sameSecond(got.Text(field), want.Text(field)) {
+						t.Errorf("Record %d: expected %s %q, got %q", i, field, want.Text(field), got.Text(field))
+					}
+				}
+				tags, err := got.List("tags")
+				if err != nil {
+					t.Fatalf("List(tags): %v", err)
+				}
+				wantTags, _ := want.List("tags")
+				if len(tags) != len(wantTags) || (len(tags) > 0 && !reflect.DeepEqual(tags, wantTags)) {
+					t.Errorf("Record %d: expected tags %v, got %v", i, wantTags, tags)
+				}
+			}
+			if _, _, err := dec.Next(); err != io.EOF {
+				t.Errorf("Expected io.EOF after last record, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParquetDecoder_RandomAccessNotSpooled(t *testing.T) {
+	f, _ := format.Lookup("parquet")
+	var buf bytes.Buffer
+	enc, _ := f.NewEncoder(&buf, testSchema)
+	enc.Encode(format.Record{"id": "a1", "active": true, "created_at": time.Now()})
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Spooling would fail: the temp directory does not exist
+	t.Setenv("TMPDIR", t.TempDir()+"/missing")
+	dec, err := f.NewDecoder(bytes.NewReader(buf.Bytes()), testSchema, format.DecodeOptions{})
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	defer dec.Close()
+	if rec, _, err := dec.Next(); err != nil || rec.Text("id") != "a1" {
+		t.Errorf("Expected record a1, got %v, %v", rec, err)
+	}
+}
+
+// sameSecond tolerates formats that store timestamps at second precision
+// (XLSX dates)
+func sameSecond(a, b string) bool {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	return errA == nil && errB == nil && ta.Truncate(time.Second).Equal(tb.Truncate(time.Second))
+}
+
+func TestJSONEncoder_SchemaOrder(t *testing.T) {
+	f, _ := format.Lookup("json")
+	var buf bytes.Buffer
+	enc, _ := f.NewEncoder(&buf, testSchema)
+	enc.Encode(format.Record{"id": "a1", "active": true, "tags": []string{"x"}, "created_at": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	enc.Close()
+
+	want := `[{"id":"a1","active":true,"tags":["x"],"created_at":"2024-01-01T00:00:00Z"}]`
+	if buf.String() != want {
+		t.Errorf("Expected %s, got %s", want, buf.String())
+	}
+}
+
+func TestJSONDecoder_ArrayAndLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"array", ` [{"id":"a1"}, 42, {"id":"a3"}]`},
+		{"ndjson", "{\"id\":\"a1\"}\n42\n{\"id\":\"a3\"}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := format.Lookup("json")
+			dec, err := f.NewDecoder(strings.NewReader(tt.input), testSchema, format.DecodeOptions{})
+			if err != nil {
+				t.Fatalf("NewDecoder: %v", err)
+			}
+
+			rec, line, err := dec.Next()
+			if err != nil || rec.Text("id") != "a1" || line != 1 {
+				t.Fatalf("Expected a1 at 1, got %v at %d (%v)", rec, line, err)
+			}
+
+			_, line, err = dec.Next()
+			var recErr *format.RecordError
+			if !errors.As(err, &recErr) || recErr.Field != "json" || line != 2 {
+				t.Fatalf("Expected a json RecordError at 2, got %v at %d", err, line)
+			}
+
+			rec, line, err = dec.Next()
+			if err != nil || rec.Text("id") != "a3" || line != 3 {
+				t.Fatalf("Expected a3 at 3, got %v at %d (%v)", rec, line, err)
+			}
+
+			if _, _, err := dec.Next(); err != io.EOF {
+				t.Errorf("Expected io.EOF, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCSVDecoder_ReadErrorStops(t *testing.T) {
+	cut := errors.New("connection reset")
+	body := io.MultiReader(strings.NewReader("id,active\na1,true\n\"a2,true\na3"), iotest.ErrReader(cut))
+
+	f, _ := format.Lookup("csv")
+	dec, err := f.NewDecoder(body, testSchema, format.DecodeOptions{})
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+
+	if rec, _, err := dec.Next(); err != nil || rec.Text("id") != "a1" {
+		t.Fatalf("Expected a1, got %v (%v)", rec, err)
+	}
+	if _, _, err := dec.Next(); !errors.Is(err, cut) {
+		t.Errorf("Expected the read error to be returned, got %v", err)
+	}
+}
+
+func TestRecordList(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    []string
+		wantErr bool
+	}{
+		{"missing", nil, nil, false},
+		{"typed", []string{"a"}, []string{"a"}, false},
+		{"json decoded", []any{"a", "b"}, []string{"a", "b"}, false},
+		{"json decoded non-string", []any{"a", 1.0}, nil, true},
+		{"json text", `["a","b"]`, []string{"a", "b"}, false},
+		{"comma separated", "a, b,,c", []string{"a", "b", "c"}, false},
+		{"wrong type", 42.0, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := format.Record{"tags": tt.value}.List("tags")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCSVDecoder_HeaderAsWritten(t *testing.T) {
+	f, _ := format.Lookup("csv")
+	dec, err := f.NewDecoder(strings.NewReader("ID, Active \na1,true\n"), testSchema, format.DecodeOptions{})
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+
+	h, ok := dec.(format.HeaderDecoder)
+	if !ok {
+		t.Fatal("Expected the CSV decoder to expose its header")
+	}
+	if got := h.Header(); !reflect.DeepEqual(got, []string{"ID", " Active "}) {
+		t.Errorf("Expected the header as written, got %q", got)
+	}
+	if rec, _, err := dec.Next(); err != nil || rec.Text("active") != "true" {
+		t.Errorf("Expected records keyed by the normalized header, got %v (%v)", rec, err)
+	}
+}
+
+func TestRecordText_DecodedJSON(t *testing.T) {
+	rec := format.Record{"tags": []any{"a", "b"}, "meta": map[string]any{"k": 1.0}}
+	if got := rec.Text("tags"); got != `["a","b"]` {
+		t.Errorf("Expected a JSON array, got %s", got)
+	}
+	if got := rec.Text("meta"); got != `{"k":1}` {
+		t.Errorf("Expected a JSON object, got %s", got)
+	}
+}
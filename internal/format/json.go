@@ -0,0 +1,230 @@
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ndjsonFormat = &Format{
+	Name:       "ndjson",
+	MIMEType:   "application/x-ndjson",
+	Extensions: []string{".ndjson", ".jsonl"},
+	NewDecoder: newNDJSONDecoder,
+	NewEncoder: newNDJSONEncoder,
+}
+
+var jsonFormat = &Format{
+	Name:       "json",
+	MIMEType:   "application/json",
+	Extensions: []string{".json"},
+	NewDecoder: newJSONDecoder,
+	NewEncoder: newJSONEncoder,
+}
+
+// NDJSON
+
+// ndjsonDecoder decodes one JSON object per line, skipping blank lines
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+	lineNum int
+}
+
+func newNDJSONDecoder(r io.Reader, _ *Schema, _ DecodeOptions) (RecordDecoder, error) {
+	scanner := bufio.NewScanner(r)
+	// Increase buffer size for long lines
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+	return &ndjsonDecoder{scanner: scanner}, nil
+}
+
+func (d *ndjsonDecoder) Next() (Record, int, error) {
+	for d.scanner.Scan() {
+		d.lineNum++
+		line := d.scanner.Bytes()
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil || rec == nil {
+			if err == nil {
+				err = fmt.Errorf("expected an object")
+			}
+			return nil, d.lineNum, &RecordError{
+				Line:    d.lineNum,
+				Field:   "json",
+				Message: fmt.Sprintf("invalid JSON: %v", err),
+			}
+		}
+		return rec, d.lineNum, nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return nil, d.lineNum, err
+	}
+	return nil, d.lineNum, io.EOF
+}
+
+func (d *ndjsonDecoder) Close() error { return nil }
+
+type ndjsonEncoder struct {
+	w      io.Writer
+	schema *Schema
+	buf    bytes.Buffer
+}
+
+func newNDJSONEncoder(w io.Writer, schema *Schema) (RecordEncoder, error) {
+	return &ndjsonEncoder{w: w, schema: schema}, nil
+}
+
+func (e *ndjsonEncoder) Encode(rec Record) error {
+	e.buf.Reset()
+	if err := appendJSONObject(&e.buf, e.schema, rec); err != nil {
+		return err
+	}
+	e.buf.WriteByte('\n')
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+func (e *ndjsonEncoder) Flush() error { return nil }
+func (e *ndjsonEncoder) Close() error { return nil }
+
+// JSON
+
+// newJSONDecoder accepts either a top-level array of objects, streamed one
+// element at a time, or newline-delimited objects. Array elements are
+// numbered from 1 in place of line numbers.
+func newJSONDecoder(r io.Reader, schema *Schema, opts DecodeOptions) (RecordDecoder, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return newNDJSONDecoder(br, schema, opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+			continue
+		}
+		br.UnreadByte()
+		if b != '[' {
+			return newNDJSONDecoder(br, schema, opts)
+		}
+		break
+	}
+
+	dec := json.NewDecoder(br)
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return &jsonArrayDecoder{dec: dec}, nil
+}
+
+type jsonArrayDecoder struct {
+	dec     *json.Decoder
+	lineNum int
+}
+
+func (d *jsonArrayDecoder) Next() (Record, int, error) {
+	if !d.dec.More() {
+		return nil, d.lineNum, io.EOF
+	}
+	d.lineNum++
+
+	var rec Record
+	err := d.dec.Decode(&rec)
+	if _, ok := err.(*json.UnmarshalTypeError); ok || (err == nil && rec == nil) {
+		// The element was consumed, so decoding can carry on
+		if err == nil {
+			err = fmt.Errorf("expected an object")
+		}
+		return nil, d.lineNum, &RecordError{
+			Line:    d.lineNum,
+			Field:   "json",
+			Message: fmt.Sprintf("invalid JSON: %v", err),
+		}
+	}
+	if err != nil {
+		// Syntax errors leave the stream in an unknown state
+		return nil, d.lineNum, fmt.Errorf("invalid JSON at element %d: %w", d.lineNum, err)
+	}
+	return rec, d.lineNum, nil
+}
+
+func (d *jsonArrayDecoder) Close() error { return nil }
+
+type jsonEncoder struct {
+	w      io.Writer
+	schema *Schema
+	buf    bytes.Buffer
+	first  bool
+}
+
+func newJSONEncoder(w io.Writer, schema *Schema) (RecordEncoder, error) {
+	if _, err := w.Write([]byte("[")); err != nil {
+		return nil, err
+	}
+	return &jsonEncoder{w: w, schema: schema, first: true}, nil
+}
+
+func (e *jsonEncoder) Encode(rec Record) error {
+	e.buf.Reset()
+	if !e.first {
+		e.buf.WriteByte(',')
+	}
+	e.first = false
+	if err := appendJSONObject(&e.buf, e.schema, rec); err != nil {
+		return err
+	}
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+func (e *jsonEncoder) Flush() error { return nil }
+
+func (e *jsonEncoder) Close() error {
+	_, err := e.w.Write([]byte("]"))
+	return err
+}
+
+// appendJSONObject writes rec as a JSON object with keys in schema order.
+// Null values of nullable fields are omitted.
+func appendJSONObject(buf *bytes.Buffer, schema *Schema, rec Record) error {
+	buf.WriteByte('{')
+	first := true
+	for _, f := range schema.Fields {
+		v := rec[f.Name]
+		if f.Nullable && isNull(v) {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		key, _ := json.Marshal(f.Name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func isNull(v any) bool {
+	if t, ok := v.(*time.Time); ok {
+		return t == nil
+	}
+	return v == nil
+}
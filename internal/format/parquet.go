@@ -0,0 +1,273 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRowGroupSize is the number of rows buffered before a row group is
+// flushed to the output. 10K rows keeps memory bounded (~a few MB for
+// articles) while producing row groups large enough for DuckDB/Spark to scan
+// efficiently.
+const parquetRowGroupSize = 10000
+
+// parquetReadBatch is the number of rows decoded from the file at a time
+const parquetReadBatch = 256
+
+var parquetFormat = &Format{
+	Name:       "parquet",
+	MIMEType:   "application/vnd.apache.parquet",
+	Extensions: []string{".parquet"},
+	NewDecoder: newParquetDecoder,
+	NewEncoder: newParquetEncoder,
+}
+
+// parquetRowType builds a struct type mirroring the schema so parquet-go
+// can derive the file schema from `parquet` tags: strings are STRING,
+// timestamps TIMESTAMP_MICROS (optional when nullable) and string lists a
+// LIST of STRING. Columns keep schema order.
+func parquetRowType(schema *Schema) reflect.Type {
+	fields := make([]reflect.StructField, len(schema.Fields))
+	for i, f := range schema.Fields {
+		var typ reflect.Type
+		tag := f.Name
+		switch f.Type {
+		case Bool:
+			typ = reflect.TypeOf(false)
+		case Timestamp:
+			typ = reflect.TypeOf(time.Time{})
+			tag += ",timestamp(microsecond)"
+			if f.Nullable {
+				typ = reflect.TypeOf(&time.Time{})
+				tag = f.Name + ",optional,timestamp(microsecond)"
+			}
+		case StringList:
+			typ = reflect.TypeOf([]string{})
+			tag += ",list"
+		default:
+			typ = reflect.TypeOf("")
+		}
+		fields[i] = reflect.StructField{
+			Name: "F" + strconv.Itoa(i),
+			Type: typ,
+			Tag:  reflect.StructTag(`parquet:"` + tag + `"`),
+		}
+	}
+	return reflect.StructOf(fields)
+}
+
+// parquetEncoder writes records to a Parquet file, emitting one row group
+// per parquetRowGroupSize rows. Parquet only needs to seek when reading, so
+// the file can be streamed straight to the client; the footer is written on
+// Close.
+type parquetEncoder struct {
+	writer  *parquet.Writer
+	schema  *Schema
+	rowType reflect.Type
+	pending int
+}
+
+func newParquetEncoder(w io.Writer, schema *Schema) (RecordEncoder, error) {
+	rowType := parquetRowType(schema)
+	pqSchema := parquet.SchemaOf(reflect.New(rowType).Interface())
+	return &parquetEncoder{
+		writer:  parquet.NewWriter(w, pqSchema),
+		schema:  schema,
+		rowType: rowType,
+	}, nil
+}
+
+func (e *parquetEncoder) Encode(rec Record) error {
+	row := reflect.New(e.rowType).Elem()
+	for i, f := range e.schema.Fields {
+		v := rec[f.Name]
+		field := row.Field(i)
+		switch f.Type {
+		case Bool:
+			field.SetBool(boolValue(v))
+		case Timestamp:
+			t, ok := timeValue(v)
+			if f.Nullable {
+				if ok {
+					field.Set(reflect.ValueOf(&t))
+				}
+			} else {
+				field.Set(reflect.ValueOf(t))
+			}
+		case StringList:
+			field.Set(reflect.ValueOf(listValue(v)))
+		default:
+			field.SetString(stringValue(v))
+		}
+	}
+
+	if err := e.writer.Write(row.Addr().Interface()); err != nil {
+		return err
+	}
+	e.pending++
+	if e.pending >= parquetRowGroupSize {
+		return e.flushRowGroup()
+	}
+	return nil
+}
+
+// Flush is a no-op: rows are only written out a full row group at a time
+func (e *parquetEncoder) Flush() error { return nil }
+
+func (e *parquetEncoder) flushRowGroup() error {
+	if e.pending == 0 {
+		return nil
+	}
+	e.pending = 0
+	return e.writer.Flush()
+}
+
+func (e *parquetEncoder) Close() error {
+	if err := e.flushRowGroup(); err != nil {
+		return err
+	}
+	return e.writer.Close()
+}
+
+// parquetDecoder reads rows through the schema's struct type, so files
+// produced by Parquet exports re-import unchanged; columns missing from the
+// file decode as empty values. Row numbers are 1-based across the file.
+type parquetDecoder struct {
+	reader  *parquet.Reader
+	schema  *Schema
+	rowType reflect.Type
+	cleanup func()
+	buf     []reflect.Value
+	pos     int
+	rowNum  int
+}
+
+func newParquetDecoder(r io.Reader, schema *Schema, _ DecodeOptions) (RecordDecoder, error) {
+	ra, size, cleanup, err := readerAt(r)
+	if err != nil {
+		return nil, err
+	}
+	file, err := parquet.OpenFile(ra, size)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("invalid parquet file: %w", err)
+	}
+
+	rowType := parquetRowType(schema)
+	reader, err := newParquetReader(file, parquet.SchemaOf(reflect.New(rowType).Interface()))
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	return &parquetDecoder{
+		reader:  reader,
+		schema:  schema,
+		rowType: rowType,
+		cleanup: cleanup,
+	}, nil
+}
+
+// newParquetReader converts the file's row groups to the import schema.
+// parquet-go panics when a column cannot be converted, so that is reported
+// as an invalid file instead.
+func newParquetReader(file *parquet.File, schema *parquet.Schema) (reader *parquet.Reader, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid parquet file: %v", r)
+		}
+	}()
+	return parquet.NewReader(file, schema), nil
+}
+
+func (d *parquetDecoder) Next() (Record, int, error) {
+	if d.pos >= len(d.buf) {
+		if err := d.fill(); err != nil {
+			return nil, d.rowNum, err
+		}
+	}
+
+	row := d.buf[d.pos].Elem()
+	d.pos++
+	d.rowNum++
+
+	rec := make(Record, len(d.schema.Fields))
+	for i, f := range d.schema.Fields {
+		field := row.Field(i)
+		switch {
+		case f.Type == Timestamp && f.Nullable:
+			if !field.IsNil() {
+				rec[f.Name] = field.Elem().Interface()
+			}
+		default:
+			rec[f.Name] = field.Interface()
+		}
+	}
+	return rec, d.rowNum, nil
+}
+
+// fill decodes the next batch of rows
+func (d *parquetDecoder) fill() error {
+	d.buf = d.buf[:0]
+	d.pos = 0
+	for len(d.buf) < parquetReadBatch {
+		row := reflect.New(d.rowType)
+		if err := d.reader.Read(row.Interface()); err != nil {
+			if err == io.EOF && len(d.buf) > 0 {
+				return nil
+			}
+			return err
+		}
+		d.buf = append(d.buf, row)
+	}
+	return nil
+}
+
+func (d *parquetDecoder) Close() error {
+	err := d.reader.Close()
+	d.cleanup()
+	return err
+}
+
+// sizedReaderAt is random access to content of known size, as memory and
+// S3 blobs offer
+type sizedReaderAt interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// readerAt returns random access to r, which Parquet needs to read the
+// footer. Files and sized readers are used directly; other streams are
+// spooled to a temp file.
+func readerAt(r io.Reader) (io.ReaderAt, int64, func(), error) {
+	switch r := r.(type) {
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return r, info.Size(), func() {}, nil
+	case sizedReaderAt:
+		return r, r.Size(), func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp("", "import-*.parquet")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+	return tmp, size, cleanup, nil
+}
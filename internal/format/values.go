@@ -0,0 +1,135 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Text returns a field as the string form the validators work with. Missing
+// and null values are empty, booleans are "true"/"false" and timestamps are
+// RFC 3339 (UTC), so typed sources validate exactly like text ones.
+func (r Record) Text(name string) string {
+	return textValue(r[name])
+}
+
+// List returns a list field as strings. Text sources (CSV, XLSX) may hold
+// either a JSON array or a comma-separated list.
+func (r Record) List(name string) ([]string, error) {
+	switch v := r[name].(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		return v, nil
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of strings", name)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	case string:
+		return parseTextList(name, v)
+	default:
+		return nil, fmt.Errorf("%s must be a list of strings", name)
+	}
+}
+
+func parseTextList(name, s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(s, "[") {
+		var out []string
+		if err := json.Unmarshal([]byte(s), &out); err != nil {
+			return nil, fmt.Errorf("%s must be a list of strings", name)
+		}
+		return out, nil
+	}
+	parts := strings.Split(s, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func textValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return formatTime(v)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return formatTime(*v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		if v == nil {
+			return ""
+		}
+		data, _ := json.Marshal(v)
+		return string(data)
+	case []any, map[string]any:
+		// Decoded JSON lists and objects stay JSON
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatTime renders a timestamp as RFC 3339, leaving missing (zero) values
+// empty so "required" validation still fires
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// timeValue normalizes a timestamp value for typed encoders; ok is false for
+// nulls
+func timeValue(v any) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func boolValue(v any) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+func stringValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return textValue(v)
+}
+
+func listValue(v any) []string {
+	l, _ := v.([]string)
+	return l
+}
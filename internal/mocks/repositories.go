@@ -2,8 +2,13 @@ package mocks
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/repository"
+	"github.com/bulk-import-export-api/internal/storage"
+	"github.com/google/uuid"
 )
 
 // MockUserRepository is a mock implementation of UserRepository
@@ -15,6 +20,8 @@ type MockUserRepository struct {
 	StreamCallback   func(*models.User) error
 	BatchInsertFunc  func(ctx context.Context, users []*models.User) (int, error)
 	BatchInsertCalls int
+	ExistingIDsCalls int
+	LookupError      error
 }
 
 func NewMockUserRepository() *MockUserRepository {
@@ -67,6 +74,30 @@ func (m *MockUserRepository) EmailExists(ctx context.Context, email string) (boo
 	return exists, nil
 }
 
+func (m *MockUserRepository) ExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	var existing []string
+	for _, email := range emails {
+		if _, exists := m.EmailToUser[email]; exists {
+			existing = append(existing, email)
+		}
+	}
+	return existing, nil
+}
+
+func (m *MockUserRepository) ExistingIDs(ctx context.Context, ids []string) ([]string, error) {
+	m.ExistingIDsCalls++
+	if m.LookupError != nil {
+		return nil, m.LookupError
+	}
+	var existing []string
+	for _, id := range ids {
+		if _, exists := m.Users[id]; exists {
+			existing = append(existing, id)
+		}
+	}
+	return existing, nil
+}
+
 func (m *MockUserRepository) GetAllIDs(ctx context.Context) ([]string, error) {
 	ids := make([]string, 0, len(m.Users))
 	for id := range m.Users {
@@ -88,6 +119,60 @@ func (m *MockUserRepository) StreamAll(ctx context.Context, callback func(*model
 	return nil
 }
 
+func (m *MockUserRepository) StreamFiltered(ctx context.Context, filter models.ExportFilter, callback func(*models.User) error) error {
+	var matched []*models.User
+	for _, user := range m.Users {
+		if filter.Matches(user.CreatedAt, user.UpdatedAt, user.ID) {
+			matched = append(matched, user)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+	for _, user := range matched {
+		if err := callback(user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockUserRepository) LatestChange(ctx context.Context) (*models.ChangeCursor, error) {
+	var latest *models.ChangeCursor
+	for _, user := range m.Users {
+		c := models.ChangeCursor{UpdatedAt: user.UpdatedAt, ID: user.ID}
+		if latest == nil || latest.Less(c) {
+			latest = &c
+		}
+	}
+	return latest, nil
+}
+
+func (m *MockUserRepository) StreamChanges(ctx context.Context, after, until models.ChangeCursor, callback func(*models.User) error) error {
+	var changed []*models.User
+	for _, user := range m.Users {
+		c := models.ChangeCursor{UpdatedAt: user.UpdatedAt, ID: user.ID}
+		if after.Less(c) && !until.Less(c) {
+			changed = append(changed, user)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return models.ChangeCursor{UpdatedAt: changed[i].UpdatedAt, ID: changed[i].ID}.Less(models.ChangeCursor{UpdatedAt: changed[j].UpdatedAt, ID: changed[j].ID})
+	})
+	for _, user := range changed {
+		if err := callback(user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // MockArticleRepository is a mock implementation of ArticleRepository
 type MockArticleRepository struct {
 	Articles         map[string]*models.Article
@@ -96,6 +181,7 @@ type MockArticleRepository struct {
 	InsertedCount    int
 	BatchInsertFunc  func(ctx context.Context, articles []*models.Article) (int, error)
 	BatchInsertCalls int
+	ExistingIDsCalls int
 }
 
 func NewMockArticleRepository() *MockArticleRepository {
@@ -144,6 +230,27 @@ func (m *MockArticleRepository) SlugExists(ctx context.Context, slug string) (bo
 	return exists, nil
 }
 
+func (m *MockArticleRepository) ExistingSlugs(ctx context.Context, slugs []string) ([]string, error) {
+	var existing []string
+	for _, slug := range slugs {
+		if _, exists := m.SlugToArticle[slug]; exists {
+			existing = append(existing, slug)
+		}
+	}
+	return existing, nil
+}
+
+func (m *MockArticleRepository) ExistingIDs(ctx context.Context, ids []string) ([]string, error) {
+	m.ExistingIDsCalls++
+	var existing []string
+	for _, id := range ids {
+		if _, exists := m.Articles[id]; exists {
+			existing = append(existing, id)
+		}
+	}
+	return existing, nil
+}
+
 func (m *MockArticleRepository) GetAllIDs(ctx context.Context) ([]string, error) {
 	ids := make([]string, 0, len(m.Articles))
 	for id := range m.Articles {
@@ -165,6 +272,60 @@ func (m *MockArticleRepository) StreamAll(ctx context.Context, callback func(*mo
 	return nil
 }
 
+func (m *MockArticleRepository) StreamFiltered(ctx context.Context, filter models.ExportFilter, callback func(*models.Article) error) error {
+	var matched []*models.Article
+	for _, article := range m.Articles {
+		if filter.Matches(article.CreatedAt, article.UpdatedAt, article.ID) {
+			matched = append(matched, article)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+	for _, article := range matched {
+		if err := callback(article); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockArticleRepository) LatestChange(ctx context.Context) (*models.ChangeCursor, error) {
+	var latest *models.ChangeCursor
+	for _, article := range m.Articles {
+		c := models.ChangeCursor{UpdatedAt: article.UpdatedAt, ID: article.ID}
+		if latest == nil || latest.Less(c) {
+			latest = &c
+		}
+	}
+	return latest, nil
+}
+
+func (m *MockArticleRepository) StreamChanges(ctx context.Context, after, until models.ChangeCursor, callback func(*models.Article) error) error {
+	var changed []*models.Article
+	for _, article := range m.Articles {
+		c := models.ChangeCursor{UpdatedAt: article.UpdatedAt, ID: article.ID}
+		if after.Less(c) && !until.Less(c) {
+			changed = append(changed, article)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return models.ChangeCursor{UpdatedAt: changed[i].UpdatedAt, ID: changed[i].ID}.Less(models.ChangeCursor{UpdatedAt: changed[j].UpdatedAt, ID: changed[j].ID})
+	})
+	for _, article := range changed {
+		if err := callback(article); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // MockCommentRepository is a mock implementation of CommentRepository
 type MockCommentRepository struct {
 	Comments         map[string]*models.Comment
@@ -225,12 +386,70 @@ func (m *MockCommentRepository) StreamAll(ctx context.Context, callback func(*mo
 	return nil
 }
 
+func (m *MockCommentRepository) StreamFiltered(ctx context.Context, filter models.ExportFilter, callback func(*models.Comment) error) error {
+	var matched []*models.Comment
+	for _, comment := range m.Comments {
+		if filter.Matches(comment.CreatedAt, comment.UpdatedAt, comment.ID) {
+			matched = append(matched, comment)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+	for _, comment := range matched {
+		if err := callback(comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockCommentRepository) LatestChange(ctx context.Context) (*models.ChangeCursor, error) {
+	var latest *models.ChangeCursor
+	for _, comment := range m.Comments {
+		c := models.ChangeCursor{UpdatedAt: comment.UpdatedAt, ID: comment.ID}
+		if latest == nil || latest.Less(c) {
+			latest = &c
+		}
+	}
+	return latest, nil
+}
+
+func (m *MockCommentRepository) StreamChanges(ctx context.Context, after, until models.ChangeCursor, callback func(*models.Comment) error) error {
+	var changed []*models.Comment
+	for _, comment := range m.Comments {
+		c := models.ChangeCursor{UpdatedAt: comment.UpdatedAt, ID: comment.ID}
+		if after.Less(c) && !until.Less(c) {
+			changed = append(changed, comment)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return models.ChangeCursor{UpdatedAt: changed[i].UpdatedAt, ID: changed[i].ID}.Less(models.ChangeCursor{UpdatedAt: changed[j].UpdatedAt, ID: changed[j].ID})
+	})
+	for _, comment := range changed {
+		if err := callback(comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // MockJobRepository is a mock implementation of JobRepository
 type MockJobRepository struct {
 	Jobs            map[string]*models.Job
 	IdempotencyJobs map[string]*models.Job
 	Errors          map[string][]models.ValidationError
+	Transforms      map[string][]models.TransformRecord
+	Rejects         map[string][]models.RejectedRecord
+	Statuses        map[string]models.JobStatus // status of each job as last written
 	CreateError     error
+	CreateFunc      func(ctx context.Context, job *models.Job) error
 	UpdateError     error
 }
 
@@ -239,14 +458,23 @@ func NewMockJobRepository() *MockJobRepository {
 		Jobs:            make(map[string]*models.Job),
 		IdempotencyJobs: make(map[string]*models.Job),
 		Errors:          make(map[string][]models.ValidationError),
+		Transforms:      make(map[string][]models.TransformRecord),
+		Rejects:         make(map[string][]models.RejectedRecord),
+		Statuses:        make(map[string]models.JobStatus),
 	}
 }
 
 func (m *MockJobRepository) Create(ctx context.Context, job *models.Job) error {
+	if m.CreateFunc != nil {
+		if err := m.CreateFunc(ctx, job); err != nil {
+			return err
+		}
+	}
 	if m.CreateError != nil {
 		return m.CreateError
 	}
 	m.Jobs[job.ID] = job
+	m.Statuses[job.ID] = job.Status
 	if job.IdempotencyKey != "" {
 		m.IdempotencyJobs[job.IdempotencyKey] = job
 	}
@@ -254,10 +482,14 @@ func (m *MockJobRepository) Create(ctx context.Context, job *models.Job) error {
 }
 
 func (m *MockJobRepository) Update(ctx context.Context, job *models.Job) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if m.UpdateError != nil {
 		return m.UpdateError
 	}
 	m.Jobs[job.ID] = job
+	m.Statuses[job.ID] = job.Status
 	return nil
 }
 
@@ -269,10 +501,29 @@ func (m *MockJobRepository) GetByIdempotencyKey(ctx context.Context, key string)
 	return m.IdempotencyJobs[key], nil
 }
 
+func (m *MockJobRepository) GetChildren(ctx context.Context, parentID string) ([]*models.Job, error) {
+	var children []*models.Job
+	for _, job := range m.Jobs {
+		if job.ParentJobID == parentID {
+			children = append(children, job)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if !children[i].CreatedAt.Equal(children[j].CreatedAt) {
+			return children[i].CreatedAt.Before(children[j].CreatedAt)
+		}
+		return children[i].ID < children[j].ID
+	})
+	return children, nil
+}
+
 func (m *MockJobRepository) GetPendingJobs(ctx context.Context) ([]*models.Job, error) {
 	var pending []*models.Job
 	for _, job := range m.Jobs {
-		if job.Status == models.JobStatusPending {
+		if job.Status != models.JobStatusPending {
+			continue
+		}
+		if parent, ok := m.Jobs[job.ParentJobID]; !ok || parent.Resource != models.ResourceBundle {
 			pending = append(pending, job)
 		}
 	}
@@ -293,7 +544,23 @@ func (m *MockJobRepository) AddError(ctx context.Context, jobID string, err *mod
 	return nil
 }
 
+func (m *MockJobRepository) OldestRunningImport(ctx context.Context) (*time.Time, error) {
+	var oldest *time.Time
+	for _, job := range m.Jobs {
+		if job.Type != models.JobTypeImport || job.Status != models.JobStatusProcessing || job.StartedAt == nil {
+			continue
+		}
+		if oldest == nil || job.StartedAt.Before(*oldest) {
+			oldest = job.StartedAt
+		}
+	}
+	return oldest, nil
+}
+
 func (m *MockJobRepository) AddErrors(ctx context.Context, jobID string, errors []models.ValidationError) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	m.Errors[jobID] = append(m.Errors[jobID], errors...)
 	return nil
 }
@@ -305,3 +572,438 @@ func (m *MockJobRepository) GetErrors(ctx context.Context, jobID string, limit i
 	}
 	return errors, nil
 }
+
+// errorsOf returns a job's issues in (line, id) order, numbered in the
+// order they were added like the job_errors sequence
+func (m *MockJobRepository) errorsOf(jobID string) []models.ValidationError {
+	issues := make([]models.ValidationError, len(m.Errors[jobID]))
+	for i, e := range m.Errors[jobID] {
+		e.ID = int64(i + 1)
+		issues[i] = e
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+func (m *MockJobRepository) StreamErrors(ctx context.Context, filter models.ErrorFilter, callback func(*models.ValidationError) error) error {
+	count := 0
+	for _, e := range m.errorsOf(filter.JobID) {
+		if !filter.Matches(&e) {
+			continue
+		}
+		if filter.Limit > 0 && count == filter.Limit {
+			break
+		}
+		count++
+		if err := callback(&e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockJobRepository) CountErrors(ctx context.Context, filter models.ErrorFilter) (map[string]int, error) {
+	filter.After = nil
+	counts := make(map[string]int)
+	for _, e := range m.errorsOf(filter.JobID) {
+		if filter.Matches(&e) {
+			counts[mockSeverity(e.Severity)]++
+		}
+	}
+	return counts, nil
+}
+
+func (m *MockJobRepository) SummarizeErrors(ctx context.Context, filter models.ErrorFilter, limit int) ([]models.ErrorGroup, error) {
+	filter.After = nil
+	var groups []models.ErrorGroup
+	index := make(map[models.ErrorGroup]int)
+	for _, e := range m.errorsOf(filter.JobID) {
+		if !filter.Matches(&e) {
+			continue
+		}
+		key := models.ErrorGroup{Field: e.Field, Message: e.Message, Severity: mockSeverity(e.Severity), Code: e.Code}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			key.FirstLine = e.Line
+			groups = append(groups, key)
+		}
+		groups[i].Count++
+		groups[i].LastLine = e.Line
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		if a.Message != b.Message {
+			return a.Message < b.Message
+		}
+		if a.Severity != b.Severity {
+			return a.Severity < b.Severity
+		}
+		return a.Code < b.Code
+	})
+	if limit > 0 && len(groups) > limit {
+		groups = groups[:limit]
+	}
+	return groups, nil
+}
+
+// mockSeverity stores issues without a severity as errors, like the database
+func mockSeverity(severity string) string {
+	if severity == "" {
+		return models.SeverityError
+	}
+	return severity
+}
+
+func (m *MockJobRepository) AddTransforms(ctx context.Context, jobID string, applied []models.TransformRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Transforms[jobID] = append(m.Transforms[jobID], applied...)
+	return nil
+}
+
+func (m *MockJobRepository) GetTransforms(ctx context.Context, jobID string, limit int) ([]models.TransformRecord, error) {
+	applied := m.Transforms[jobID]
+	if limit > 0 && len(applied) > limit {
+		return applied[:limit], nil
+	}
+	return applied, nil
+}
+
+func (m *MockJobRepository) AddRecovered(ctx context.Context, jobID string, recovered int) error {
+	job, ok := m.Jobs[jobID]
+	if !ok {
+		return nil
+	}
+	targets := []*models.Job{job}
+	if parent, ok := m.Jobs[job.ParentJobID]; ok && parent.Resource == models.ResourceBundle {
+		targets = append(targets, parent)
+	}
+	for _, j := range targets {
+		j.SuccessfulCount += recovered
+		j.FailedCount = max(j.FailedCount-recovered, 0)
+	}
+	return nil
+}
+
+func (m *MockJobRepository) AddRejects(ctx context.Context, jobID string, rejects []models.RejectedRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Rejects[jobID] = append(m.Rejects[jobID], rejects...)
+	return nil
+}
+
+// StreamRejects mirrors ORDER BY line_number, id
+func (m *MockJobRepository) StreamRejects(ctx context.Context, jobID string, retryableOnly bool, callback func(*models.RejectedRecord) error) error {
+	var rejects []models.RejectedRecord
+	for _, rej := range m.Rejects[jobID] {
+		if rej.Retryable || !retryableOnly {
+			rejects = append(rejects, rej)
+		}
+	}
+	sort.SliceStable(rejects, func(i, j int) bool {
+		return rejects[i].Line < rejects[j].Line
+	})
+	for i := range rejects {
+		if err := callback(&rejects[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finishedAt mirrors COALESCE(completed_at, created_at)
+func finishedAt(job *models.Job) time.Time {
+	if job.CompletedAt != nil {
+		return *job.CompletedAt
+	}
+	return job.CreatedAt
+}
+
+func (m *MockJobRepository) GetFinishedWithFiles(ctx context.Context, jobType models.JobType, statuses []models.JobStatus, before time.Time, afterID string, limit int) ([]*models.Job, error) {
+	var jobs []*models.Job
+	for _, job := range m.Jobs {
+		if job.Type != jobType || job.FilePath == "" || job.ID <= afterID || !finishedAt(job).Before(before) {
+			continue
+		}
+		for _, status := range statuses {
+			if job.Status == status {
+				jobs = append(jobs, job)
+				break
+			}
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+func (m *MockJobRepository) ClearFilePath(ctx context.Context, id string) error {
+	if job, ok := m.Jobs[id]; ok {
+		job.FilePath = ""
+	}
+	return nil
+}
+
+// retainsFile reports whether a job holds a file in the service's storage
+func retainsFile(job *models.Job) bool {
+	return job.FilePath != "" && !storage.IsS3(job.FilePath)
+}
+
+func (m *MockJobRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error) {
+	deleted := 0
+	for id, job := range m.Jobs {
+		if job.ParentJobID != "" || retainsFile(job) || !finishedAt(job).Before(before) {
+			continue
+		}
+		if job.Status != models.JobStatusCompleted && job.Status != models.JobStatusFailed && job.Status != models.JobStatusCancelled {
+			continue
+		}
+		// Children, and retries of a bundle's children
+		children, _ := m.GetChildren(ctx, id)
+		for _, child := range children {
+			retries, _ := m.GetChildren(ctx, child.ID)
+			children = append(children, retries...)
+		}
+		retained := false
+		for _, child := range children {
+			retained = retained || retainsFile(child)
+		}
+		if retained {
+			continue
+		}
+		for _, child := range children {
+			delete(m.Jobs, child.ID)
+			delete(m.Errors, child.ID)
+			delete(m.Transforms, child.ID)
+			delete(m.Rejects, child.ID)
+			if child.IdempotencyKey != "" {
+				delete(m.IdempotencyJobs, child.IdempotencyKey)
+			}
+		}
+		delete(m.Jobs, id)
+		delete(m.Errors, id)
+		delete(m.Transforms, id)
+		delete(m.Rejects, id)
+		if job.IdempotencyKey != "" {
+			delete(m.IdempotencyJobs, job.IdempotencyKey)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// MockUploadRepository is a mock implementation of UploadRepository
+type MockUploadRepository struct {
+	Uploads     map[string]*models.Upload
+	CreateError error
+}
+
+func NewMockUploadRepository() *MockUploadRepository {
+	return &MockUploadRepository{
+		Uploads: make(map[string]*models.Upload),
+	}
+}
+
+func (m *MockUploadRepository) Create(ctx context.Context, upload *models.Upload) error {
+	if m.CreateError != nil {
+		return m.CreateError
+	}
+	stored := *upload
+	m.Uploads[upload.ID] = &stored
+	return nil
+}
+
+func (m *MockUploadRepository) GetByID(ctx context.Context, id string) (*models.Upload, error) {
+	upload, exists := m.Uploads[id]
+	if !exists {
+		return nil, nil
+	}
+	copied := *upload
+	return &copied, nil
+}
+
+func (m *MockUploadRepository) GetByIdempotencyKey(ctx context.Context, key string) (*models.Upload, error) {
+	for _, upload := range m.Uploads {
+		if upload.IdempotencyKey == key {
+			copied := *upload
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockUploadRepository) UpdateOffset(ctx context.Context, id string, offset int64, expiresAt time.Time) error {
+	if upload, exists := m.Uploads[id]; exists {
+		upload.Offset = offset
+		upload.ExpiresAt = expiresAt
+		upload.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (m *MockUploadRepository) SetJobID(ctx context.Context, id, jobID string) error {
+	if upload, exists := m.Uploads[id]; exists {
+		upload.JobID = jobID
+		upload.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (m *MockUploadRepository) Delete(ctx context.Context, id string) error {
+	delete(m.Uploads, id)
+	return nil
+}
+
+func (m *MockUploadRepository) GetExpired(ctx context.Context, before time.Time) ([]*models.Upload, error) {
+	var expired []*models.Upload
+	for _, upload := range m.Uploads {
+		if upload.ExpiresAt.Before(before) {
+			copied := *upload
+			expired = append(expired, &copied)
+		}
+	}
+	return expired, nil
+}
+
+// MockExternalIDRepository is a mock implementation of ExternalIDRepository
+type MockExternalIDRepository struct {
+	Mappings map[string]*models.ExternalID // keyed by resource/source/external ID
+}
+
+// Verify interface compliance
+var _ repository.ExternalIDRepository = (*MockExternalIDRepository)(nil)
+
+func NewMockExternalIDRepository() *MockExternalIDRepository {
+	return &MockExternalIDRepository{
+		Mappings: make(map[string]*models.ExternalID),
+	}
+}
+
+func externalIDKey(resource, source, externalID string) string {
+	return resource + "/" + source + "/" + externalID
+}
+
+func (m *MockExternalIDRepository) Assign(ctx context.Context, resource, source string, externalIDs []string) (map[string]string, error) {
+	for _, externalID := range externalIDs {
+		key := externalIDKey(resource, source, externalID)
+		if _, exists := m.Mappings[key]; !exists {
+			m.Mappings[key] = &models.ExternalID{
+				Resource:   resource,
+				Source:     source,
+				ExternalID: externalID,
+				InternalID: uuid.New().String(),
+				CreatedAt:  time.Now(),
+			}
+		}
+	}
+	return m.Resolve(ctx, resource, source, externalIDs)
+}
+
+func (m *MockExternalIDRepository) Resolve(ctx context.Context, resource, source string, externalIDs []string) (map[string]string, error) {
+	mapped := make(map[string]string)
+	for _, externalID := range externalIDs {
+		if mapping, exists := m.Mappings[externalIDKey(resource, source, externalID)]; exists {
+			mapped[externalID] = mapping.InternalID
+		}
+	}
+	return mapped, nil
+}
+
+func (m *MockExternalIDRepository) List(ctx context.Context, filter models.ExternalIDFilter) ([]models.ExternalID, error) {
+	mappings := []models.ExternalID{}
+	for _, mapping := range m.Mappings {
+		if mapping.Resource != filter.Resource ||
+			(filter.Source != "" && mapping.Source != filter.Source) ||
+			(filter.ExternalID != "" && mapping.ExternalID != filter.ExternalID) ||
+			(filter.InternalID != "" && mapping.InternalID != filter.InternalID) ||
+			(filter.After != "" && mapping.ExternalID <= filter.After) {
+			continue
+		}
+		mappings = append(mappings, *mapping)
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		if mappings[i].Source != mappings[j].Source {
+			return mappings[i].Source < mappings[j].Source
+		}
+		return mappings[i].ExternalID < mappings[j].ExternalID
+	})
+	if filter.Limit > 0 && len(mappings) > filter.Limit {
+		mappings = mappings[:filter.Limit]
+	}
+	return mappings, nil
+}
+
+// MockSnapshotRepository is a mock implementation of SnapshotRepository. A
+// snapshot copies the repositories' rows when it starts, so writes made
+// during fn are not visible to it, as with REPEATABLE READ.
+type MockSnapshotRepository struct {
+	Users    *MockUserRepository
+	Articles *MockArticleRepository
+	Comments *MockCommentRepository
+}
+
+func NewMockSnapshotRepository(users *MockUserRepository, articles *MockArticleRepository, comments *MockCommentRepository) *MockSnapshotRepository {
+	return &MockSnapshotRepository{Users: users, Articles: articles, Comments: comments}
+}
+
+func (m *MockSnapshotRepository) WithSnapshot(ctx context.Context, fn func(repository.Snapshot) error) error {
+	snap := &mockSnapshot{}
+	for _, u := range m.Users.Users {
+		snap.users = append(snap.users, u)
+	}
+	for _, a := range m.Articles.Articles {
+		snap.articles = append(snap.articles, a)
+	}
+	for _, c := range m.Comments.Comments {
+		snap.comments = append(snap.comments, c)
+	}
+	sort.Slice(snap.users, func(i, j int) bool { return snap.users[i].ID < snap.users[j].ID })
+	sort.Slice(snap.articles, func(i, j int) bool { return snap.articles[i].ID < snap.articles[j].ID })
+	sort.Slice(snap.comments, func(i, j int) bool { return snap.comments[i].ID < snap.comments[j].ID })
+	return fn(snap)
+}
+
+type mockSnapshot struct {
+	users    []*models.User
+	articles []*models.Article
+	comments []*models.Comment
+}
+
+func (s *mockSnapshot) StreamUsers(ctx context.Context, callback func(*models.User) error) error {
+	for _, u := range s.users {
+		if err := callback(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *mockSnapshot) StreamArticles(ctx context.Context, callback func(*models.Article) error) error {
+	for _, a := range s.articles {
+		if err := callback(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *mockSnapshot) StreamComments(ctx context.Context, callback func(*models.Comment) error) error {
+	for _, c := range s.comments {
+		if err := callback(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
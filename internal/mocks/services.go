@@ -2,7 +2,9 @@ package mocks
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/bulk-import-export-api/internal/models"
 	"github.com/bulk-import-export-api/internal/service"
@@ -10,10 +12,13 @@ import (
 
 // MockImportService is a mock implementation of ImportService
 type MockImportService struct {
-	CreateJobFunc func(ctx context.Context, req *models.ImportRequest, filePath string) (*models.Job, error)
-	ProcessFunc   func(ctx context.Context, job *models.Job) error
-	ProcessedJobs []*models.Job
-	CreatedJobs   []*models.Job
+	CreateJobFunc    func(ctx context.Context, req *models.ImportRequest, filePath string) (*models.Job, error)
+	CreateBundleFunc func(ctx context.Context, req *models.ImportRequest, archivePath string) (*models.Job, error)
+	StreamFunc       func(ctx context.Context, req *models.ImportRequest, format string, body io.Reader, report func(models.LineResult) error) (*models.Job, error)
+	ProcessFunc      func(ctx context.Context, job *models.Job) error
+	RetryFunc        func(ctx context.Context, jobID string, req *models.ImportRequest, filePath string) (*models.Job, error)
+	ProcessedJobs    []*models.Job
+	CreatedJobs      []*models.Job
 }
 
 // Verify interface compliance
@@ -39,6 +44,47 @@ func (m *MockImportService) CreateImportJob(ctx context.Context, req *models.Imp
 	return job, nil
 }
 
+func (m *MockImportService) CreateBundleImportJob(ctx context.Context, req *models.ImportRequest, archivePath string) (*models.Job, error) {
+	if m.CreateBundleFunc != nil {
+		return m.CreateBundleFunc(ctx, req, archivePath)
+	}
+	job := &models.Job{
+		ID:       "test-bundle-job-id",
+		Resource: models.ResourceBundle,
+		Status:   models.JobStatusPending,
+	}
+	m.CreatedJobs = append(m.CreatedJobs, job)
+	return job, nil
+}
+
+func (m *MockImportService) StreamImport(ctx context.Context, req *models.ImportRequest, format string, body io.Reader, report func(models.LineResult) error) (*models.Job, error) {
+	if m.StreamFunc != nil {
+		return m.StreamFunc(ctx, req, format, body, report)
+	}
+	job := &models.Job{
+		ID:       "test-stream-job-id",
+		Resource: req.Resource,
+		Status:   models.JobStatusCompleted,
+	}
+	m.CreatedJobs = append(m.CreatedJobs, job)
+	return job, nil
+}
+
+func (m *MockImportService) RetryImport(ctx context.Context, jobID string, req *models.ImportRequest, filePath string) (*models.Job, error) {
+	if m.RetryFunc != nil {
+		return m.RetryFunc(ctx, jobID, req, filePath)
+	}
+	job := &models.Job{
+		ID:          "test-retry-job-id",
+		Resource:    "users",
+		Status:      models.JobStatusPending,
+		ParentJobID: jobID,
+		FilePath:    filePath,
+	}
+	m.CreatedJobs = append(m.CreatedJobs, job)
+	return job, nil
+}
+
 func (m *MockImportService) ProcessImport(ctx context.Context, job *models.Job) error {
 	if m.ProcessFunc != nil {
 		return m.ProcessFunc(ctx, job)
@@ -53,6 +99,11 @@ type MockExportService struct {
 	StreamUsersFunc    func(ctx context.Context, w http.ResponseWriter, format string) error
 	StreamArticlesFunc func(ctx context.Context, w http.ResponseWriter, format string) error
 	StreamCommentsFunc func(ctx context.Context, w http.ResponseWriter, format string) error
+	StreamBundleFunc   func(ctx context.Context, w http.ResponseWriter, format string) error
+	StreamFromFunc     func(ctx context.Context, w http.ResponseWriter, resource, format string, after models.PageCursor) error
+	StreamChangesFunc  func(ctx context.Context, w http.ResponseWriter, resource, format string, after models.ChangeCursor) error
+	ListPageFunc       func(ctx context.Context, resource string, filter models.ExportFilter) (*models.ResourcePage, error)
+	CreateJobFunc      func(ctx context.Context, req *models.ExportRequest) (*models.Job, error)
 	Counts             map[string]int
 }
 
@@ -90,15 +141,63 @@ func (m *MockExportService) StreamComments(ctx context.Context, w http.ResponseW
 	return nil
 }
 
+func (m *MockExportService) StreamBundle(ctx context.Context, w http.ResponseWriter, format string) error {
+	if m.StreamBundleFunc != nil {
+		return m.StreamBundleFunc(ctx, w, format)
+	}
+	return nil
+}
+
+func (m *MockExportService) StreamFrom(ctx context.Context, w http.ResponseWriter, resource, format string, after models.PageCursor) error {
+	if m.StreamFromFunc != nil {
+		return m.StreamFromFunc(ctx, w, resource, format, after)
+	}
+	return nil
+}
+
+func (m *MockExportService) StreamChanges(ctx context.Context, w http.ResponseWriter, resource, format string, after models.ChangeCursor) error {
+	if m.StreamChangesFunc != nil {
+		return m.StreamChangesFunc(ctx, w, resource, format, after)
+	}
+	return nil
+}
+
+func (m *MockExportService) ListPage(ctx context.Context, resource string, filter models.ExportFilter) (*models.ResourcePage, error) {
+	if m.ListPageFunc != nil {
+		return m.ListPageFunc(ctx, resource, filter)
+	}
+	return &models.ResourcePage{Resource: resource, Data: []byte("[]"), Limit: filter.Limit}, nil
+}
+
 func (m *MockExportService) GetCount(ctx context.Context, resource string) (int, error) {
 	return m.Counts[resource], nil
 }
 
+func (m *MockExportService) CreateExportJob(ctx context.Context, req *models.ExportRequest) (*models.Job, error) {
+	if m.CreateJobFunc != nil {
+		return m.CreateJobFunc(ctx, req)
+	}
+	return &models.Job{
+		ID:       "test-export-job-id",
+		Type:     models.JobTypeExport,
+		Resource: req.Resource,
+		Status:   models.JobStatusPending,
+		FilePath: req.Destination,
+	}, nil
+}
+
+func (m *MockExportService) ProcessExport(ctx context.Context, job *models.Job) error {
+	return nil
+}
+
 // MockJobService is a mock implementation of JobService
 type MockJobService struct {
 	Jobs          map[string]*models.JobResponse
 	Errors        map[string][]models.ValidationError
+	Transforms    map[string][]models.TransformRecord
+	Rejects       map[string]string // rejects file by job ID, in any format
 	ImportService service.ImportService
+	ExportService service.ExportService
 }
 
 // Verify interface compliance
@@ -106,8 +205,10 @@ var _ service.JobService = (*MockJobService)(nil)
 
 func NewMockJobService() *MockJobService {
 	return &MockJobService{
-		Jobs:   make(map[string]*models.JobResponse),
-		Errors: make(map[string][]models.ValidationError),
+		Jobs:       make(map[string]*models.JobResponse),
+		Errors:     make(map[string][]models.ValidationError),
+		Transforms: make(map[string][]models.TransformRecord),
+		Rejects:    make(map[string]string),
 	}
 }
 
@@ -128,10 +229,242 @@ func (m *MockJobService) GetJobByIdempotencyKey(ctx context.Context, key string)
 	return nil, nil
 }
 
-func (m *MockJobService) GetJobErrors(ctx context.Context, id string) ([]models.ValidationError, error) {
-	return m.Errors[id], nil
+// issues returns the issues of the filter's job it selects, numbered in
+// the order they were added
+func (m *MockJobService) issues(filter models.ErrorFilter) []models.ValidationError {
+	var issues []models.ValidationError
+	for i, e := range m.Errors[filter.JobID] {
+		e.ID = int64(i + 1)
+		if filter.Matches(&e) {
+			issues = append(issues, e)
+		}
+	}
+	return issues
+}
+
+func (m *MockJobService) ListJobErrors(ctx context.Context, filter models.ErrorFilter) (*models.ErrorPage, error) {
+	if err := service.CheckErrorFilter(filter); err != nil {
+		return nil, err
+	}
+	page := &models.ErrorPage{JobID: filter.JobID, Errors: []models.ValidationError{}, Limit: filter.Limit}
+	// Counts cover every page
+	all := filter
+	all.After = nil
+	for _, e := range m.issues(all) {
+		switch e.Severity {
+		case models.SeverityWarning:
+			page.WarningCount++
+		case models.SeverityInfo:
+		default:
+			page.ErrorCount++
+		}
+	}
+	issues := m.issues(filter)
+	if filter.Limit > 0 && len(issues) > filter.Limit {
+		issues = issues[:filter.Limit]
+		last := issues[len(issues)-1]
+		page.NextCursor = service.EncodeErrorCursor(models.ErrorCursor{Line: last.Line, ID: last.ID})
+	}
+	page.Errors = append(page.Errors, issues...)
+	page.Count = len(page.Errors)
+	return page, nil
+}
+
+func (m *MockJobService) StreamJobErrors(ctx context.Context, filter models.ErrorFilter, callback func(*models.ValidationError) error) error {
+	if err := service.CheckErrorFilter(filter); err != nil {
+		return err
+	}
+	issues := m.issues(filter)
+	if filter.Limit > 0 && len(issues) > filter.Limit {
+		issues = issues[:filter.Limit]
+	}
+	for i := range issues {
+		if err := callback(&issues[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockJobService) SummarizeJobErrors(ctx context.Context, filter models.ErrorFilter) (*models.ErrorSummary, error) {
+	if err := service.CheckErrorFilter(filter); err != nil {
+		return nil, err
+	}
+	filter.After = nil
+	summary := &models.ErrorSummary{JobID: filter.JobID, Groups: []models.ErrorGroup{}}
+	for _, e := range m.issues(filter) {
+		severity := e.Severity
+		if severity == "" {
+			severity = models.SeverityError
+		}
+		switch severity {
+		case models.SeverityWarning:
+			summary.WarningCount++
+		case models.SeverityInfo:
+			summary.InfoCount++
+		default:
+			summary.ErrorCount++
+		}
+		found := false
+		for i := range summary.Groups {
+			g := &summary.Groups[i]
+			if g.Field == e.Field && g.Message == e.Message && g.Severity == severity && g.Code == e.Code {
+				g.Count++
+				g.LastLine = e.Line
+				found = true
+				break
+			}
+		}
+		if !found {
+			summary.Groups = append(summary.Groups, models.ErrorGroup{
+				Field: e.Field, Message: e.Message, Severity: severity, Code: e.Code,
+				Count: 1, FirstLine: e.Line, LastLine: e.Line,
+			})
+		}
+	}
+	return summary, nil
+}
+
+func (m *MockJobService) GetJobTransforms(ctx context.Context, id string) ([]models.TransformRecord, error) {
+	return m.Transforms[id], nil
+}
+
+func (m *MockJobService) WriteRejects(ctx context.Context, job *models.Job, format string, w io.Writer) error {
+	_, err := io.WriteString(w, m.Rejects[job.ID])
+	return err
 }
 
 func (m *MockJobService) SetImportService(importService service.ImportService) {
 	m.ImportService = importService
 }
+
+func (m *MockJobService) SetExportService(exportService service.ExportService) {
+	m.ExportService = exportService
+}
+
+// MockUploadService is a mock implementation of UploadService
+type MockUploadService struct {
+	CreateFunc   func(ctx context.Context, req *models.UploadRequest) (*models.Upload, error)
+	WriteFunc    func(ctx context.Context, id string, offset int64, chunk io.Reader) (*models.Upload, error)
+	CompleteFunc func(ctx context.Context, id string) (*models.Job, error)
+	Uploads      map[string]*models.Upload
+}
+
+// Verify interface compliance
+var _ service.UploadService = (*MockUploadService)(nil)
+
+func NewMockUploadService() *MockUploadService {
+	return &MockUploadService{
+		Uploads: make(map[string]*models.Upload),
+	}
+}
+
+func (m *MockUploadService) CreateUpload(ctx context.Context, req *models.UploadRequest) (*models.Upload, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, req)
+	}
+	upload := &models.Upload{
+		ID:       "test-upload-id",
+		Resource: req.Resource,
+		Format:   req.Format,
+		Size:     req.Size,
+	}
+	m.Uploads[upload.ID] = upload
+	return upload, nil
+}
+
+func (m *MockUploadService) GetUpload(ctx context.Context, id string) (*models.Upload, error) {
+	upload, exists := m.Uploads[id]
+	if !exists {
+		return nil, service.ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+func (m *MockUploadService) WriteChunk(ctx context.Context, id string, offset int64, chunk io.Reader) (*models.Upload, error) {
+	if m.WriteFunc != nil {
+		return m.WriteFunc(ctx, id, offset, chunk)
+	}
+	upload, exists := m.Uploads[id]
+	if !exists {
+		return nil, service.ErrUploadNotFound
+	}
+	if offset != upload.Offset {
+		return upload, service.ErrOffsetMismatch
+	}
+	n, err := io.Copy(io.Discard, chunk)
+	upload.Offset += n
+	return upload, err
+}
+
+func (m *MockUploadService) CompleteUpload(ctx context.Context, id string) (*models.Job, error) {
+	if m.CompleteFunc != nil {
+		return m.CompleteFunc(ctx, id)
+	}
+	upload, exists := m.Uploads[id]
+	if !exists {
+		return nil, service.ErrUploadNotFound
+	}
+	if upload.Offset < upload.Size {
+		return nil, service.ErrUploadIncomplete
+	}
+	return &models.Job{ID: "test-job-id", Resource: upload.Resource, Status: models.JobStatusPending}, nil
+}
+
+func (m *MockUploadService) DeleteUpload(ctx context.Context, id string) error {
+	if _, exists := m.Uploads[id]; !exists {
+		return service.ErrUploadNotFound
+	}
+	delete(m.Uploads, id)
+	return nil
+}
+
+func (m *MockUploadService) ExpireUploads(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *MockUploadService) StartSweeper(ctx context.Context) {}
+
+// MockJanitorService is a mock implementation of JanitorService
+type MockJanitorService struct {
+	RunFunc func(ctx context.Context) (*models.CleanupReport, error)
+	Runs    int
+}
+
+// Verify interface compliance
+var _ service.JanitorService = (*MockJanitorService)(nil)
+
+func NewMockJanitorService() *MockJanitorService {
+	return &MockJanitorService{}
+}
+
+func (m *MockJanitorService) Run(ctx context.Context) (*models.CleanupReport, error) {
+	m.Runs++
+	if m.RunFunc != nil {
+		return m.RunFunc(ctx)
+	}
+	return &models.CleanupReport{StartedAt: time.Now()}, nil
+}
+
+func (m *MockJanitorService) Start(ctx context.Context) {}
+
+// MockExternalIDService is a mock implementation of ExternalIDService
+type MockExternalIDService struct {
+	ListFunc func(ctx context.Context, filter models.ExternalIDFilter) (*models.ExternalIDPage, error)
+	Filters  []models.ExternalIDFilter
+}
+
+// Verify interface compliance
+var _ service.ExternalIDService = (*MockExternalIDService)(nil)
+
+func NewMockExternalIDService() *MockExternalIDService {
+	return &MockExternalIDService{}
+}
+
+func (m *MockExternalIDService) List(ctx context.Context, filter models.ExternalIDFilter) (*models.ExternalIDPage, error) {
+	m.Filters = append(m.Filters, filter)
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, filter)
+	}
+	return &models.ExternalIDPage{Resource: filter.Resource, Data: []models.ExternalID{}, Limit: filter.Limit}, nil
+}
@@ -6,17 +6,20 @@ import (
 
 // Article represents an article in the system
 type Article struct {
-	ID          string     `json:"id" db:"id"`
-	Slug        string     `json:"slug" db:"slug"`
-	Title       string     `json:"title" db:"title"`
-	Body        string     `json:"body" db:"body"`
-	AuthorID    string     `json:"author_id" db:"author_id"`
-	Tags        []string   `json:"tags" db:"-"` // Stored as JSON string in DB
-	TagsJSON    string     `json:"-" db:"tags"` // For DB storage
-	Status      string     `json:"status" db:"status"`
-	PublishedAt *time.Time `json:"published_at,omitempty" db:"published_at"`
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	ID          string     `json:"id" db:"id" parquet:"id"`
+	Slug        string     `json:"slug" db:"slug" parquet:"slug"`
+	Title       string     `json:"title" db:"title" parquet:"title"`
+	Body        string     `json:"body" db:"body" parquet:"body"`
+	AuthorID    string     `json:"author_id" db:"author_id" parquet:"author_id"`
+	Tags        []string   `json:"tags" db:"-" parquet:"tags,list"` // Stored as JSON string in DB
+	TagsJSON    string     `json:"-" db:"tags" parquet:"-"`         // For DB storage
+	Status      string     `json:"status" db:"status" parquet:"status"`
+	PublishedAt *time.Time `json:"published_at,omitempty" db:"published_at" parquet:"published_at,optional,timestamp(microsecond)"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at" parquet:"created_at,timestamp(microsecond)"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at" parquet:"updated_at,timestamp(microsecond)"`
+	// SourceUpdatedAt is the updated_at an import read from its file;
+	// updated_at itself is always the time the row was written
+	SourceUpdatedAt *time.Time `json:"-" db:"source_updated_at" parquet:"-"`
 }
 
 // ValidStatuses defines allowed article statuses
@@ -35,4 +38,6 @@ type ArticleNDJSON struct {
 	Tags        []string `json:"tags"`
 	Status      string   `json:"status"`
 	PublishedAt string   `json:"published_at,omitempty"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
 }
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ChangeCursor is a position in (updated_at, id) order. Incremental exports
+// return the rows strictly after one cursor and up to another.
+type ChangeCursor struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	ID        string    `json:"id"`
+}
+
+// Less reports whether c sorts before other
+func (c ChangeCursor) Less(other ChangeCursor) bool {
+	if !c.UpdatedAt.Equal(other.UpdatedAt) {
+		return c.UpdatedAt.Before(other.UpdatedAt)
+	}
+	return c.ID < other.ID
+}
+
+// PageCursor is a position in (created_at, id) order, the order of full
+// exports and of the resource page API
+type PageCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+// ExportFilter narrows an export or page: rows after a (created_at, id)
+// position, optionally only those updated since a time, up to a limit
+type ExportFilter struct {
+	After        *PageCursor
+	UpdatedSince time.Time
+	Limit        int // 0 = no limit
+}
+
+// Matches reports whether a row passes the filter's conditions (not the limit)
+func (f ExportFilter) Matches(createdAt, updatedAt time.Time, id string) bool {
+	if f.After != nil {
+		if createdAt.Before(f.After.CreatedAt) || (createdAt.Equal(f.After.CreatedAt) && id <= f.After.ID) {
+			return false
+		}
+	}
+	return f.UpdatedSince.IsZero() || !updatedAt.Before(f.UpdatedSince)
+}
+
+// ResourcePage is one page of the resource page API. Data holds the rows
+// exactly as a format=json export writes them.
+type ResourcePage struct {
+	Resource   string          `json:"resource"`
+	Data       json.RawMessage `json:"data"`
+	Count      int             `json:"count"`
+	Limit      int             `json:"limit"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RemovedArtifact is one import source file the janitor deleted
+type RemovedArtifact struct {
+	JobID    string    `json:"job_id"`
+	Status   JobStatus `json:"status"`
+	Location string    `json:"location"`
+	Size     int64     `json:"size"`
+}
+
+// CleanupReport summarises one janitor run
+type CleanupReport struct {
+	UploadsDeleted int               `json:"uploads_deleted"`
+	JobsDeleted    int               `json:"jobs_deleted"`
+	BytesFreed     int64             `json:"bytes_freed"`
+	Failures       int               `json:"failures"`
+	Removed        []RemovedArtifact `json:"removed,omitempty"` // first 100 files removed
+	StartedAt      time.Time         `json:"started_at"`
+	DurationMs     int64             `json:"duration_ms"`
+}
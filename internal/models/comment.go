@@ -6,12 +6,15 @@ import (
 
 // Comment represents a comment on an article
 type Comment struct {
-	ID        string    `json:"id" db:"id"`
-	ArticleID string    `json:"article_id" db:"article_id"`
-	UserID    string    `json:"user_id" db:"user_id"`
-	Body      string    `json:"body" db:"body"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        string    `json:"id" db:"id" parquet:"id"`
+	ArticleID string    `json:"article_id" db:"article_id" parquet:"article_id"`
+	UserID    string    `json:"user_id" db:"user_id" parquet:"user_id"`
+	Body      string    `json:"body" db:"body" parquet:"body"`
+	CreatedAt time.Time `json:"created_at" db:"created_at" parquet:"created_at,timestamp(microsecond)"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at" parquet:"updated_at,timestamp(microsecond)"`
+	// SourceUpdatedAt is the updated_at an import read from its file;
+	// updated_at itself is always the time the row was written
+	SourceUpdatedAt *time.Time `json:"-" db:"source_updated_at" parquet:"-"`
 }
 
 // CommentNDJSON represents a comment record from NDJSON import
@@ -21,6 +24,7 @@ type CommentNDJSON struct {
 	UserID    string `json:"user_id"`
 	Body      string `json:"body"`
 	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at,omitempty"`
 }
 
 // MaxCommentWords is the maximum allowed words in a comment body
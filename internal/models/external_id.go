@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// DefaultSource is the source system of non-UUID IDs in imports that do
+// not name one
+const DefaultSource = "default"
+
+// ExternalID maps an identifier from a foreign system onto the UUID a row
+// was stored under. Mappings are per resource and source system.
+type ExternalID struct {
+	Resource   string    `json:"resource"`
+	Source     string    `json:"source"`
+	ExternalID string    `json:"external_id"`
+	InternalID string    `json:"internal_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ExternalIDFilter selects mappings of one resource: by source (optionally
+// one external ID, or a page after an external ID), or every mapping of
+// one stored row
+type ExternalIDFilter struct {
+	Resource   string
+	Source     string
+	ExternalID string
+	InternalID string
+	After      string // external ID the page starts after
+	Limit      int    // 0 = no limit
+}
+
+// ExternalIDPage is one page of the external ID mapping API
+type ExternalIDPage struct {
+	Resource   string       `json:"resource"`
+	Data       []ExternalID `json:"data"`
+	Count      int          `json:"count"`
+	Limit      int          `json:"limit"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
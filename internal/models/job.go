@@ -15,6 +15,10 @@ const (
 	JobStatusCancelled  JobStatus = "cancelled"
 )
 
+// ResourceBundle is the resource of a parent job importing several
+// resources from one archive; each resource runs as a child job
+const ResourceBundle = "bundle"
+
 // JobType represents the type of job
 type JobType string
 
@@ -25,31 +29,77 @@ const (
 
 // Job represents an import or export job
 type Job struct {
-	ID              string     `json:"job_id" db:"id"`
-	Type            JobType    `json:"type" db:"type"`
-	Resource        string     `json:"resource" db:"resource"`
-	Status          JobStatus  `json:"status" db:"status"`
-	IdempotencyKey  string     `json:"idempotency_key,omitempty" db:"idempotency_key"`
-	TotalRecords    int        `json:"total_records" db:"total_records"`
-	ProcessedCount  int        `json:"processed" db:"processed_count"`
-	SuccessfulCount int        `json:"successful" db:"successful_count"`
-	FailedCount     int        `json:"failed" db:"failed_count"`
-	DurationMs      int64      `json:"duration_ms,omitempty" db:"duration_ms"`
-	RowsPerSec      float64    `json:"rows_per_sec,omitempty" db:"rows_per_sec"`
-	FilePath        string     `json:"-" db:"file_path"`
-	DownloadURL     string     `json:"download_url,omitempty" db:"download_url"`
-	ErrorReportPath string     `json:"-" db:"error_report_path"`
-	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
-	StartedAt       *time.Time `json:"started_at,omitempty" db:"started_at"`
-	CompletedAt     *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	ID              string         `json:"job_id" db:"id"`
+	Type            JobType        `json:"type" db:"type"`
+	Resource        string         `json:"resource" db:"resource"`
+	Status          JobStatus      `json:"status" db:"status"`
+	IdempotencyKey  string         `json:"idempotency_key,omitempty" db:"idempotency_key"`
+	ParentJobID     string         `json:"parent_job_id,omitempty" db:"parent_job_id"`
+	TotalRecords    int            `json:"total_records" db:"total_records"`
+	ProcessedCount  int            `json:"processed" db:"processed_count"`
+	SuccessfulCount int            `json:"successful" db:"successful_count"`
+	FailedCount     int            `json:"failed" db:"failed_count"`
+	WarningCount    int            `json:"warnings" db:"warning_count"` // warnings reported, on imported and rejected rows alike
+	DurationMs      int64          `json:"duration_ms,omitempty" db:"duration_ms"`
+	RowsPerSec      float64        `json:"rows_per_sec,omitempty" db:"rows_per_sec"`
+	FilePath        string         `json:"-" db:"file_path"`
+	DownloadURL     string         `json:"download_url,omitempty" db:"download_url"`
+	ErrorReportPath string         `json:"-" db:"error_report_path"`
+	SourceHeader    []string       `json:"-" db:"source_header"` // header of a tabular source file as written
+	Options         *ImportOptions `json:"options,omitempty" db:"options"`
+	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
+	StartedAt       *time.Time     `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt     *time.Time     `json:"completed_at,omitempty" db:"completed_at"`
 }
 
-// ValidationError represents a single validation error
+// ImportOptions holds per-job settings supplied with the upload
+type ImportOptions struct {
+	Sheet  string `json:"sheet,omitempty"`  // XLSX worksheet name (first sheet if empty)
+	Format string `json:"format,omitempty"` // Decoder for files whose extension does not name one; encoder for export jobs
+	Source string `json:"source,omitempty"` // System the file's non-UUID IDs come from; they are mapped onto minted UUIDs
+
+	// Transforms normalize each record, in order, before it is validated
+	Transforms []Transform `json:"transforms,omitempty"`
+
+	// DateLayouts are accepted for timestamps besides RFC 3339: datetime,
+	// date, unix, unix_ms or Go reference layouts
+	DateLayouts []string `json:"date_layouts,omitempty"`
+	// Timezone (IANA name) of timestamps without an offset, UTC if empty
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// Severities of a reported issue. Only errors reject a record; warnings
+// and info are reported and the record is imported.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// ValidationError represents a single issue found in a record
 type ValidationError struct {
-	Line    int         `json:"line"`
-	Field   string      `json:"field"`
-	Message string      `json:"message"`
-	Value   interface{} `json:"value,omitempty"`
+	ID       int64       `json:"-"` // orders the issues of one line; set when read back
+	Line     int         `json:"line"`
+	Field    string      `json:"field"`
+	Message  string      `json:"message"`
+	Value    interface{} `json:"value,omitempty"`
+	Severity string      `json:"severity,omitempty"` // error when empty
+	Code     string      `json:"code,omitempty"`     // stable, machine-readable kind of issue
+}
+
+// Outcomes of a record in a streaming import
+const (
+	LineImported = "imported"
+	LineFailed   = "failed"
+)
+
+// LineResult is the outcome of one input record in a streaming import
+type LineResult struct {
+	Line   int               `json:"line"`
+	Status string            `json:"status"`           // imported or failed
+	Errors []ValidationError `json:"errors,omitempty"` // with the warnings of a failed record
+	// Warnings holds the warnings and info of an imported record
+	Warnings []ValidationError `json:"warnings,omitempty"`
 }
 
 // JobResponse is the API response for job status
@@ -58,19 +108,26 @@ type JobResponse struct {
 	Errors      []ValidationError `json:"errors,omitempty"`
 	ErrorCount  int               `json:"error_count,omitempty"`
 	ErrorReport string            `json:"error_report_url,omitempty"`
+	RejectsURL  string            `json:"rejects_url,omitempty"` // rejected records as a re-uploadable file
+	Children    []*JobResponse    `json:"children,omitempty"`    // Child jobs of a bundle, in run order
+	Retries     []*JobResponse    `json:"retries,omitempty"`     // Retries of an import, oldest first
 }
 
 // ImportRequest represents an import job request
 type ImportRequest struct {
-	Resource       string `json:"resource" form:"resource"` // users, articles, comments
-	FileURL        string `json:"file_url,omitempty"`       // Remote file URL
-	IdempotencyKey string `json:"-"`                        // From header
+	Resource       string         `json:"resource" form:"resource"` // users, articles, comments, bundle
+	FileURL        string         `json:"file_url,omitempty"`       // Remote file URL
+	IdempotencyKey string         `json:"-"`                        // From header
+	Options        *ImportOptions `json:"options,omitempty"`        // Format-specific options
 }
 
 // ExportRequest represents an export job request
 type ExportRequest struct {
 	Resource string            `json:"resource" form:"resource"` // users, articles, comments
-	Format   string            `json:"format" form:"format"`     // json, ndjson, csv
+	Format   string            `json:"format" form:"format"`     // json, ndjson, csv, parquet, arrow, xlsx
 	Filters  map[string]string `json:"filters,omitempty"`        // Optional filters
 	Fields   []string          `json:"fields,omitempty"`         // Optional field selection
+
+	Destination    string `json:"destination,omitempty" form:"destination"` // s3://bucket/key (or prefix/) to export to as a job
+	IdempotencyKey string `json:"-"`
 }
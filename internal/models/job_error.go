@@ -0,0 +1,81 @@
+package models
+
+// ErrorCursor is a position in (line_number, id) order, the order of a
+// job's error report
+type ErrorCursor struct {
+	Line int   `json:"line"`
+	ID   int64 `json:"id"`
+}
+
+// ErrorFilter selects the issues of one job: by field, message, severity
+// and code (each matched exactly), within a line range and after a
+// position, up to a limit
+type ErrorFilter struct {
+	JobID    string
+	Field    string
+	Message  string
+	Severity string
+	Code     string
+	FromLine int // 0 = from the first line
+	ToLine   int // 0 = to the last line
+	After    *ErrorCursor
+	Limit    int // 0 = no limit
+}
+
+// Matches reports whether an issue passes the filter's conditions (not the
+// job or the limit). Issues stored without a severity are errors.
+func (f ErrorFilter) Matches(e *ValidationError) bool {
+	severity := e.Severity
+	if severity == "" {
+		severity = SeverityError
+	}
+	switch {
+	case f.Field != "" && e.Field != f.Field,
+		f.Message != "" && e.Message != f.Message,
+		f.Severity != "" && severity != f.Severity,
+		f.Code != "" && e.Code != f.Code,
+		f.FromLine > 0 && e.Line < f.FromLine,
+		f.ToLine > 0 && e.Line > f.ToLine:
+		return false
+	}
+	if f.After != nil {
+		return e.Line > f.After.Line || (e.Line == f.After.Line && e.ID > f.After.ID)
+	}
+	return true
+}
+
+// ErrorPage is one page of a job's error report. The counts cover every
+// issue the filter selects, not only the page.
+type ErrorPage struct {
+	JobID        string            `json:"job_id"`
+	ErrorCount   int               `json:"error_count"`
+	WarningCount int               `json:"warning_count"`
+	Errors       []ValidationError `json:"errors"`
+	Count        int               `json:"count"`
+	Limit        int               `json:"limit"`
+	NextCursor   string            `json:"next_cursor,omitempty"`
+}
+
+// ErrorGroup counts the issues of a job sharing a field, message, severity
+// and code, with the first and last line they were found on
+type ErrorGroup struct {
+	Field     string `json:"field"`
+	Message   string `json:"message"`
+	Severity  string `json:"severity"`
+	Code      string `json:"code,omitempty"`
+	Count     int    `json:"count"`
+	FirstLine int    `json:"first_line"`
+	LastLine  int    `json:"last_line"`
+}
+
+// ErrorSummary aggregates a job's issues: counts by severity and the most
+// frequent groups. Truncated is set when more groups exist than were
+// returned.
+type ErrorSummary struct {
+	JobID        string       `json:"job_id"`
+	ErrorCount   int          `json:"error_count"`
+	WarningCount int          `json:"warning_count"`
+	InfoCount    int          `json:"info_count"`
+	Groups       []ErrorGroup `json:"groups"`
+	Truncated    bool         `json:"truncated,omitempty"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// RejectedRecord is a record an import rejected, as read from the file
+// (before transforms), with why it was rejected
+type RejectedRecord struct {
+	Line   int            `json:"line"`
+	Record map[string]any `json:"record"`
+	Errors string         `json:"errors"` // "field: message" of each error, separated by "; "
+	// Retryable is set when every error came from the state of the
+	// database, so a retry may import the record unchanged
+	Retryable bool `json:"retryable"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// Transform names a built-in row transform applied before validation, and
+// the field it applies to
+type Transform struct {
+	Name  string `json:"name"`
+	Field string `json:"field,omitempty"` // field to change; trim without one trims every text field
+	From  string `json:"from,omitempty"`  // source field of slugify (default title)
+	Value string `json:"value,omitempty"` // value set by default
+}
+
+// TransformRecord is one change a transform made to one record, kept so
+// imports can be audited
+type TransformRecord struct {
+	Line      int    `json:"line"`
+	Field     string `json:"field"`
+	Transform string `json:"transform"`
+	Before    string `json:"before"`
+	After     string `json:"after"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// Upload is a resumable upload session. Chunks are appended to a partial
+// file in the upload directory until Offset reaches Size, then the session
+// is completed into an import job.
+type Upload struct {
+	ID             string         `json:"upload_id" db:"id"`
+	Resource       string         `json:"resource" db:"resource"`
+	Format         string         `json:"format" db:"format"`
+	Filename       string         `json:"filename,omitempty" db:"filename"`
+	Size           int64          `json:"size" db:"size"`
+	Offset         int64          `json:"offset" db:"offset"`
+	IdempotencyKey string         `json:"idempotency_key,omitempty" db:"idempotency_key"`
+	JobID          string         `json:"job_id,omitempty" db:"job_id"` // Set once the upload is completed
+	FilePath       string         `json:"-" db:"file_path"`
+	Options        *ImportOptions `json:"options,omitempty" db:"options"`
+	ExpiresAt      time.Time      `json:"expires_at" db:"expires_at"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// UploadRequest opens an upload session
+type UploadRequest struct {
+	Resource       string         `json:"resource"`           // users, articles, comments, bundle
+	Format         string         `json:"format,omitempty"`   // Format name; defaults from the filename extension
+	Filename       string         `json:"filename,omitempty"` // Original file name
+	Size           int64          `json:"size"`               // Total size in bytes
+	IdempotencyKey string         `json:"-"`                  // From header
+	Options        *ImportOptions `json:"options,omitempty"`  // Format-specific options
+}
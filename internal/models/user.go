@@ -6,13 +6,16 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID        string    `json:"id" db:"id"`
-	Email     string    `json:"email" db:"email"`
-	Name      string    `json:"name" db:"name"`
-	Role      string    `json:"role" db:"role"`
-	Active    bool      `json:"active" db:"active"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        string    `json:"id" db:"id" parquet:"id"`
+	Email     string    `json:"email" db:"email" parquet:"email"`
+	Name      string    `json:"name" db:"name" parquet:"name"`
+	Role      string    `json:"role" db:"role" parquet:"role"`
+	Active    bool      `json:"active" db:"active" parquet:"active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at" parquet:"created_at,timestamp(microsecond)"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at" parquet:"updated_at,timestamp(microsecond)"`
+	// SourceUpdatedAt is the updated_at an import read from its file;
+	// updated_at itself is always the time the row was written
+	SourceUpdatedAt *time.Time `json:"-" db:"source_updated_at" parquet:"-"`
 }
 
 // ValidRoles defines allowed user roles
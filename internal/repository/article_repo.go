@@ -26,13 +26,13 @@ func (r *articleRepo) Create(ctx context.Context, article *models.Article) error
 	tagsJSON, _ := json.Marshal(article.Tags)
 
 	query := `
-		INSERT INTO articles (id, slug, title, body, author_id, tags, status, published_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO articles (id, slug, title, body, author_id, tags, status, published_at, created_at, updated_at, source_updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		article.ID, article.Slug, article.Title, article.Body, article.AuthorID,
 		tagsJSON, article.Status, article.PublishedAt,
-		article.CreatedAt, time.Now(),
+		article.CreatedAt, time.Now(), article.SourceUpdatedAt,
 	)
 	return err
 }
@@ -50,7 +50,7 @@ func (r *articleRepo) BatchInsert(ctx context.Context, articles []*models.Articl
 	defer tx.Rollback()
 
 	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("articles",
-		"id", "slug", "title", "body", "author_id", "tags", "status", "published_at", "created_at", "updated_at",
+		"id", "slug", "title", "body", "author_id", "tags", "status", "published_at", "created_at", "updated_at", "source_updated_at",
 	))
 	if err != nil {
 		return 0, err
@@ -69,7 +69,7 @@ func (r *articleRepo) BatchInsert(ctx context.Context, articles []*models.Articl
 		_, err := stmt.ExecContext(ctx,
 			article.ID, article.Slug, article.Title, article.Body, article.AuthorID,
 			string(tagsJSON), article.Status, article.PublishedAt,
-			article.CreatedAt, now,
+			article.CreatedAt, now, article.SourceUpdatedAt,
 		)
 		if err != nil {
 			continue
@@ -132,6 +132,25 @@ func (r *articleRepo) SlugExists(ctx context.Context, slug string) (bool, error)
 	return exists, err
 }
 
+// ExistingSlugs returns those of slugs that belong to stored articles, in
+// one query on the unique slug index
+func (r *articleRepo) ExistingSlugs(ctx context.Context, slugs []string) ([]string, error) {
+	if len(slugs) == 0 {
+		return nil, nil
+	}
+	return queryStrings(ctx, r.db, "SELECT slug FROM articles WHERE slug = ANY($1)", pq.Array(slugs))
+}
+
+// ExistingIDs returns those of ids that belong to stored articles, for FK
+// validation one batch at a time. Ids that are not UUIDs are never stored.
+func (r *articleRepo) ExistingIDs(ctx context.Context, ids []string) ([]string, error) {
+	ids = uuidsOnly(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return queryStrings(ctx, r.db, "SELECT id::text FROM articles WHERE id = ANY($1::uuid[])", pq.Array(ids))
+}
+
 // GetAllIDs retrieves all article IDs (for FK validation cache)
 func (r *articleRepo) GetAllIDs(ctx context.Context) ([]string, error) {
 	rows, err := r.db.QueryContext(ctx, "SELECT id FROM articles")
@@ -160,11 +179,32 @@ func (r *articleRepo) Count(ctx context.Context) (int, error) {
 
 // StreamAll streams all articles for export
 func (r *articleRepo) StreamAll(ctx context.Context, callback func(*models.Article) error) error {
+	return streamArticles(ctx, r.db, exportOrder, nil, callback)
+}
+
+// StreamFiltered streams the articles matching filter in (created_at, id) order
+func (r *articleRepo) StreamFiltered(ctx context.Context, filter models.ExportFilter, callback func(*models.Article) error) error {
+	clause, args := filterClause(filter)
+	return streamArticles(ctx, r.db, clause, args, callback)
+}
+
+// LatestChange returns the position of the most recently updated article, or nil if there are none
+func (r *articleRepo) LatestChange(ctx context.Context) (*models.ChangeCursor, error) {
+	return latestChange(ctx, r.db, "articles")
+}
+
+// StreamChanges streams articles updated after `after`, up to and including `until`, in (updated_at, id) order
+func (r *articleRepo) StreamChanges(ctx context.Context, after, until models.ChangeCursor, callback func(*models.Article) error) error {
+	return streamArticles(ctx, r.db, changesClause, changesArgs(after, until), callback)
+}
+
+// streamArticles runs the export query, narrowed and ordered by clause, on q:
+// either the pool or a snapshot transaction
+func streamArticles(ctx context.Context, q queryer, clause string, args []any, callback func(*models.Article) error) error {
 	query := `
 		SELECT id, slug, title, body, author_id, tags, status, published_at, created_at, updated_at 
-		FROM articles ORDER BY created_at
-	`
-	rows, err := r.db.QueryContext(ctx, query)
+		FROM articles ` + clause
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bulk-import-export-api/internal/models"
+)
+
+// exportOrder is the order of full exports
+const exportOrder = `ORDER BY created_at, id`
+
+// nilUUID stands in for an empty cursor id; it sorts before every other UUID
+const nilUUID = "00000000-0000-0000-0000-000000000000"
+
+// cursorID returns a cursor's id as compared with the uuid column
+func cursorID(id string) string {
+	if id == "" {
+		return nilUUID
+	}
+	return id
+}
+
+// changesClause selects rows in (after, until] by (updated_at, id). Ids are
+// compared as uuids so the (updated_at, id) index serves the range.
+const changesClause = `
+	WHERE (updated_at, id) > ($1, $2::uuid) AND (updated_at, id) <= ($3, $4::uuid)
+	ORDER BY updated_at, id`
+
+func changesArgs(after, until models.ChangeCursor) []any {
+	return []any{after.UpdatedAt, cursorID(after.ID), until.UpdatedAt, cursorID(until.ID)}
+}
+
+// latestChange returns the greatest (updated_at, id) in table, or nil if
+// the table is empty
+func latestChange(ctx context.Context, q queryer, table string) (*models.ChangeCursor, error) {
+	rows, err := q.QueryContext(ctx, `SELECT updated_at, id::text FROM `+table+` ORDER BY updated_at DESC, id DESC LIMIT 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	var cursor models.ChangeCursor
+	if err := rows.Scan(&cursor.UpdatedAt, &cursor.ID); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// filterClause builds the WHERE, ORDER BY and LIMIT of a filtered export in
+// (created_at, id) order, comparing ids as uuids like the ORDER BY so the
+// (created_at, id) index serves the page
+func filterClause(f models.ExportFilter) (string, []any) {
+	var conds []string
+	var args []any
+	if f.After != nil {
+		args = append(args, f.After.CreatedAt, cursorID(f.After.ID))
+		conds = append(conds, fmt.Sprintf("(created_at, id) > ($%d, $%d::uuid)", len(args)-1, len(args)))
+	}
+	if !f.UpdatedSince.IsZero() {
+		args = append(args, f.UpdatedSince)
+		conds = append(conds, fmt.Sprintf("updated_at >= $%d", len(args)))
+	}
+
+	clause := exportOrder
+	if len(conds) > 0 {
+		clause = "WHERE " + strings.Join(conds, " AND ") + " " + clause
+	}
+	if f.Limit > 0 {
+		clause += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+	return clause, args
+}
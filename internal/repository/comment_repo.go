@@ -23,12 +23,12 @@ func NewCommentRepo(db *database.DB) CommentRepository {
 // Create inserts a new comment
 func (r *commentRepo) Create(ctx context.Context, comment *models.Comment) error {
 	query := `
-		INSERT INTO comments (id, article_id, user_id, body, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO comments (id, article_id, user_id, body, created_at, updated_at, source_updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		comment.ID, comment.ArticleID, comment.UserID, comment.Body,
-		comment.CreatedAt, time.Now(),
+		comment.CreatedAt, time.Now(), comment.SourceUpdatedAt,
 	)
 	return err
 }
@@ -46,7 +46,7 @@ func (r *commentRepo) BatchInsert(ctx context.Context, comments []*models.Commen
 	defer tx.Rollback()
 
 	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("comments",
-		"id", "article_id", "user_id", "body", "created_at", "updated_at",
+		"id", "article_id", "user_id", "body", "created_at", "updated_at", "source_updated_at",
 	))
 	if err != nil {
 		return 0, err
@@ -59,7 +59,7 @@ func (r *commentRepo) BatchInsert(ctx context.Context, comments []*models.Commen
 	for _, comment := range comments {
 		_, err := stmt.ExecContext(ctx,
 			comment.ID, comment.ArticleID, comment.UserID, comment.Body,
-			comment.CreatedAt, now,
+			comment.CreatedAt, now, comment.SourceUpdatedAt,
 		)
 		if err != nil {
 			continue
@@ -113,8 +113,30 @@ func (r *commentRepo) Count(ctx context.Context) (int, error) {
 
 // StreamAll streams all comments for export
 func (r *commentRepo) StreamAll(ctx context.Context, callback func(*models.Comment) error) error {
-	query := `SELECT id, article_id, user_id, body, created_at, updated_at FROM comments ORDER BY created_at`
-	rows, err := r.db.QueryContext(ctx, query)
+	return streamComments(ctx, r.db, exportOrder, nil, callback)
+}
+
+// StreamFiltered streams the comments matching filter in (created_at, id) order
+func (r *commentRepo) StreamFiltered(ctx context.Context, filter models.ExportFilter, callback func(*models.Comment) error) error {
+	clause, args := filterClause(filter)
+	return streamComments(ctx, r.db, clause, args, callback)
+}
+
+// LatestChange returns the position of the most recently updated comment, or nil if there are none
+func (r *commentRepo) LatestChange(ctx context.Context) (*models.ChangeCursor, error) {
+	return latestChange(ctx, r.db, "comments")
+}
+
+// StreamChanges streams comments updated after `after`, up to and including `until`, in (updated_at, id) order
+func (r *commentRepo) StreamChanges(ctx context.Context, after, until models.ChangeCursor, callback func(*models.Comment) error) error {
+	return streamComments(ctx, r.db, changesClause, changesArgs(after, until), callback)
+}
+
+// streamComments runs the export query, narrowed and ordered by clause, on q:
+// either the pool or a snapshot transaction
+func streamComments(ctx context.Context, q queryer, clause string, args []any, callback func(*models.Comment) error) error {
+	query := `SELECT id, article_id, user_id, body, created_at, updated_at FROM comments ` + clause
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bulk-import-export-api/internal/database"
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/lib/pq"
+)
+
+// externalIDRepo is the concrete implementation of ExternalIDRepository
+type externalIDRepo struct {
+	db *database.DB
+}
+
+// NewExternalIDRepo creates a new external ID mapping repository
+func NewExternalIDRepo(db *database.DB) ExternalIDRepository {
+	return &externalIDRepo{db: db}
+}
+
+// Assign returns the UUID mapped to each external ID, minting mappings for
+// those without one. Concurrent imports of the same ID agree on one UUID:
+// the first insert wins and the others read it back.
+func (r *externalIDRepo) Assign(ctx context.Context, resource, source string, externalIDs []string) (map[string]string, error) {
+	if len(externalIDs) == 0 {
+		return map[string]string{}, nil
+	}
+
+	query := `
+		INSERT INTO external_ids (resource, source, external_id, internal_id)
+		SELECT $1, $2, e, gen_random_uuid() FROM unnest($3::text[]) AS e
+		ON CONFLICT (resource, source, external_id) DO NOTHING
+	`
+	if _, err := r.db.ExecContext(ctx, query, resource, source, pq.Array(externalIDs)); err != nil {
+		return nil, err
+	}
+	return r.Resolve(ctx, resource, source, externalIDs)
+}
+
+// Resolve returns the UUID mapped to each external ID that has a mapping
+func (r *externalIDRepo) Resolve(ctx context.Context, resource, source string, externalIDs []string) (map[string]string, error) {
+	mapped := make(map[string]string, len(externalIDs))
+	if len(externalIDs) == 0 {
+		return mapped, nil
+	}
+
+	query := `
+		SELECT external_id, internal_id FROM external_ids
+		WHERE resource = $1 AND source = $2 AND external_id = ANY($3)
+	`
+	rows, err := r.db.QueryContext(ctx, query, resource, source, pq.Array(externalIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var externalID, internalID string
+		if err := rows.Scan(&externalID, &internalID); err != nil {
+			return nil, err
+		}
+		mapped[externalID] = internalID
+	}
+	return mapped, rows.Err()
+}
+
+// List returns the mappings selected by filter in (source, external_id) order
+func (r *externalIDRepo) List(ctx context.Context, filter models.ExternalIDFilter) ([]models.ExternalID, error) {
+	conditions := []string{"resource = $1"}
+	args := []interface{}{filter.Resource}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Source != "" {
+		where("source = $%d", filter.Source)
+	}
+	if filter.ExternalID != "" {
+		where("external_id = $%d", filter.ExternalID)
+	}
+	if filter.InternalID != "" {
+		where("internal_id = $%d", filter.InternalID)
+	}
+	if filter.After != "" {
+		where("external_id > $%d", filter.After)
+	}
+
+	query := `SELECT resource, source, external_id, internal_id, created_at FROM external_ids
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY source, external_id`
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mappings := []models.ExternalID{}
+	for rows.Next() {
+		var m models.ExternalID
+		if err := rows.Scan(&m.Resource, &m.Source, &m.ExternalID, &m.InternalID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, rows.Err()
+}
@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/bulk-import-export-api/internal/database"
@@ -23,14 +26,14 @@ func NewJobRepo(db *database.DB) JobRepository {
 // Create inserts a new job
 func (r *jobRepo) Create(ctx context.Context, job *models.Job) error {
 	query := `
-		INSERT INTO jobs (id, type, resource, status, idempotency_key, total_records, 
-			processed_count, successful_count, failed_count, file_path, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO jobs (id, type, resource, status, idempotency_key, parent_job_id, total_records, 
+			processed_count, successful_count, failed_count, warning_count, file_path, options, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 	_, err := r.db.ExecContext(ctx, query,
-		job.ID, job.Type, job.Resource, job.Status, nullString(job.IdempotencyKey),
-		job.TotalRecords, job.ProcessedCount, job.SuccessfulCount, job.FailedCount,
-		nullString(job.FilePath), job.CreatedAt,
+		job.ID, job.Type, job.Resource, job.Status, nullString(job.IdempotencyKey), nullString(job.ParentJobID),
+		job.TotalRecords, job.ProcessedCount, job.SuccessfulCount, job.FailedCount, job.WarningCount,
+		nullString(job.FilePath), marshalOptions(job.Options), job.CreatedAt,
 	)
 	return err
 }
@@ -40,48 +43,46 @@ func (r *jobRepo) Update(ctx context.Context, job *models.Job) error {
 	query := `
 		UPDATE jobs SET 
 			status = $1, total_records = $2, processed_count = $3, successful_count = $4, 
-			failed_count = $5, duration_ms = $6, rows_per_sec = $7, download_url = $8,
-			error_report_path = $9, started_at = $10, completed_at = $11
-		WHERE id = $12
+			failed_count = $5, warning_count = $6, duration_ms = $7, rows_per_sec = $8, download_url = $9,
+			error_report_path = $10, source_header = $11, started_at = $12, completed_at = $13
+		WHERE id = $14
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		job.Status, job.TotalRecords, job.ProcessedCount, job.SuccessfulCount,
-		job.FailedCount, job.DurationMs, job.RowsPerSec, nullString(job.DownloadURL),
-		nullString(job.ErrorReportPath), job.StartedAt, job.CompletedAt, job.ID,
+		job.FailedCount, job.WarningCount, job.DurationMs, job.RowsPerSec, nullString(job.DownloadURL),
+		nullString(job.ErrorReportPath), pq.Array(job.SourceHeader), job.StartedAt, job.CompletedAt, job.ID,
 	)
 	return err
 }
 
-// GetByID retrieves a job by ID
-func (r *jobRepo) GetByID(ctx context.Context, id string) (*models.Job, error) {
-	query := `
-		SELECT id, type, resource, status, idempotency_key, total_records, processed_count, 
-			successful_count, failed_count, duration_ms, rows_per_sec, file_path, download_url,
-			error_report_path, created_at, started_at, completed_at 
-		FROM jobs WHERE id = $1
-	`
+// jobColumns is the column list read by scanJob
+const jobColumns = `id, type, resource, status, idempotency_key, parent_job_id, total_records, processed_count, 
+	successful_count, failed_count, warning_count, duration_ms, rows_per_sec, file_path, download_url,
+	error_report_path, source_header, options, created_at, started_at, completed_at`
 
+// scanJob scans a row selected with jobColumns
+func scanJob(row interface{ Scan(dest ...any) error }) (*models.Job, error) {
 	var job models.Job
-	var idempotencyKey, filePath, downloadURL, errorReportPath sql.NullString
+	var idempotencyKey, parentJobID, filePath, downloadURL, errorReportPath sql.NullString
+	var options []byte
 	var startedAt, completedAt sql.NullTime
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&job.ID, &job.Type, &job.Resource, &job.Status, &idempotencyKey,
-		&job.TotalRecords, &job.ProcessedCount, &job.SuccessfulCount, &job.FailedCount,
+	err := row.Scan(
+		&job.ID, &job.Type, &job.Resource, &job.Status, &idempotencyKey, &parentJobID,
+		&job.TotalRecords, &job.ProcessedCount, &job.SuccessfulCount, &job.FailedCount, &job.WarningCount,
 		&job.DurationMs, &job.RowsPerSec, &filePath, &downloadURL, &errorReportPath,
-		&job.CreatedAt, &startedAt, &completedAt,
+		pq.Array(&job.SourceHeader), &options, &job.CreatedAt, &startedAt, &completedAt,
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
 	if err != nil {
 		return nil, err
 	}
 
 	job.IdempotencyKey = idempotencyKey.String
+	job.ParentJobID = parentJobID.String
 	job.FilePath = filePath.String
 	job.DownloadURL = downloadURL.String
 	job.ErrorReportPath = errorReportPath.String
+	job.Options = unmarshalOptions(options)
 	if startedAt.Valid {
 		job.StartedAt = &startedAt.Time
 	}
@@ -92,53 +93,59 @@ func (r *jobRepo) GetByID(ctx context.Context, id string) (*models.Job, error) {
 	return &job, nil
 }
 
+// GetByID retrieves a job by ID
+func (r *jobRepo) GetByID(ctx context.Context, id string) (*models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+
+	job, err := scanJob(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
 // GetByIdempotencyKey retrieves a job by idempotency key
 func (r *jobRepo) GetByIdempotencyKey(ctx context.Context, key string) (*models.Job, error) {
-	query := `
-		SELECT id, type, resource, status, idempotency_key, total_records, processed_count, 
-			successful_count, failed_count, duration_ms, rows_per_sec, file_path, download_url,
-			error_report_path, created_at, started_at, completed_at 
-		FROM jobs WHERE idempotency_key = $1
-	`
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE idempotency_key = $1`
 
-	var job models.Job
-	var idempotencyKey, filePath, downloadURL, errorReportPath sql.NullString
-	var startedAt, completedAt sql.NullTime
-
-	err := r.db.QueryRowContext(ctx, query, key).Scan(
-		&job.ID, &job.Type, &job.Resource, &job.Status, &idempotencyKey,
-		&job.TotalRecords, &job.ProcessedCount, &job.SuccessfulCount, &job.FailedCount,
-		&job.DurationMs, &job.RowsPerSec, &filePath, &downloadURL, &errorReportPath,
-		&job.CreatedAt, &startedAt, &completedAt,
-	)
+	job, err := scanJob(r.db.QueryRowContext(ctx, query, key))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	return job, err
+}
+
+// GetChildren retrieves the child jobs of a bundle job in creation order
+func (r *jobRepo) GetChildren(ctx context.Context, parentID string) ([]*models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE parent_job_id = $1 ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, parentID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	job.IdempotencyKey = idempotencyKey.String
-	job.FilePath = filePath.String
-	job.DownloadURL = downloadURL.String
-	job.ErrorReportPath = errorReportPath.String
-	if startedAt.Valid {
-		job.StartedAt = &startedAt.Time
-	}
-	if completedAt.Valid {
-		job.CompletedAt = &completedAt.Time
+	var jobs []*models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
 	}
 
-	return &job, nil
+	return jobs, rows.Err()
 }
 
-// GetPendingJobs retrieves all pending jobs
+// GetPendingJobs retrieves all pending jobs, top-level jobs and retries.
+// Child jobs of a bundle are run by their parent.
 func (r *jobRepo) GetPendingJobs(ctx context.Context) ([]*models.Job, error) {
 	query := `
-		SELECT id, type, resource, file_path, created_at 
-		FROM jobs WHERE status = 'pending' 
-		ORDER BY created_at
-		FOR UPDATE SKIP LOCKED
+		SELECT j.id, j.type, j.resource, j.parent_job_id, j.file_path, j.options, j.created_at 
+		FROM jobs j WHERE j.status = 'pending'
+			AND NOT EXISTS (SELECT 1 FROM jobs p WHERE p.id = j.parent_job_id AND p.resource = 'bundle')
+		ORDER BY j.created_at
+		FOR UPDATE OF j SKIP LOCKED
 	`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -149,12 +156,15 @@ func (r *jobRepo) GetPendingJobs(ctx context.Context) ([]*models.Job, error) {
 	var jobs []*models.Job
 	for rows.Next() {
 		var job models.Job
-		var filePath sql.NullString
-		err := rows.Scan(&job.ID, &job.Type, &job.Resource, &filePath, &job.CreatedAt)
+		var parentJobID, filePath sql.NullString
+		var options []byte
+		err := rows.Scan(&job.ID, &job.Type, &job.Resource, &parentJobID, &filePath, &options, &job.CreatedAt)
 		if err != nil {
 			continue
 		}
+		job.ParentJobID = parentJobID.String
 		job.FilePath = filePath.String
+		job.Options = unmarshalOptions(options)
 		job.Status = models.JobStatusPending
 		jobs = append(jobs, &job)
 	}
@@ -176,9 +186,23 @@ func (r *jobRepo) MarkJobAsProcessing(ctx context.Context, jobID string) (bool,
 	return rows > 0, nil
 }
 
+// OldestRunningImport returns when the earliest import still processing
+// started, or nil if none is
+func (r *jobRepo) OldestRunningImport(ctx context.Context) (*time.Time, error) {
+	query := `SELECT MIN(started_at) FROM jobs WHERE type = 'import' AND status = 'processing'`
+	var started sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query).Scan(&started); err != nil {
+		return nil, err
+	}
+	if !started.Valid {
+		return nil, nil
+	}
+	return &started.Time, nil
+}
+
 // AddError adds a validation error to the job
 func (r *jobRepo) AddError(ctx context.Context, jobID string, err *models.ValidationError) error {
-	query := `INSERT INTO job_errors (job_id, line_number, field, message, value) VALUES ($1, $2, $3, $4, $5)`
+	query := `INSERT INTO job_errors (job_id, line_number, field, message, value, severity, code) VALUES ($1, $2, $3, $4, $5, $6, $7)`
 	valueStr := ""
 	if err.Value != nil {
 		switch v := err.Value.(type) {
@@ -186,7 +210,7 @@ func (r *jobRepo) AddError(ctx context.Context, jobID string, err *models.Valida
 			valueStr = v
 		}
 	}
-	_, dbErr := r.db.ExecContext(ctx, query, jobID, err.Line, err.Field, err.Message, valueStr)
+	_, dbErr := r.db.ExecContext(ctx, query, jobID, err.Line, err.Field, err.Message, valueStr, severity(err), err.Code)
 	return dbErr
 }
 
@@ -205,7 +229,7 @@ func (r *jobRepo) AddErrors(ctx context.Context, jobID string, errors []models.V
 	defer tx.Rollback()
 
 	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("job_errors",
-		"job_id", "line_number", "field", "message", "value",
+		"job_id", "line_number", "field", "message", "value", "severity", "code",
 	))
 	if err != nil {
 		return err
@@ -220,7 +244,7 @@ func (r *jobRepo) AddErrors(ctx context.Context, jobID string, errors []models.V
 				valueStr = v
 			}
 		}
-		stmt.ExecContext(ctx, jobID, e.Line, e.Field, e.Message, valueStr)
+		stmt.ExecContext(ctx, jobID, e.Line, e.Field, e.Message, valueStr, severity(&e), e.Code)
 	}
 
 	// Flush the COPY buffer
@@ -233,7 +257,7 @@ func (r *jobRepo) AddErrors(ctx context.Context, jobID string, errors []models.V
 
 // GetErrors retrieves validation errors for a job
 func (r *jobRepo) GetErrors(ctx context.Context, jobID string, limit int) ([]models.ValidationError, error) {
-	query := `SELECT line_number, field, message, value FROM job_errors WHERE job_id = $1 ORDER BY line_number`
+	query := `SELECT line_number, field, message, value, severity, code FROM job_errors WHERE job_id = $1 ORDER BY line_number, id`
 	if limit > 0 {
 		query += " LIMIT $2"
 	}
@@ -254,7 +278,7 @@ func (r *jobRepo) GetErrors(ctx context.Context, jobID string, limit int) ([]mod
 	for rows.Next() {
 		var e models.ValidationError
 		var value sql.NullString
-		err := rows.Scan(&e.Line, &e.Field, &e.Message, &value)
+		err := rows.Scan(&e.Line, &e.Field, &e.Message, &value, &e.Severity, &e.Code)
 		if err != nil {
 			continue
 		}
@@ -267,6 +291,348 @@ func (r *jobRepo) GetErrors(ctx context.Context, jobID string, limit int) ([]mod
 	return errors, rows.Err()
 }
 
+// errorConditions returns the WHERE clause selecting a filter's issues and
+// its arguments. Every condition follows job_id, so the queries run on
+// idx_job_errors_job_line.
+func errorConditions(filter models.ErrorFilter) (string, []interface{}) {
+	conds := []string{"job_id = $1"}
+	args := []interface{}{filter.JobID}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Field != "" {
+		add("field = $%d", filter.Field)
+	}
+	if filter.Message != "" {
+		add("message = $%d", filter.Message)
+	}
+	if filter.Severity != "" {
+		add("severity = $%d", filter.Severity)
+	}
+	if filter.Code != "" {
+		add("code = $%d", filter.Code)
+	}
+	if filter.FromLine > 0 {
+		add("line_number >= $%d", filter.FromLine)
+	}
+	if filter.ToLine > 0 {
+		add("line_number <= $%d", filter.ToLine)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.Line, filter.After.ID)
+		conds = append(conds, fmt.Sprintf("(line_number, id) > ($%d, $%d)", len(args)-1, len(args)))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// StreamErrors calls callback for each issue a filter selects, in
+// (line_number, id) order, up to its limit
+func (r *jobRepo) StreamErrors(ctx context.Context, filter models.ErrorFilter, callback func(*models.ValidationError) error) error {
+	where, args := errorConditions(filter)
+	query := `SELECT id, line_number, field, message, value, severity, code FROM job_errors
+		WHERE ` + where + ` ORDER BY line_number, id`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.ValidationError
+		var value sql.NullString
+		if err := rows.Scan(&e.ID, &e.Line, &e.Field, &e.Message, &value, &e.Severity, &e.Code); err != nil {
+			return err
+		}
+		if value.Valid {
+			e.Value = value.String
+		}
+		if err := callback(&e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// CountErrors counts the issues a filter selects by severity, ignoring its
+// position and limit
+func (r *jobRepo) CountErrors(ctx context.Context, filter models.ErrorFilter) (map[string]int, error) {
+	filter.After = nil
+	where, args := errorConditions(filter)
+	rows, err := r.db.QueryContext(ctx, `SELECT severity, COUNT(*) FROM job_errors
+		WHERE `+where+` GROUP BY severity`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var severity string
+		var count int
+		if err := rows.Scan(&severity, &count); err != nil {
+			return nil, err
+		}
+		counts[severity] = count
+	}
+	return counts, rows.Err()
+}
+
+// SummarizeErrors groups the issues a filter selects by field, message,
+// severity and code, most frequent first, up to limit groups (0 = all)
+func (r *jobRepo) SummarizeErrors(ctx context.Context, filter models.ErrorFilter, limit int) ([]models.ErrorGroup, error) {
+	filter.After = nil
+	where, args := errorConditions(filter)
+	query := `SELECT field, message, severity, code, COUNT(*), MIN(line_number), MAX(line_number)
+		FROM job_errors WHERE ` + where + `
+		GROUP BY field, message, severity, code
+		ORDER BY COUNT(*) DESC, field, message, severity, code`
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []models.ErrorGroup
+	for rows.Next() {
+		var g models.ErrorGroup
+		if err := rows.Scan(&g.Field, &g.Message, &g.Severity, &g.Code, &g.Count, &g.FirstLine, &g.LastLine); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// severity returns the stored severity of an issue, error when unset
+func severity(e *models.ValidationError) string {
+	if e.Severity == "" {
+		return models.SeverityError
+	}
+	return e.Severity
+}
+
+// AddTransforms adds a job's transform audit in one COPY
+func (r *jobRepo) AddTransforms(ctx context.Context, jobID string, applied []models.TransformRecord) error {
+	if len(applied) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("job_transforms",
+		"job_id", "line_number", "field", "transform", "before_value", "after_value",
+	))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, t := range applied {
+		if _, err := stmt.ExecContext(ctx, jobID, t.Line, t.Field, t.Transform, t.Before, t.After); err != nil {
+			return err
+		}
+	}
+
+	// Flush the COPY buffer
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTransforms retrieves a job's transform audit in line order
+func (r *jobRepo) GetTransforms(ctx context.Context, jobID string, limit int) ([]models.TransformRecord, error) {
+	query := `SELECT line_number, field, transform, before_value, after_value FROM job_transforms
+		WHERE job_id = $1 ORDER BY line_number, id`
+	args := []interface{}{jobID}
+	if limit > 0 {
+		query += " LIMIT $2"
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := []models.TransformRecord{}
+	for rows.Next() {
+		var t models.TransformRecord
+		if err := rows.Scan(&t.Line, &t.Field, &t.Transform, &t.Before, &t.After); err != nil {
+			return nil, err
+		}
+		applied = append(applied, t)
+	}
+	return applied, rows.Err()
+}
+
+// AddRecovered moves rows a retry imported from failed to successful on
+// the retried job and, when that job is a bundle's child, on its bundle.
+// One statement, so concurrent retries do not lose each other's counts.
+func (r *jobRepo) AddRecovered(ctx context.Context, jobID string, recovered int) error {
+	query := `
+		UPDATE jobs SET
+			successful_count = successful_count + $2,
+			failed_count = GREATEST(failed_count - $2, 0)
+		WHERE id = $1
+			OR id = (SELECT c.parent_job_id FROM jobs c JOIN jobs p ON p.id = c.parent_job_id
+				WHERE c.id = $1 AND p.resource = 'bundle')
+	`
+	_, err := r.db.ExecContext(ctx, query, jobID, recovered)
+	return err
+}
+
+// AddRejects stores records an import rejected in one COPY
+func (r *jobRepo) AddRejects(ctx context.Context, jobID string, rejects []models.RejectedRecord) error {
+	if len(rejects) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("job_rejects", "job_id", "line_number", "record", "errors", "retryable"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rej := range rejects {
+		record, err := json.Marshal(rej.Record)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, jobID, rej.Line, string(record), rej.Errors, rej.Retryable); err != nil {
+			return err
+		}
+	}
+
+	// Flush the COPY buffer
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// StreamRejects calls callback for each record a job rejected, or only the
+// retryable ones, in line order
+func (r *jobRepo) StreamRejects(ctx context.Context, jobID string, retryableOnly bool, callback func(*models.RejectedRecord) error) error {
+	query := `SELECT line_number, record, errors, retryable FROM job_rejects WHERE job_id = $1`
+	if retryableOnly {
+		query += ` AND retryable`
+	}
+	query += ` ORDER BY line_number, id`
+
+	rows, err := r.db.QueryContext(ctx, query, jobID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rej models.RejectedRecord
+		var record []byte
+		if err := rows.Scan(&rej.Line, &record, &rej.Errors, &rej.Retryable); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(record, &rej.Record); err != nil {
+			return err
+		}
+		if err := callback(&rej); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetFinishedWithFiles retrieves finished jobs of a type that still hold a
+// file and finished before the cutoff, in ID order after afterID. Cancelled
+// children never complete, so their creation time stands in.
+func (r *jobRepo) GetFinishedWithFiles(ctx context.Context, jobType models.JobType, statuses []models.JobStatus, before time.Time, afterID string, limit int) ([]*models.Job, error) {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+
+	query := `SELECT ` + jobColumns + ` FROM jobs
+		WHERE type = $1 AND status = ANY($2) AND file_path IS NOT NULL
+			AND COALESCE(completed_at, created_at) < $3 AND id > $4
+		ORDER BY id
+		LIMIT $5`
+
+	if afterID == "" {
+		afterID = "00000000-0000-0000-0000-000000000000"
+	}
+	rows, err := r.db.QueryContext(ctx, query, jobType, pq.Array(names), before, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// ClearFilePath records that a job's file has been deleted
+func (r *jobRepo) ClearFilePath(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE jobs SET file_path = NULL WHERE id = $1`, id)
+	return err
+}
+
+// DeleteFinishedBefore deletes finished top-level jobs that completed before
+// the cutoff; children (bundle files and retries), errors and rejects go
+// with them (ON DELETE CASCADE). Jobs whose files, or whose children's or
+// their retries' files, are still retained are kept until those are
+// removed. Objects in a client's bucket are never retained by us.
+func (r *jobRepo) DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error) {
+	query := `
+		DELETE FROM jobs j
+		WHERE j.parent_job_id IS NULL
+			AND j.status IN ('completed', 'failed', 'cancelled')
+			AND COALESCE(j.completed_at, j.created_at) < $1
+			AND (j.file_path IS NULL OR j.file_path LIKE 's3://%')
+			AND NOT EXISTS (SELECT 1 FROM jobs c WHERE c.parent_job_id = j.id
+				AND c.file_path IS NOT NULL AND c.file_path NOT LIKE 's3://%')
+			AND NOT EXISTS (SELECT 1 FROM jobs c JOIN jobs g ON g.parent_job_id = c.id
+				WHERE c.parent_job_id = j.id AND g.file_path IS NOT NULL AND g.file_path NOT LIKE 's3://%')
+	`
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
 // helper to convert empty string to NULL
 func nullString(s string) sql.NullString {
 	if s == "" {
@@ -274,3 +640,27 @@ func nullString(s string) sql.NullString {
 	}
 	return sql.NullString{String: s, Valid: true}
 }
+
+// marshalOptions encodes job options for the JSONB column
+func marshalOptions(opts *models.ImportOptions) []byte {
+	if opts == nil {
+		return []byte("{}")
+	}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return []byte("{}")
+	}
+	return data
+}
+
+// unmarshalOptions decodes job options, returning nil when none were set
+func unmarshalOptions(data []byte) *models.ImportOptions {
+	if len(data) == 0 || string(data) == "{}" {
+		return nil
+	}
+	var opts models.ImportOptions
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return nil
+	}
+	return &opts
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/bulk-import-export-api/internal/database"
 	"github.com/bulk-import-export-api/internal/models"
@@ -15,9 +16,14 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id string) (*models.User, error)
 	Exists(ctx context.Context, id string) (bool, error)
 	EmailExists(ctx context.Context, email string) (bool, error)
+	ExistingEmails(ctx context.Context, emails []string) ([]string, error)
+	ExistingIDs(ctx context.Context, ids []string) ([]string, error)
 	GetAllIDs(ctx context.Context) ([]string, error)
 	Count(ctx context.Context) (int, error)
 	StreamAll(ctx context.Context, callback func(*models.User) error) error
+	StreamFiltered(ctx context.Context, filter models.ExportFilter, callback func(*models.User) error) error
+	LatestChange(ctx context.Context) (*models.ChangeCursor, error)
+	StreamChanges(ctx context.Context, after, until models.ChangeCursor, callback func(*models.User) error) error
 }
 
 // ArticleRepository defines the interface for article data operations
@@ -27,9 +33,14 @@ type ArticleRepository interface {
 	GetByID(ctx context.Context, id string) (*models.Article, error)
 	Exists(ctx context.Context, id string) (bool, error)
 	SlugExists(ctx context.Context, slug string) (bool, error)
+	ExistingSlugs(ctx context.Context, slugs []string) ([]string, error)
+	ExistingIDs(ctx context.Context, ids []string) ([]string, error)
 	GetAllIDs(ctx context.Context) ([]string, error)
 	Count(ctx context.Context) (int, error)
 	StreamAll(ctx context.Context, callback func(*models.Article) error) error
+	StreamFiltered(ctx context.Context, filter models.ExportFilter, callback func(*models.Article) error) error
+	LatestChange(ctx context.Context) (*models.ChangeCursor, error)
+	StreamChanges(ctx context.Context, after, until models.ChangeCursor, callback func(*models.Article) error) error
 }
 
 // CommentRepository defines the interface for comment data operations
@@ -40,6 +51,9 @@ type CommentRepository interface {
 	Exists(ctx context.Context, id string) (bool, error)
 	Count(ctx context.Context) (int, error)
 	StreamAll(ctx context.Context, callback func(*models.Comment) error) error
+	StreamFiltered(ctx context.Context, filter models.ExportFilter, callback func(*models.Comment) error) error
+	LatestChange(ctx context.Context) (*models.ChangeCursor, error)
+	StreamChanges(ctx context.Context, after, until models.ChangeCursor, callback func(*models.Comment) error) error
 }
 
 // JobRepository defines the interface for job data operations
@@ -48,27 +62,82 @@ type JobRepository interface {
 	Update(ctx context.Context, job *models.Job) error
 	GetByID(ctx context.Context, id string) (*models.Job, error)
 	GetByIdempotencyKey(ctx context.Context, key string) (*models.Job, error)
+	GetChildren(ctx context.Context, parentID string) ([]*models.Job, error)
 	GetPendingJobs(ctx context.Context) ([]*models.Job, error)
 	MarkJobAsProcessing(ctx context.Context, jobID string) (bool, error)
+	OldestRunningImport(ctx context.Context) (*time.Time, error)
 	AddError(ctx context.Context, jobID string, err *models.ValidationError) error
 	AddErrors(ctx context.Context, jobID string, errors []models.ValidationError) error
 	GetErrors(ctx context.Context, jobID string, limit int) ([]models.ValidationError, error)
+	StreamErrors(ctx context.Context, filter models.ErrorFilter, callback func(*models.ValidationError) error) error
+	CountErrors(ctx context.Context, filter models.ErrorFilter) (map[string]int, error)
+	SummarizeErrors(ctx context.Context, filter models.ErrorFilter, limit int) ([]models.ErrorGroup, error)
+	AddTransforms(ctx context.Context, jobID string, applied []models.TransformRecord) error
+	GetTransforms(ctx context.Context, jobID string, limit int) ([]models.TransformRecord, error)
+	AddRejects(ctx context.Context, jobID string, rejects []models.RejectedRecord) error
+	StreamRejects(ctx context.Context, jobID string, retryableOnly bool, callback func(*models.RejectedRecord) error) error
+	AddRecovered(ctx context.Context, jobID string, recovered int) error
+
+	// Retention: finished jobs still holding a file, paged by ID after
+	// afterID; clearing a job's file once it is deleted; and deleting
+	// finished top-level jobs (with their children, errors and rejects) that no
+	// longer hold files
+	GetFinishedWithFiles(ctx context.Context, jobType models.JobType, statuses []models.JobStatus, before time.Time, afterID string, limit int) ([]*models.Job, error)
+	ClearFilePath(ctx context.Context, id string) error
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// UploadRepository defines the interface for resumable upload sessions
+type UploadRepository interface {
+	Create(ctx context.Context, upload *models.Upload) error
+	GetByID(ctx context.Context, id string) (*models.Upload, error)
+	GetByIdempotencyKey(ctx context.Context, key string) (*models.Upload, error)
+	UpdateOffset(ctx context.Context, id string, offset int64, expiresAt time.Time) error
+	SetJobID(ctx context.Context, id, jobID string) error
+	Delete(ctx context.Context, id string) error
+	GetExpired(ctx context.Context, before time.Time) ([]*models.Upload, error)
+}
+
+// Snapshot streams every resource from one consistent point in time
+type Snapshot interface {
+	StreamUsers(ctx context.Context, callback func(*models.User) error) error
+	StreamArticles(ctx context.Context, callback func(*models.Article) error) error
+	StreamComments(ctx context.Context, callback func(*models.Comment) error) error
+}
+
+// ExternalIDRepository defines the interface for mappings of foreign
+// systems' identifiers onto stored rows
+type ExternalIDRepository interface {
+	Assign(ctx context.Context, resource, source string, externalIDs []string) (map[string]string, error)
+	Resolve(ctx context.Context, resource, source string, externalIDs []string) (map[string]string, error)
+	List(ctx context.Context, filter models.ExternalIDFilter) ([]models.ExternalID, error)
+}
+
+// SnapshotRepository defines the interface for consistent multi-resource reads
+type SnapshotRepository interface {
+	WithSnapshot(ctx context.Context, fn func(Snapshot) error) error
 }
 
 // Repositories holds all repository interfaces
 type Repositories struct {
-	User    UserRepository
-	Article ArticleRepository
-	Comment CommentRepository
-	Job     JobRepository
+	User       UserRepository
+	Article    ArticleRepository
+	Comment    CommentRepository
+	Job        JobRepository
+	Upload     UploadRepository
+	ExternalID ExternalIDRepository
+	Snapshot   SnapshotRepository
 }
 
 // New creates all repositories with the given database connection
 func New(db *database.DB) *Repositories {
 	return &Repositories{
-		User:    NewUserRepo(db),
-		Article: NewArticleRepo(db),
-		Comment: NewCommentRepo(db),
-		Job:     NewJobRepo(db),
+		User:       NewUserRepo(db),
+		Article:    NewArticleRepo(db),
+		Comment:    NewCommentRepo(db),
+		Job:        NewJobRepo(db),
+		Upload:     NewUploadRepo(db),
+		ExternalID: NewExternalIDRepo(db),
+		Snapshot:   NewSnapshotRepo(db),
 	}
 }
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bulk-import-export-api/internal/database"
+	"github.com/bulk-import-export-api/internal/models"
+)
+
+// queryer is satisfied by both the connection pool and a transaction, so
+// export queries can run inside a snapshot
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// snapshotRepo is the concrete implementation of SnapshotRepository
+type snapshotRepo struct {
+	db *database.DB
+}
+
+// NewSnapshotRepo creates a new snapshot repository
+func NewSnapshotRepo(db *database.DB) SnapshotRepository {
+	return &snapshotRepo{db: db}
+}
+
+// WithSnapshot runs fn inside a read-only REPEATABLE READ transaction: every
+// query made through the Snapshot sees the database as of its first query,
+// whatever is written concurrently
+func (r *snapshotRepo) WithSnapshot(ctx context.Context, fn func(Snapshot) error) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&txSnapshot{tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// txSnapshot streams resources through a snapshot transaction
+type txSnapshot struct {
+	tx *sql.Tx
+}
+
+func (s *txSnapshot) StreamUsers(ctx context.Context, callback func(*models.User) error) error {
+	return streamUsers(ctx, s.tx, exportOrder, nil, callback)
+}
+
+func (s *txSnapshot) StreamArticles(ctx context.Context, callback func(*models.Article) error) error {
+	return streamArticles(ctx, s.tx, exportOrder, nil, callback)
+}
+
+func (s *txSnapshot) StreamComments(ctx context.Context, callback func(*models.Comment) error) error {
+	return streamComments(ctx, s.tx, exportOrder, nil, callback)
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/database"
+	"github.com/bulk-import-export-api/internal/models"
+)
+
+// uploadRepo is the concrete implementation of UploadRepository
+type uploadRepo struct {
+	db *database.DB
+}
+
+// NewUploadRepo creates a new upload session repository
+func NewUploadRepo(db *database.DB) UploadRepository {
+	return &uploadRepo{db: db}
+}
+
+// Create inserts a new upload session
+func (r *uploadRepo) Create(ctx context.Context, upload *models.Upload) error {
+	query := `
+		INSERT INTO upload_sessions (id, resource, format, filename, size, "offset", idempotency_key,
+			file_path, options, created_at, updated_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		upload.ID, upload.Resource, upload.Format, nullString(upload.Filename), upload.Size, upload.Offset,
+		nullString(upload.IdempotencyKey), upload.FilePath, marshalOptions(upload.Options),
+		upload.CreatedAt, upload.UpdatedAt, upload.ExpiresAt,
+	)
+	return err
+}
+
+// UpdateOffset records the bytes received so far and extends the session's
+// expiry
+func (r *uploadRepo) UpdateOffset(ctx context.Context, id string, offset int64, expiresAt time.Time) error {
+	query := `UPDATE upload_sessions SET "offset" = $1, expires_at = $2, updated_at = $3 WHERE id = $4`
+	_, err := r.db.ExecContext(ctx, query, offset, expiresAt, time.Now(), id)
+	return err
+}
+
+// SetJobID links a completed upload to the import job created from it
+func (r *uploadRepo) SetJobID(ctx context.Context, id, jobID string) error {
+	query := `UPDATE upload_sessions SET job_id = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, jobID, time.Now(), id)
+	return err
+}
+
+// Delete removes an upload session
+func (r *uploadRepo) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM upload_sessions WHERE id = $1`, id)
+	return err
+}
+
+// uploadColumns is the column list read by scanUpload
+const uploadColumns = `id, resource, format, filename, size, "offset", idempotency_key, job_id,
+	file_path, options, created_at, updated_at, expires_at`
+
+// scanUpload scans a row selected with uploadColumns
+func scanUpload(row interface{ Scan(dest ...any) error }) (*models.Upload, error) {
+	var upload models.Upload
+	var filename, idempotencyKey, jobID sql.NullString
+	var options []byte
+
+	err := row.Scan(
+		&upload.ID, &upload.Resource, &upload.Format, &filename, &upload.Size, &upload.Offset,
+		&idempotencyKey, &jobID, &upload.FilePath, &options,
+		&upload.CreatedAt, &upload.UpdatedAt, &upload.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	upload.Filename = filename.String
+	upload.IdempotencyKey = idempotencyKey.String
+	upload.JobID = jobID.String
+	upload.Options = unmarshalOptions(options)
+
+	return &upload, nil
+}
+
+// GetByID retrieves an upload session by ID
+func (r *uploadRepo) GetByID(ctx context.Context, id string) (*models.Upload, error) {
+	query := `SELECT ` + uploadColumns + ` FROM upload_sessions WHERE id = $1`
+
+	upload, err := scanUpload(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return upload, err
+}
+
+// GetByIdempotencyKey retrieves an upload session by idempotency key
+func (r *uploadRepo) GetByIdempotencyKey(ctx context.Context, key string) (*models.Upload, error) {
+	query := `SELECT ` + uploadColumns + ` FROM upload_sessions WHERE idempotency_key = $1`
+
+	upload, err := scanUpload(r.db.QueryRowContext(ctx, query, key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return upload, err
+}
+
+// GetExpired retrieves the sessions whose expiry is before the given time
+func (r *uploadRepo) GetExpired(ctx context.Context, before time.Time) ([]*models.Upload, error) {
+	query := `SELECT ` + uploadColumns + ` FROM upload_sessions WHERE expires_at < $1 ORDER BY expires_at`
+
+	rows, err := r.db.QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uploads []*models.Upload
+	for rows.Next() {
+		upload, err := scanUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, rows.Err()
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/bulk-import-export-api/internal/database"
 	"github.com/bulk-import-export-api/internal/models"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
@@ -23,12 +24,12 @@ func NewUserRepo(db *database.DB) UserRepository {
 // Create inserts a new user
 func (r *userRepo) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (id, email, name, role, active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (id, email, name, role, active, created_at, updated_at, source_updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		user.ID, user.Email, user.Name, user.Role, user.Active,
-		user.CreatedAt, time.Now(),
+		user.CreatedAt, time.Now(), user.SourceUpdatedAt,
 	)
 	return err
 }
@@ -36,17 +37,18 @@ func (r *userRepo) Create(ctx context.Context, user *models.User) error {
 // Upsert inserts or updates a user by email
 func (r *userRepo) Upsert(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (id, email, name, role, active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (id, email, name, role, active, created_at, updated_at, source_updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		ON CONFLICT (email) DO UPDATE SET
 			name = EXCLUDED.name,
 			role = EXCLUDED.role,
 			active = EXCLUDED.active,
-			updated_at = EXCLUDED.updated_at
+			updated_at = EXCLUDED.updated_at,
+			source_updated_at = EXCLUDED.source_updated_at
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		user.ID, user.Email, user.Name, user.Role, user.Active,
-		user.CreatedAt, time.Now(),
+		user.CreatedAt, time.Now(), user.SourceUpdatedAt,
 	)
 	return err
 }
@@ -65,7 +67,7 @@ func (r *userRepo) BatchInsert(ctx context.Context, users []*models.User) (int,
 
 	// Prepare COPY statement for bulk insert
 	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("users",
-		"id", "email", "name", "role", "active", "created_at", "updated_at",
+		"id", "email", "name", "role", "active", "created_at", "updated_at", "source_updated_at",
 	))
 	if err != nil {
 		return 0, err
@@ -78,7 +80,7 @@ func (r *userRepo) BatchInsert(ctx context.Context, users []*models.User) (int,
 	for _, user := range users {
 		_, err := stmt.ExecContext(ctx,
 			user.ID, user.Email, user.Name, user.Role, user.Active,
-			user.CreatedAt, now,
+			user.CreatedAt, now, user.SourceUpdatedAt,
 		)
 		if err != nil {
 			// Skip individual errors but log them
@@ -132,6 +134,25 @@ func (r *userRepo) EmailExists(ctx context.Context, email string) (bool, error)
 	return exists, err
 }
 
+// ExistingEmails returns those of emails that belong to stored users, in
+// one query on the unique email index
+func (r *userRepo) ExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+	return queryStrings(ctx, r.db, "SELECT email FROM users WHERE email = ANY($1)", pq.Array(emails))
+}
+
+// ExistingIDs returns those of ids that belong to stored users, for FK
+// validation one batch at a time. Ids that are not UUIDs are never stored.
+func (r *userRepo) ExistingIDs(ctx context.Context, ids []string) ([]string, error) {
+	ids = uuidsOnly(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return queryStrings(ctx, r.db, "SELECT id::text FROM users WHERE id = ANY($1::uuid[])", pq.Array(ids))
+}
+
 // GetAllIDs retrieves all user IDs (for FK validation cache)
 func (r *userRepo) GetAllIDs(ctx context.Context) ([]string, error) {
 	rows, err := r.db.QueryContext(ctx, "SELECT id FROM users")
@@ -160,8 +181,30 @@ func (r *userRepo) Count(ctx context.Context) (int, error) {
 
 // StreamAll streams all users for export (memory efficient)
 func (r *userRepo) StreamAll(ctx context.Context, callback func(*models.User) error) error {
-	query := `SELECT id, email, name, role, active, created_at, updated_at FROM users ORDER BY created_at`
-	rows, err := r.db.QueryContext(ctx, query)
+	return streamUsers(ctx, r.db, exportOrder, nil, callback)
+}
+
+// StreamFiltered streams the users matching filter in (created_at, id) order
+func (r *userRepo) StreamFiltered(ctx context.Context, filter models.ExportFilter, callback func(*models.User) error) error {
+	clause, args := filterClause(filter)
+	return streamUsers(ctx, r.db, clause, args, callback)
+}
+
+// LatestChange returns the position of the most recently updated user, or nil if there are none
+func (r *userRepo) LatestChange(ctx context.Context) (*models.ChangeCursor, error) {
+	return latestChange(ctx, r.db, "users")
+}
+
+// StreamChanges streams users updated after `after`, up to and including `until`, in (updated_at, id) order
+func (r *userRepo) StreamChanges(ctx context.Context, after, until models.ChangeCursor, callback func(*models.User) error) error {
+	return streamUsers(ctx, r.db, changesClause, changesArgs(after, until), callback)
+}
+
+// streamUsers runs the export query, narrowed and ordered by clause, on q:
+// either the pool or a snapshot transaction
+func streamUsers(ctx context.Context, q queryer, clause string, args []any, callback func(*models.User) error) error {
+	query := `SELECT id, email, name, role, active, created_at, updated_at FROM users ` + clause
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -184,3 +227,34 @@ func (r *userRepo) StreamAll(ctx context.Context, callback func(*models.User) er
 
 	return rows.Err()
 }
+
+// queryStrings runs a query selecting one text column and collects it
+func queryStrings(ctx context.Context, db *database.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// uuidsOnly returns those of ids that are valid UUIDs. Anything else cannot
+// match a stored row, and would fail a uuid[] cast for the whole query.
+func uuidsOnly(ids []string) []string {
+	valid := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err == nil {
+			valid = append(valid, id)
+		}
+	}
+	return valid
+}
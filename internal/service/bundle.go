@@ -0,0 +1,526 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/format"
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidBundle is returned when a bundle archive cannot be turned into
+// import jobs (unreadable archive, unknown resource, missing file, ...)
+var ErrInvalidBundle = errors.New("invalid bundle")
+
+// bundleManifestName is the optional manifest at the root of a bundle
+const bundleManifestName = "manifest.json"
+
+// maxBundleEntries caps the number of files extracted from one archive
+const maxBundleEntries = 100
+
+// bundleOrder is the dependency order of resources: articles reference
+// users, comments reference users and articles. Children run in this order
+// so the FK caches of each import see the rows inserted before it.
+var bundleOrder = map[string]int{
+	"users":    0,
+	"articles": 1,
+	"comments": 2,
+}
+
+// bundleManifest maps archive entries onto resources when file names do not
+// follow the users.csv / articles.ndjson / comments.ndjson convention.
+// Backups (GET /v1/exports/bundle) also record row counts and checksums,
+// which are verified before anything is loaded.
+type bundleManifest struct {
+	Version    int          `json:"version,omitempty"`
+	SnapshotAt *time.Time   `json:"snapshot_at,omitempty"`
+	Files      []bundleFile `json:"files"`
+}
+
+type bundleFile struct {
+	Resource string                `json:"resource"`
+	Path     string                `json:"path"`
+	Format   string                `json:"format,omitempty"` // defaults to the path's extension
+	Options  *models.ImportOptions `json:"options,omitempty"`
+	Rows     *int                  `json:"rows,omitempty"`   // checked on import when set
+	SHA256   string                `json:"sha256,omitempty"` // hex digest, checked on import when set
+}
+
+// BundleArchiveExt returns the archive extension of a bundle upload
+// (.zip, .tar, .tar.gz or .tgz), or false if it is not a supported archive
+func BundleArchiveExt(filename string) (string, bool) {
+	name := strings.ToLower(filename)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(name, ext) {
+			return ext, true
+		}
+	}
+	return "", false
+}
+
+// CreateBundleImportJob extracts a bundle archive and creates a parent job
+// with one pending child job per resource. Only the parent is picked up by
+// the job processor; it runs the children in dependency order. The archive
+// is deleted once its jobs exist: they only read the extracted files.
+func (s *importService) CreateBundleImportJob(ctx context.Context, req *models.ImportRequest, archivePath string) (*models.Job, error) {
+	ext, ok := BundleArchiveExt(archivePath)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported archive %s", ErrInvalidBundle, filepath.Base(archivePath))
+	}
+	if err := checkImportOptions(s.rules, req.Resource, req.Options); err != nil {
+		return nil, err
+	}
+
+	bundleDir := strings.TrimSuffix(archivePath, ext) + "_bundle"
+	files, err := s.extractBundle(ctx, archivePath, ext, bundleDir)
+	if err != nil {
+		s.removeAll(ctx, bundleDir+"/")
+		return nil, err
+	}
+
+	now := time.Now()
+	parent := &models.Job{
+		ID:             uuid.New().String(),
+		Type:           models.JobTypeImport,
+		Resource:       models.ResourceBundle,
+		Status:         models.JobStatusPending,
+		IdempotencyKey: req.IdempotencyKey,
+		FilePath:       bundleDir,
+		CreatedAt:      now,
+	}
+	if err := s.repos.Job.Create(ctx, parent); err != nil {
+		s.removeAll(ctx, bundleDir+"/")
+		return nil, err
+	}
+
+	children := make([]*models.Job, 0, len(files))
+	for i, f := range files {
+		// Every file of a bundle comes from the same source system unless
+		// its manifest entry names another
+		if req.Options != nil && req.Options.Source != "" && f.Options.Source == "" {
+			f.Options.Source = req.Options.Source
+		}
+		// and writes its dates the same way
+		if req.Options != nil && len(f.Options.DateLayouts) == 0 && f.Options.Timezone == "" {
+			f.Options.DateLayouts = req.Options.DateLayouts
+			f.Options.Timezone = req.Options.Timezone
+		}
+		child := &models.Job{
+			ID:          uuid.New().String(),
+			Type:        models.JobTypeImport,
+			Resource:    f.Resource,
+			Status:      models.JobStatusPending,
+			ParentJobID: parent.ID,
+			FilePath:    f.Path,
+			Options:     f.Options,
+			// Spaced so children list in run order
+			CreatedAt: now.Add(time.Duration(i+1) * time.Microsecond),
+		}
+		if err := s.repos.Job.Create(ctx, child); err != nil {
+			s.abandonBundle(ctx, parent, children, err)
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	if err := s.blobs.Delete(ctx, archivePath); err != nil {
+		s.log.Warn().Err(err).Str("job_id", parent.ID).Str("file", archivePath).Msg("Failed to delete extracted bundle archive")
+	}
+
+	s.log.Info().
+		Str("job_id", parent.ID).
+		Int("children", len(files)).
+		Str("file", archivePath).
+		Msg("Bundle import job created")
+
+	return parent, nil
+}
+
+// abandonBundle fails a parent whose children could not all be created, so
+// the processor never runs a partial bundle, and removes its extracted files.
+func (s *importService) abandonBundle(ctx context.Context, parent *models.Job, children []*models.Job, cause error) {
+	now := time.Now()
+	for _, child := range children {
+		child.Status = models.JobStatusCancelled
+		child.CompletedAt = &now
+		if err := s.repos.Job.Update(ctx, child); err != nil {
+			s.log.Error().Err(err).Str("job_id", child.ID).Msg("Failed to cancel bundle child job")
+		}
+	}
+	s.log.Error().Err(cause).Str("job_id", parent.ID).Msg("Bundle import job abandoned")
+	dir := parent.FilePath
+	parent.Status = models.JobStatusFailed
+	parent.FilePath = ""
+	parent.CompletedAt = &now
+	if err := s.repos.Job.Update(ctx, parent); err != nil {
+		s.log.Error().Err(err).Str("job_id", parent.ID).Msg("Failed to mark bundle job failed")
+	}
+	s.removeAll(ctx, dir+"/")
+}
+
+// extractBundle unpacks the archive into storage under dir and resolves
+// which file holds which resource. Entry names are never used as keys: each
+// entry is stored as dir/entry_NNN, so archives cannot escape dir, and the
+// resolved files record their format in their options.
+func (s *importService) extractBundle(ctx context.Context, archivePath, ext, dir string) ([]bundleFile, error) {
+	entries := make(map[string]string) // archive entry name -> extracted key
+	budget := s.cfg.Import.MaxBundleSize
+
+	extract := func(name string, r io.Reader) error {
+		name = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+		base := path.Base(name)
+		if strings.HasPrefix(base, ".") || strings.HasPrefix(name, "__MACOSX/") {
+			return nil
+		}
+		if len(entries) >= maxBundleEntries {
+			return fmt.Errorf("%w: more than %d files in archive", ErrInvalidBundle, maxBundleEntries)
+		}
+
+		dst := fmt.Sprintf("%s/entry_%03d", dir, len(entries))
+		entries[name] = dst
+
+		// Guard against archive bombs: stop once the extracted total passes
+		// MaxBundleSize
+		src := r
+		if s.cfg.Import.MaxBundleSize > 0 {
+			src = io.LimitReader(r, budget+1)
+		}
+		counted := &countingReader{r: src}
+		if err := s.blobs.Put(ctx, dst, counted); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		if s.cfg.Import.MaxBundleSize > 0 {
+			budget -= counted.n
+			if budget < 0 {
+				return fmt.Errorf("%w: extracted size exceeds %d MB", ErrInvalidBundle, s.cfg.Import.MaxBundleSize/(1024*1024))
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if ext == ".zip" {
+		err = s.walkZip(ctx, archivePath, extract)
+	} else {
+		err = s.walkTar(ctx, archivePath, ext != ".tar", extract)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := s.resolveBundleFiles(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record each file's format, which its extracted name no longer
+	// carries, and drop everything else
+	used := make(map[string]bool)
+	for i := range files {
+		opts := models.ImportOptions{}
+		if files[i].Options != nil {
+			opts = *files[i].Options
+		}
+		opts.Format = files[i].Format
+		files[i].Options = &opts
+		used[files[i].Path] = true
+	}
+	for _, extracted := range entries {
+		if !used[extracted] {
+			s.blobs.Delete(ctx, extracted)
+		}
+	}
+
+	for _, f := range files {
+		if err := s.verifyBundleFile(ctx, f); err != nil {
+			return nil, err
+		}
+		if err := checkImportOptions(s.rules, f.Resource, f.Options); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidBundle, f.Path, err)
+		}
+	}
+
+	return files, nil
+}
+
+// removeAll deletes every stored artifact under prefix
+func (s *importService) removeAll(ctx context.Context, prefix string) {
+	infos, err := s.blobs.List(ctx, prefix)
+	if err != nil {
+		s.log.Error().Err(err).Str("prefix", prefix).Msg("Failed to list artifacts for removal")
+		return
+	}
+	for _, info := range infos {
+		s.blobs.Delete(ctx, info.Key)
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// verifyBundleFile checks a file against the checksum and row count its
+// manifest entry records, so a truncated or edited backup is rejected before
+// any of it is loaded
+func (s *importService) verifyBundleFile(ctx context.Context, f bundleFile) error {
+	if f.SHA256 != "" {
+		file, err := s.blobs.Get(ctx, f.Path)
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, f.SHA256) {
+			return fmt.Errorf("%w: %s checksum mismatch (manifest %s, file %s)", ErrInvalidBundle, f.Resource, f.SHA256, sum)
+		}
+	}
+
+	if f.Rows != nil {
+		decoder, err := openDecoder(ctx, s.blobs, &models.Job{FilePath: f.Path, Options: f.Options}, resourceSchemas[f.Resource])
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidBundle, f.Resource, err)
+		}
+		defer decoder.Close()
+
+		rows := 0
+		for {
+			_, _, err := decoder.Next()
+			if err == io.EOF {
+				break
+			}
+			var recErr *format.RecordError
+			if err != nil && !errors.As(err, &recErr) {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidBundle, f.Resource, err)
+			}
+			// Malformed rows still count: the loader reports them per line
+			rows++
+		}
+		if rows != *f.Rows {
+			return fmt.Errorf("%w: %s has %d rows, manifest lists %d", ErrInvalidBundle, f.Resource, rows, *f.Rows)
+		}
+	}
+
+	return nil
+}
+
+// resolveBundleFiles picks the file for each resource, from manifest.json
+// if present or else by name (users.csv, articles.ndjson, ...), checks that
+// each has a decodable format, and sorts them in dependency order
+func (s *importService) resolveBundleFiles(ctx context.Context, entries map[string]string) ([]bundleFile, error) {
+	var files []bundleFile
+
+	if manifestKey, ok := entries[bundleManifestName]; ok {
+		data, err := s.readAll(ctx, manifestKey)
+		if err != nil {
+			return nil, err
+		}
+		var manifest bundleManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("%w: invalid %s: %v", ErrInvalidBundle, bundleManifestName, err)
+		}
+		for _, f := range manifest.Files {
+			name := strings.TrimPrefix(path.Clean("/"+f.Path), "/")
+			extracted, ok := entries[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s lists %s, which is not in the archive", ErrInvalidBundle, bundleManifestName, f.Path)
+			}
+			if f.Format == "" {
+				if ff, ok := format.ForExtension(path.Ext(name)); ok {
+					f.Format = ff.Name
+				}
+			}
+			f.Path = extracted
+			files = append(files, f)
+		}
+	} else {
+		for name, extracted := range entries {
+			base := path.Base(name)
+			resource := strings.TrimSuffix(base, path.Ext(base))
+			if _, ok := bundleOrder[resource]; !ok {
+				continue
+			}
+			f, ok := format.ForExtension(path.Ext(base))
+			if !ok {
+				continue
+			}
+			files = append(files, bundleFile{Resource: resource, Path: extracted, Format: f.Name})
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: no users, articles or comments file found", ErrInvalidBundle)
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range files {
+		if _, ok := bundleOrder[f.Resource]; !ok {
+			return nil, fmt.Errorf("%w: unknown resource %q", ErrInvalidBundle, f.Resource)
+		}
+		if seen[f.Resource] {
+			return nil, fmt.Errorf("%w: more than one %s file", ErrInvalidBundle, f.Resource)
+		}
+		seen[f.Resource] = true
+
+		ff, ok := format.Lookup(f.Format)
+		if !ok || ff.NewDecoder == nil {
+			return nil, fmt.Errorf("%w: unsupported format for %s file", ErrInvalidBundle, f.Resource)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return bundleOrder[files[i].Resource] < bundleOrder[files[j].Resource]
+	})
+	return files, nil
+}
+
+// readAll reads a whole stored artifact
+func (s *importService) readAll(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// walkZip reads the central directory at the end of the archive, so it
+// needs random access; every storage backend's readers provide it
+func (s *importService) walkZip(ctx context.Context, archivePath string, fn func(name string, r io.Reader) error) error {
+	info, err := s.blobs.Stat(ctx, archivePath)
+	if err != nil {
+		return err
+	}
+	file, err := s.blobs.Get(ctx, archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ra, ok := file.(io.ReaderAt)
+	if !ok {
+		return fmt.Errorf("%w: storage for %s does not support random access", ErrInvalidBundle, filepath.Base(archivePath))
+	}
+	zr, err := zip.NewReader(ra, info.Size)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		err = fn(entry.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *importService) walkTar(ctx context.Context, archivePath string, gzipped bool, fn func(name string, r io.Reader) error) error {
+	file, err := s.blobs.Get(ctx, archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if gzipped {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// processBundle runs a bundle's child jobs in dependency order and rolls
+// their counters up into the parent. A child that fails stops the bundle:
+// the remaining children depend on it and are cancelled.
+func (s *importService) processBundle(ctx context.Context, job *models.Job) error {
+	children, err := s.repos.Job.GetChildren(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return bundleOrder[children[i].Resource] < bundleOrder[children[j].Resource]
+	})
+
+	var failed error
+	for _, child := range children {
+		if failed == nil && ctx.Err() != nil {
+			failed = ctx.Err()
+		}
+		if failed != nil {
+			child.Status = models.JobStatusCancelled
+			store, cancel := bookkeeping(ctx)
+			s.repos.Job.Update(store, child)
+			cancel()
+			continue
+		}
+
+		if err := s.ProcessImport(ctx, child); err != nil {
+			failed = fmt.Errorf("%s import failed: %w", child.Resource, err)
+		}
+
+		job.TotalRecords += child.TotalRecords
+		job.ProcessedCount += child.ProcessedCount
+		job.SuccessfulCount += child.SuccessfulCount
+		job.FailedCount += child.FailedCount
+		job.WarningCount += child.WarningCount
+
+		// Publish progress after each child
+		s.repos.Job.Update(ctx, job)
+	}
+
+	return failed
+}
@@ -0,0 +1,433 @@
+package service_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+	"github.com/bulk-import-export-api/internal/storage"
+)
+
+const (
+	bundleUserID    = "550e8400-e29b-41d4-a716-446655440000"
+	bundleArticleID = "6f9619ff-8b86-d011-b42d-00cf4fc964ff"
+	bundleComment1  = "8e9619ff-8b86-d011-b42d-00cf4fc964f1"
+	bundleComment2  = "8e9619ff-8b86-d011-b42d-00cf4fc964f2"
+)
+
+var bundleFiles = map[string]string{
+	"users.csv": "id,email,name,role,active,created_at\n" +
+		bundleUserID + ",author@test.com,Author,editor,true,2024-01-01T00:00:00Z\n",
+	"articles.ndjson": `{"id":"` + bundleArticleID + `","slug":"hello","title":"Hello","body":"b","author_id":"` + bundleUserID + `","status":"draft"}` + "\n",
+	// The second comment references an article that exists nowhere; it is
+	// only rejected if articles were imported first
+	"comments.ndjson": `{"id":"` + bundleComment1 + `","article_id":"` + bundleArticleID + `","user_id":"` + bundleUserID + `","body":"nice","created_at":"2024-01-02T00:00:00Z"}` + "\n" +
+		`{"id":"` + bundleComment2 + `","article_id":"7f9619ff-8b86-d011-b42d-00cf4fc964ff","user_id":"` + bundleUserID + `","body":"orphan","created_at":"2024-01-02T00:00:00Z"}` + "\n",
+}
+
+// writeZip writes entries in the given order, so tests can check that run
+// order does not depend on archive order
+func writeZip(t *testing.T, h *testHarness, names []string, files map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, _ := zw.Create(name)
+		w.Write([]byte(files[name]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return h.put(t, "bundle.zip", buf.Bytes())
+}
+
+func createBundle(t *testing.T, h *testHarness, archivePath string) *models.Job {
+	t.Helper()
+	job, err := h.services.Import.CreateBundleImportJob(context.Background(), &models.ImportRequest{Resource: models.ResourceBundle}, archivePath)
+	if err != nil {
+		t.Fatalf("CreateBundleImportJob returned error: %v", err)
+	}
+	return job
+}
+
+func TestBundleImport_DependencyOrder(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	archive := writeZip(t, h, []string{"comments.ndjson", "articles.ndjson", "users.csv"}, bundleFiles)
+	parent := createBundle(t, h, archive)
+
+	children, _ := h.jobRepo.GetChildren(ctx, parent.ID)
+	if len(children) != 3 {
+		t.Fatalf("Expected 3 child jobs, got %d", len(children))
+	}
+	for i, resource := range []string{"users", "articles", "comments"} {
+		if children[i].Resource != resource {
+			t.Errorf("Expected child %d to be %s, got %s", i, resource, children[i].Resource)
+		}
+	}
+
+	// Only the parent is picked up by the job processor
+	pending, _ := h.jobRepo.GetPendingJobs(ctx)
+	if len(pending) != 1 || pending[0].ID != parent.ID {
+		t.Errorf("Expected only the parent job to be pending, got %d jobs", len(pending))
+	}
+
+	// The children read the extracted files; the archive itself is gone
+	if _, err := h.blobs.Stat(ctx, archive); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the archive deleted after extraction, got %v", err)
+	}
+
+	if err := h.services.Import.ProcessImport(ctx, parent); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+
+	if parent.Status != models.JobStatusCompleted {
+		t.Errorf("Expected parent completed, got %s", parent.Status)
+	}
+	if parent.TotalRecords != 4 || parent.SuccessfulCount != 3 || parent.FailedCount != 1 {
+		t.Errorf("Expected 4 total / 3 ok / 1 failed, got %d / %d / %d", parent.TotalRecords, parent.SuccessfulCount, parent.FailedCount)
+	}
+	for _, child := range children {
+		if child.Status != models.JobStatusCompleted {
+			t.Errorf("Expected %s child completed, got %s", child.Resource, child.Status)
+		}
+	}
+
+	comments := children[2]
+	errs := h.jobRepo.Errors[comments.ID]
+	if len(errs) != 1 || errs[0].Field != "article_id" || errs[0].Line != 2 {
+		t.Errorf("Expected orphan comment rejected on line 2, got %v", errs)
+	}
+
+	resp, _ := h.services.Job.GetJob(ctx, parent.ID)
+	if len(resp.Children) != 3 || resp.Children[2].ErrorReport == "" {
+		t.Errorf("Expected 3 children with an error report on comments, got %+v", resp.Children)
+	}
+}
+
+func TestBundleImport_Manifest(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	files := map[string]string{
+		"manifest.json": `{"files":[
+			{"resource":"articles","path":"data/posts.txt","format":"ndjson"},
+			{"resource":"users","path":"./data/people.csv"}
+		]}`,
+		"data/people.csv": bundleFiles["users.csv"],
+		"data/posts.txt":  bundleFiles["articles.ndjson"],
+		"README.md":       "ignored",
+	}
+
+	// tar.gz this time
+	var out bytes.Buffer
+	gz := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gz)
+	for _, name := range []string{"data/posts.txt", "data/people.csv", "README.md", "manifest.json"} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg})
+		tw.Write([]byte(files[name]))
+	}
+	tw.Close()
+	gz.Close()
+
+	parent := createBundle(t, h, h.put(t, "bundle.tar.gz", out.Bytes()))
+	if err := h.services.Import.ProcessImport(ctx, parent); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+
+	if parent.SuccessfulCount != 2 {
+		t.Errorf("Expected 2 records imported, got %d", parent.SuccessfulCount)
+	}
+	if h.articleRepo.Articles[bundleArticleID] == nil {
+		t.Error("Expected article from manifest-mapped file to be imported")
+	}
+}
+
+func TestBundleImport_FailedChildCancelsDependents(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	files := map[string]string{
+		"users.parquet":   "not a parquet file",
+		"articles.ndjson": bundleFiles["articles.ndjson"],
+		"comments.ndjson": bundleFiles["comments.ndjson"],
+	}
+	archive := writeZip(t, h, []string{"users.parquet", "articles.ndjson", "comments.ndjson"}, files)
+	parent := createBundle(t, h, archive)
+
+	err := h.services.Import.ProcessImport(ctx, parent)
+	if err == nil || !strings.Contains(err.Error(), "users import failed") {
+		t.Fatalf("Expected users import failure, got %v", err)
+	}
+	if parent.Status != models.JobStatusFailed {
+		t.Errorf("Expected parent failed, got %s", parent.Status)
+	}
+
+	children, _ := h.jobRepo.GetChildren(ctx, parent.ID)
+	want := []models.JobStatus{models.JobStatusFailed, models.JobStatusCancelled, models.JobStatusCancelled}
+	for i, child := range children {
+		if child.Status != want[i] {
+			t.Errorf("Expected %s child %s, got %s", child.Resource, want[i], child.Status)
+		}
+	}
+	if len(h.articleRepo.Articles) != 0 {
+		t.Error("Expected no articles imported after users failed")
+	}
+}
+
+func TestBundleImport_FailedChildCreationFailsParent(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	archive := writeZip(t, h, []string{"users.csv", "articles.ndjson", "comments.ndjson"}, bundleFiles)
+	h.jobRepo.CreateFunc = func(ctx context.Context, job *models.Job) error {
+		if job.Resource == "comments" {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+
+	_, err := h.services.Import.CreateBundleImportJob(ctx, &models.ImportRequest{Resource: models.ResourceBundle}, archive)
+	if err == nil {
+		t.Fatal("Expected error when a child job cannot be created")
+	}
+
+	for _, job := range h.jobRepo.Jobs {
+		switch {
+		case job.Resource == models.ResourceBundle && job.Status != models.JobStatusFailed:
+			t.Errorf("Expected parent failed, got %s", job.Status)
+		case job.ParentJobID != "" && job.Status != models.JobStatusCancelled:
+			t.Errorf("Expected %s child cancelled, got %s", job.Resource, job.Status)
+		}
+	}
+	if len(h.jobRepo.Jobs) != 3 {
+		t.Errorf("Expected parent and 2 children, got %d jobs", len(h.jobRepo.Jobs))
+	}
+	infos, _ := h.blobs.List(ctx, strings.TrimSuffix(archive, ".zip")+"_bundle/")
+	if len(infos) != 0 {
+		t.Errorf("Expected extracted files removed, got %d", len(infos))
+	}
+}
+
+func TestBundleImport_InvalidArchives(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "no resource files",
+			files: map[string]string{"notes.txt": "hello"},
+			want:  "no users, articles or comments file found",
+		},
+		{
+			name:  "duplicate resource",
+			files: map[string]string{"users.csv": "id\n", "a/users.ndjson": "{}\n"},
+			want:  "more than one users file",
+		},
+		{
+			name:  "manifest references missing file",
+			files: map[string]string{"manifest.json": `{"files":[{"resource":"users","path":"missing.csv"}]}`},
+			want:  "not in the archive",
+		},
+		{
+			name:  "manifest unknown resource",
+			files: map[string]string{"manifest.json": `{"files":[{"resource":"tags","path":"tags.csv"}]}`, "tags.csv": "x\n"},
+			want:  `unknown resource "tags"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHarness(t)
+			var names []string
+			for name := range tt.files {
+				names = append(names, name)
+			}
+			archive := writeZip(t, h, names, tt.files)
+
+			_, err := h.services.Import.CreateBundleImportJob(context.Background(), &models.ImportRequest{Resource: models.ResourceBundle}, archive)
+			if !errors.Is(err, service.ErrInvalidBundle) {
+				t.Fatalf("Expected ErrInvalidBundle, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+			if len(h.jobRepo.Jobs) != 0 {
+				t.Errorf("Expected no jobs created, got %d", len(h.jobRepo.Jobs))
+			}
+		})
+	}
+}
+
+func TestBundleImport_EntryNamesCannotEscape(t *testing.T) {
+	h := newTestHarness(t)
+
+	files := map[string]string{"../../users.csv": bundleFiles["users.csv"]}
+	archive := writeZip(t, h, []string{"../../users.csv"}, files)
+	parent := createBundle(t, h, archive)
+
+	children, _ := h.jobRepo.GetChildren(context.Background(), parent.ID)
+	if len(children) != 1 {
+		t.Fatalf("Expected 1 child job, got %d", len(children))
+	}
+	if path.Dir(children[0].FilePath) != parent.FilePath {
+		t.Errorf("Expected extracted file inside %s, got %s", parent.FilePath, children[0].FilePath)
+	}
+}
+
+// readZip returns the entries of a zip archive in order, with their contents
+func readZip(t *testing.T, data []byte) ([]string, map[string]string) {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Expected a valid zip archive: %v", err)
+	}
+	var names []string
+	files := make(map[string]string)
+	for _, entry := range zr.File {
+		rc, _ := entry.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		names = append(names, entry.Name)
+		files[entry.Name] = string(content)
+	}
+	return names, files
+}
+
+// backupOf imports the shared bundle fixture and returns a backup of it
+func backupOf(t *testing.T, formatName string) []byte {
+	t.Helper()
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	parent := createBundle(t, h, writeZip(t, h, []string{"users.csv", "articles.ndjson", "comments.ndjson"}, bundleFiles))
+	if err := h.services.Import.ProcessImport(ctx, parent); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamBundle(ctx, w, formatName); err != nil {
+		t.Fatalf("StreamBundle returned error: %v", err)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Expected application/zip, got %s", ct)
+	}
+	return w.Body.Bytes()
+}
+
+func TestBundleExport_RestoreRoundTrip(t *testing.T) {
+	for _, formatName := range []string{"ndjson", "csv", "parquet"} {
+		t.Run(formatName, func(t *testing.T) {
+			backup := backupOf(t, formatName)
+
+			names, files := readZip(t, backup)
+			if len(names) != 4 || names[3] != "manifest.json" {
+				t.Fatalf("Expected 3 resource files then manifest.json, got %v", names)
+			}
+
+			var manifest struct {
+				Version int `json:"version"`
+				Files   []struct {
+					Resource string `json:"resource"`
+					Format   string `json:"format"`
+					Rows     int    `json:"rows"`
+					SHA256   string `json:"sha256"`
+				} `json:"files"`
+			}
+			if err := json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil {
+				t.Fatalf("Invalid manifest: %v", err)
+			}
+			if manifest.Version != 1 || len(manifest.Files) != 3 {
+				t.Fatalf("Expected version 1 manifest with 3 files, got %+v", manifest)
+			}
+			for _, f := range manifest.Files {
+				if f.Rows != 1 || len(f.SHA256) != 64 || f.Format != formatName {
+					t.Errorf("Expected 1 row with a checksum for %s, got %+v", f.Resource, f)
+				}
+			}
+
+			// Restore into an empty dataset
+			h := newTestHarness(t)
+			ctx := context.Background()
+			parent := createBundle(t, h, h.put(t, "backup.zip", backup))
+			if err := h.services.Import.ProcessImport(ctx, parent); err != nil {
+				t.Fatalf("ProcessImport returned error: %v", err)
+			}
+			if parent.SuccessfulCount != 3 || parent.FailedCount != 0 {
+				t.Errorf("Expected 3 restored rows and no failures, got %d / %d", parent.SuccessfulCount, parent.FailedCount)
+			}
+			if h.userRepo.Users[bundleUserID] == nil || h.articleRepo.Articles[bundleArticleID] == nil || len(h.commentRepo.Comments) != 1 {
+				t.Error("Expected every backed up row to be restored")
+			}
+		})
+	}
+}
+
+func TestBundleExport_RestoreRejectsTamperedBackup(t *testing.T) {
+	backup := backupOf(t, "ndjson")
+	names, files := readZip(t, backup)
+
+	tests := []struct {
+		name   string
+		tamper func(files map[string]string)
+		want   string
+	}{
+		{
+			name: "edited file",
+			tamper: func(files map[string]string) {
+				files["users.ndjson"] = strings.Replace(files["users.ndjson"], "Author", "Mallory", 1)
+			},
+			want: "users checksum mismatch",
+		},
+		{
+			name: "row count",
+			tamper: func(files map[string]string) {
+				files["manifest.json"] = strings.Replace(files["manifest.json"], `"rows": 1`, `"rows": 5`, 1)
+			},
+			want: "users has 1 rows, manifest lists 5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHarness(t)
+			tampered := make(map[string]string)
+			for name, content := range files {
+				tampered[name] = content
+			}
+			tt.tamper(tampered)
+
+			_, err := h.services.Import.CreateBundleImportJob(context.Background(), &models.ImportRequest{Resource: models.ResourceBundle}, writeZip(t, h, names, tampered))
+			if !errors.Is(err, service.ErrInvalidBundle) || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Expected ErrInvalidBundle containing %q, got %v", tt.want, err)
+			}
+			if len(h.jobRepo.Jobs) != 0 || len(h.userRepo.Users) != 0 {
+				t.Error("Expected nothing loaded from a tampered backup")
+			}
+		})
+	}
+}
+
+func TestBundleExport_UnsupportedFormat(t *testing.T) {
+	h := newTestHarness(t)
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamBundle(context.Background(), w, "yaml"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+	if w.Body.Len() != 0 {
+		t.Error("Expected nothing written for unsupported format")
+	}
+}
@@ -0,0 +1,280 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+)
+
+// exportChanges runs an incremental users export and returns the exported
+// ids and the cursor to resume from
+func exportChanges(t *testing.T, h *testHarness, after models.ChangeCursor) ([]string, models.ChangeCursor, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamChanges(context.Background(), w, "users", "ndjson", after); err != nil {
+		t.Fatalf("StreamChanges returned error: %v", err)
+	}
+
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(w.Body.String()), "\n") {
+		if i := strings.Index(line, `"id":"`); i >= 0 {
+			ids = append(ids, line[i+6:i+6+strings.Index(line[i+6:], `"`)])
+		}
+	}
+
+	next, err := service.DecodeChangeCursor(w.Header().Get(service.NextCursorHeader))
+	if err != nil {
+		t.Fatalf("Expected a decodable %s header, got %q", service.NextCursorHeader, w.Header().Get(service.NextCursorHeader))
+	}
+	return ids, next, w.Header().Get(service.WatermarkHeader)
+}
+
+func TestStreamChanges_Watermarks(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	addUser := func(id string, updated time.Time) {
+		h.userRepo.Create(ctx, &models.User{ID: id, Email: id + "@test.com", Name: id, Role: "viewer", CreatedAt: base, UpdatedAt: updated})
+	}
+	addUser("u1", base)
+	addUser("u3", base.Add(time.Minute))
+	addUser("u2", base.Add(time.Minute)) // same updated_at as u3: ordered by id
+	addUser("u4", base.Add(2*time.Minute))
+
+	ids, next, watermark := exportChanges(t, h, models.ChangeCursor{UpdatedAt: base.Add(time.Minute)})
+	if strings.Join(ids, ",") != "u2,u3,u4" {
+		t.Errorf("Expected u2,u3,u4 updated since the watermark, got %v", ids)
+	}
+	if next.ID != "u4" || !next.UpdatedAt.Equal(base.Add(2*time.Minute)) {
+		t.Errorf("Expected next cursor at u4, got %+v", next)
+	}
+	if watermark != "2024-03-01T12:02:00Z" {
+		t.Errorf("Expected watermark 2024-03-01T12:02:00Z, got %s", watermark)
+	}
+
+	// Nothing changed: no rows and the same cursor back
+	ids, again, _ := exportChanges(t, h, next)
+	if len(ids) != 0 || again != next {
+		t.Errorf("Expected no rows and an unchanged cursor, got %v and %+v", ids, again)
+	}
+
+	// A row changed at the watermark's timestamp but sorting after it is
+	// not lost
+	addUser("u5", base.Add(2*time.Minute))
+	ids, _, _ = exportChanges(t, h, next)
+	if strings.Join(ids, ",") != "u5" {
+		t.Errorf("Expected only u5, got %v", ids)
+	}
+}
+
+func TestStreamChanges_RunningImportHoldsWatermark(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	addUser := func(id string, updated time.Time) {
+		h.userRepo.Create(ctx, &models.User{ID: id, Email: id + "@test.com", Name: id, Role: "viewer", CreatedAt: base, UpdatedAt: updated})
+	}
+	addUser("u1", base)
+
+	// An import started at base+1m may still commit rows stamped from then
+	// on, so a committed row stamped later is held back with them
+	started := base.Add(time.Minute)
+	running := &models.Job{ID: "running", Type: models.JobTypeImport, Resource: "users", Status: models.JobStatusProcessing, StartedAt: &started}
+	h.jobRepo.Create(ctx, running)
+	addUser("u3", base.Add(2*time.Minute))
+
+	ids, next, watermark := exportChanges(t, h, models.ChangeCursor{})
+	if strings.Join(ids, ",") != "u1" {
+		t.Errorf("Expected only u1 before the running import's start, got %v", ids)
+	}
+	if watermark != "2024-03-01T12:01:00Z" {
+		t.Errorf("Expected watermark at the import's start, got %s", watermark)
+	}
+
+	// The import commits a row stamped before u3, then finishes
+	addUser("u2", started)
+	running.Status = models.JobStatusCompleted
+	ids, _, _ = exportChanges(t, h, next)
+	if strings.Join(ids, ",") != "u2,u3" {
+		t.Errorf("Expected u2,u3 once the import finished, got %v", ids)
+	}
+}
+
+func TestStreamChanges_EmptyAndUnknown(t *testing.T) {
+	h := newTestHarness(t)
+
+	ids, next, watermark := exportChanges(t, h, models.ChangeCursor{})
+	if len(ids) != 0 || next != (models.ChangeCursor{}) || watermark != "" {
+		t.Errorf("Expected an empty export with a zero cursor, got %v %+v %q", ids, next, watermark)
+	}
+
+	if err := h.services.Export.StreamChanges(context.Background(), httptest.NewRecorder(), "tags", "ndjson", models.ChangeCursor{}); err == nil {
+		t.Error("Expected error for unknown resource")
+	}
+}
+
+func TestDecodeChangeCursor_Invalid(t *testing.T) {
+	for _, token := range []string{"not base64!", "bm90IGpzb24"} {
+		if _, err := service.DecodeChangeCursor(token); err != service.ErrInvalidCursor {
+			t.Errorf("Expected ErrInvalidCursor for %q, got %v", token, err)
+		}
+	}
+}
+
+// Imported rows carry updated_at so they show up in incremental exports
+func TestImport_SetsUpdatedAt(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+	before := time.Now()
+
+	job := createTestJob(h, "users", h.put(t, "users.csv", []byte(bundleFiles["users.csv"])))
+	if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+
+	user := h.userRepo.Users[bundleUserID]
+	if user == nil || user.UpdatedAt.Before(before) {
+		t.Fatalf("Expected updated_at set at import time, got %+v", user)
+	}
+
+	ids, _, _ := exportChanges(t, h, models.ChangeCursor{UpdatedAt: before})
+	if len(ids) != 1 || ids[0] != bundleUserID {
+		t.Errorf("Expected the imported user in the changes since import, got %v", ids)
+	}
+}
+
+func TestListPage_KeysetPagination(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// u2 and u3 share created_at: ordered by id, and never split or repeated
+	// across a page boundary
+	for i, created := range []time.Time{base, base.Add(time.Second), base.Add(time.Second), base.Add(2 * time.Second), base.Add(3 * time.Second)} {
+		id := fmt.Sprintf("u%d", i+1)
+		h.userRepo.Create(ctx, &models.User{ID: id, Email: id + "@test.com", Name: id, Role: "viewer", CreatedAt: created, UpdatedAt: created})
+	}
+
+	var pages [][]string
+	filter := models.ExportFilter{Limit: 2}
+	for {
+		page, err := h.services.Export.ListPage(ctx, "users", filter)
+		if err != nil {
+			t.Fatalf("ListPage returned error: %v", err)
+		}
+
+		var rows []map[string]any
+		if err := json.Unmarshal(page.Data, &rows); err != nil {
+			t.Fatalf("Expected a JSON array of rows, got %s", page.Data)
+		}
+		if len(rows) != page.Count {
+			t.Errorf("Expected count %d to match %d rows", page.Count, len(rows))
+		}
+		var ids []string
+		for _, row := range rows {
+			ids = append(ids, row["id"].(string))
+		}
+		pages = append(pages, ids)
+
+		if page.NextCursor == "" {
+			break
+		}
+		after, err := service.DecodePageCursor(page.NextCursor)
+		if err != nil {
+			t.Fatalf("Expected a decodable next_cursor: %v", err)
+		}
+		filter.After = &after
+	}
+
+	if fmt.Sprint(pages) != "[[u1 u2] [u3 u4] [u5]]" {
+		t.Errorf("Expected pages [[u1 u2] [u3 u4] [u5]], got %v", pages)
+	}
+}
+
+func TestListPage_ProjectionAndFilter(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	h.userRepo.Create(ctx, &models.User{ID: "old", Email: "old@test.com", Name: "Old", Role: "viewer", CreatedAt: base, UpdatedAt: base})
+	h.userRepo.Create(ctx, &models.User{ID: "new", Email: "new@test.com", Name: "New", Role: "admin", Active: true, CreatedAt: base, UpdatedAt: base.Add(time.Hour)})
+
+	page, err := h.services.Export.ListPage(ctx, "users", models.ExportFilter{UpdatedSince: base.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("ListPage returned error: %v", err)
+	}
+	if page.Limit != service.DefaultPageLimit || page.NextCursor != "" {
+		t.Errorf("Expected default limit and no next cursor, got %d %q", page.Limit, page.NextCursor)
+	}
+
+	// Same bytes as a format=json export of the same row
+	want := `[{"id":"new","email":"new@test.com","name":"New","role":"admin","active":true,"created_at":"2024-03-01T12:00:00Z","updated_at":"2024-03-01T13:00:00Z"}]`
+	if string(page.Data) != want {
+		t.Errorf("Expected %s, got %s", want, page.Data)
+	}
+
+	if _, err := h.services.Export.ListPage(ctx, "tags", models.ExportFilter{}); err == nil {
+		t.Error("Expected error for unknown resource")
+	}
+}
+
+func TestStreamFrom_ResumeToken(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, created := range []time.Time{base, base.Add(time.Second), base.Add(time.Second), base.Add(2 * time.Second)} {
+		id := fmt.Sprintf("u%d", i+1)
+		h.userRepo.Create(ctx, &models.User{ID: id, Email: id + "@test.com", Name: id, Role: "viewer", CreatedAt: created, UpdatedAt: created})
+	}
+
+	stream := func(after models.PageCursor) (string, string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		if err := h.services.Export.StreamFrom(ctx, w, "users", "ndjson", after); err != nil {
+			t.Fatalf("StreamFrom returned error: %v", err)
+		}
+		resp := w.Result()
+		if resp.Header.Get("Trailer") != service.ResumeTokenHeader {
+			t.Errorf("Expected %s declared as a trailer, got %q", service.ResumeTokenHeader, resp.Header.Get("Trailer"))
+		}
+		return w.Body.String(), resp.Trailer.Get(service.ResumeTokenHeader)
+	}
+
+	// Resume after u2: u3 shares its created_at and must not be skipped
+	body, token := stream(models.PageCursor{CreatedAt: base.Add(time.Second), ID: "u2"})
+	if strings.Count(body, "\n") != 2 || !strings.Contains(body, `"id":"u3"`) || !strings.Contains(body, `"id":"u4"`) {
+		t.Errorf("Expected u3 and u4 after the resume point, got %s", body)
+	}
+	last, err := service.DecodePageCursor(token)
+	if err != nil || last.ID != "u4" || !last.CreatedAt.Equal(base.Add(2*time.Second)) {
+		t.Fatalf("Expected a resume token at u4, got %q (%v)", token, err)
+	}
+
+	// Resuming at the end writes nothing and hands the same position back
+	body, again := stream(last)
+	if body != "" || again != token {
+		t.Errorf("Expected an empty export with the same token, got %q and %q", body, again)
+	}
+
+	// Formats that cannot be appended to have no resume token
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamUsers(ctx, w, "csv"); err != nil {
+		t.Fatalf("StreamUsers returned error: %v", err)
+	}
+	if resp := w.Result(); resp.Header.Get("Trailer") != "" || resp.Trailer.Get(service.ResumeTokenHeader) != "" {
+		t.Errorf("Expected no resume token for csv, got %q", resp.Trailer.Get(service.ResumeTokenHeader))
+	}
+	if err := h.services.Export.StreamFrom(ctx, httptest.NewRecorder(), "users", "csv", last); err == nil {
+		t.Error("Expected error resuming a csv export")
+	}
+}
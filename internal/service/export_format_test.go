@@ -0,0 +1,239 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/parquet-go/parquet-go"
+)
+
+// --- Parquet Export Tests ---
+
+func TestStreamArticles_Parquet(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	created := time.Date(2024, 1, 5, 10, 0, 0, 123456000, time.UTC)
+	published := created.Add(time.Hour)
+	h.articleRepo.Create(ctx, &models.Article{
+		ID:          "6f9619ff-8b86-d011-b42d-00cf4fc964ff",
+		Slug:        "hello-world",
+		Title:       "Hello World",
+		Body:        "body",
+		AuthorID:    "550e8400-e29b-41d4-a716-446655440000",
+		Tags:        []string{"go", "parquet"},
+		Status:      "published",
+		PublishedAt: &published,
+		CreatedAt:   created,
+		UpdatedAt:   created,
+	})
+
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamArticles(ctx, w, "parquet"); err != nil {
+		t.Fatalf("StreamArticles returned error: %v", err)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/vnd.apache.parquet" {
+		t.Errorf("Expected parquet content type, got %s", ct)
+	}
+
+	data := w.Body.Bytes()
+	reader := parquet.NewGenericReader[models.Article](bytes.NewReader(data))
+	defer reader.Close()
+
+	if reader.NumRows() != 1 {
+		t.Fatalf("Expected 1 row, got %d", reader.NumRows())
+	}
+
+	rows := make([]models.Article, 1)
+	if n, _ := reader.Read(rows); n != 1 {
+		t.Fatalf("Expected to read 1 row, got %d", n)
+	}
+
+	got := rows[0]
+	if got.Slug != "hello-world" {
+		t.Errorf("Expected slug 'hello-world', got '%s'", got.Slug)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "go" || got.Tags[1] != "parquet" {
+		t.Errorf("Expected tags [go parquet], got %v", got.Tags)
+	}
+	if !got.CreatedAt.Equal(created) {
+		t.Errorf("Expected created_at %v, got %v", created, got.CreatedAt)
+	}
+	if got.PublishedAt == nil || !got.PublishedAt.Equal(published) {
+		t.Errorf("Expected published_at %v, got %v", published, got.PublishedAt)
+	}
+
+	// Verify the logical types consumers rely on
+	schema := reader.Schema()
+	for _, col := range []string{"created_at", "updated_at", "published_at"} {
+		field, ok := schema.Lookup(col)
+		if !ok {
+			t.Fatalf("Column %s missing from schema", col)
+		}
+		lt := field.Node.Type().LogicalType()
+		if lt == nil || !strings.Contains(lt.String(), "TIMESTAMP") || !strings.Contains(lt.String(), "MICROS") {
+			t.Errorf("Column %s should be TIMESTAMP_MICROS, got %v", col, lt)
+		}
+	}
+}
+
+func TestStreamUsers_Parquet_MultipleRowGroups(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	const total = 25000
+	for i := 0; i < total; i++ {
+		h.userRepo.Create(ctx, &models.User{
+			ID:        "550e8400-e29b-41d4-a716-" + padInt(i, 12),
+			Email:     "user" + padInt(i, 6) + "@test.com",
+			Name:      "User",
+			Role:      "viewer",
+			Active:    i%2 == 0,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+	}
+
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamUsers(ctx, w, "parquet"); err != nil {
+		t.Fatalf("StreamUsers returned error: %v", err)
+	}
+
+	file, err := parquet.OpenFile(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open parquet output: %v", err)
+	}
+	if file.NumRows() != total {
+		t.Errorf("Expected %d rows, got %d", total, file.NumRows())
+	}
+	if len(file.RowGroups()) != 3 {
+		t.Errorf("Expected 3 row groups of at most 10000 rows, got %d", len(file.RowGroups()))
+	}
+}
+
+// --- Arrow IPC Export Tests ---
+
+func TestStreamArticles_Arrow(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	created := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)
+	published := created.Add(time.Hour)
+	h.articleRepo.Create(ctx, &models.Article{
+		ID: "6f9619ff-8b86-d011-b42d-00cf4fc964ff", Slug: "published-post", Title: "Published",
+		Body: "body", AuthorID: "550e8400-e29b-41d4-a716-446655440000", Tags: []string{"a", "b"},
+		Status: "published", PublishedAt: &published, CreatedAt: created, UpdatedAt: created,
+	})
+
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamArticles(ctx, w, "arrow"); err != nil {
+		t.Fatalf("StreamArticles returned error: %v", err)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/vnd.apache.arrow.stream" {
+		t.Errorf("Expected arrow stream content type, got %s", ct)
+	}
+
+	reader, err := ipc.NewReader(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to open arrow stream: %v", err)
+	}
+	defer reader.Release()
+
+	schema := reader.Schema()
+	if schema.NumFields() != 10 || schema.Field(5).Name != "tags" {
+		t.Errorf("Unexpected articles schema: %v", schema)
+	}
+
+	if !reader.Next() {
+		t.Fatal("Expected one record batch")
+	}
+	rec := reader.RecordBatch()
+	if rec.NumRows() != 1 {
+		t.Fatalf("Expected 1 row, got %d", rec.NumRows())
+	}
+
+	tags := rec.Column(5).(*array.List)
+	start, end := tags.ValueOffsets(0)
+	if end-start != 2 {
+		t.Errorf("Expected 2 tags, got %d", end-start)
+	}
+
+	publishedCol := rec.Column(7).(*array.Timestamp)
+	if publishedCol.IsNull(0) {
+		t.Fatal("published_at should not be null")
+	}
+	if got := publishedCol.Value(0).ToTime(arrow.Microsecond); !got.Equal(published) {
+		t.Errorf("Expected published_at %v, got %v", published, got)
+	}
+}
+
+func TestStreamComments_Arrow_Batches(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	const total = 20000
+	for i := 0; i < total; i++ {
+		h.commentRepo.Create(ctx, &models.Comment{
+			ID:        "550e8400-e29b-41d4-a716-" + padInt(i, 12),
+			ArticleID: "6f9619ff-8b86-d011-b42d-00cf4fc964ff",
+			UserID:    "550e8400-e29b-41d4-a716-446655440000",
+			Body:      "comment",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+	}
+
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamComments(ctx, w, "arrow"); err != nil {
+		t.Fatalf("StreamComments returned error: %v", err)
+	}
+
+	reader, err := ipc.NewReader(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to open arrow stream: %v", err)
+	}
+	defer reader.Release()
+
+	batches, rows := 0, int64(0)
+	for reader.Next() {
+		batches++
+		rows += reader.RecordBatch().NumRows()
+	}
+	if rows != total {
+		t.Errorf("Expected %d rows, got %d", total, rows)
+	}
+	if batches != 3 {
+		t.Errorf("Expected 3 record batches, got %d", batches)
+	}
+}
+
+func TestStreamUsers_Arrow_Empty(t *testing.T) {
+	h := newTestHarness(t)
+
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamUsers(context.Background(), w, "arrow"); err != nil {
+		t.Fatalf("StreamUsers returned error: %v", err)
+	}
+
+	reader, err := ipc.NewReader(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Empty export should still be a valid stream: %v", err)
+	}
+	defer reader.Release()
+
+	if reader.Schema().NumFields() != 7 {
+		t.Errorf("Expected 7 user fields, got %d", reader.Schema().NumFields())
+	}
+	if reader.Next() {
+		t.Error("Expected no record batches")
+	}
+}
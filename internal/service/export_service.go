@@ -1,261 +1,611 @@
 package service
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
-	"encoding/csv"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/bulk-import-export-api/internal/format"
 	"github.com/bulk-import-export-api/internal/models"
 	"github.com/bulk-import-export-api/internal/repository"
+	"github.com/bulk-import-export-api/internal/storage"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
+// ErrInvalidExport is returned for an export job that cannot be run
+var ErrInvalidExport = errors.New("invalid export")
+
 // exportService is the concrete implementation of ExportService
 type exportService struct {
 	repos *repository.Repositories
+	blobs storage.Blob
 	log   zerolog.Logger
 }
 
 // newExportService creates a new ExportService
-func newExportService(repos *repository.Repositories, log zerolog.Logger) *exportService {
+func newExportService(repos *repository.Repositories, blobs storage.Blob, log zerolog.Logger) *exportService {
 	return &exportService{
 		repos: repos,
+		blobs: blobs,
 		log:   log.With().Str("service", "export").Logger(),
 	}
 }
 
-// StreamUsers streams users in the specified format
-func (s *exportService) StreamUsers(ctx context.Context, w http.ResponseWriter, format string) error {
-	s.log.Info().Str("format", format).Msg("Starting users export")
-
-	switch format {
-	case "ndjson":
-		return s.streamUsersNDJSON(ctx, w)
-	case "json":
-		return s.streamUsersJSON(ctx, w)
-	case "csv":
-		return s.streamUsersCSV(ctx, w)
-	default:
-		return fmt.Errorf("unsupported format: %s", format)
+// CreateExportJob queues an export of resource to an object store
+// destination. A destination ending in "/" is a prefix: the object is
+// named after the resource and job.
+func (s *exportService) CreateExportJob(ctx context.Context, req *models.ExportRequest) (*models.Job, error) {
+	if _, ok := resourceSchemas[req.Resource]; !ok {
+		return nil, fmt.Errorf("%w: unknown resource: %s", ErrInvalidExport, req.Resource)
+	}
+	f, ok := format.Lookup(req.Format)
+	if !ok || f.NewEncoder == nil {
+		return nil, fmt.Errorf("%w: unsupported format: %s", ErrInvalidExport, req.Format)
+	}
+	if !storage.IsS3(req.Destination) {
+		return nil, fmt.Errorf("%w: destination must be an s3:// URL", ErrInvalidExport)
 	}
-}
 
-// StreamArticles streams articles in the specified format
-func (s *exportService) StreamArticles(ctx context.Context, w http.ResponseWriter, format string) error {
-	s.log.Info().Str("format", format).Msg("Starting articles export")
-
-	switch format {
-	case "ndjson":
-		return s.streamArticlesNDJSON(ctx, w)
-	case "json":
-		return s.streamArticlesJSON(ctx, w)
-	default:
-		return fmt.Errorf("unsupported format: %s", format)
+	id := uuid.New().String()
+	destination := req.Destination
+	if strings.HasSuffix(destination, "/") {
+		destination += req.Resource + "_" + id[:8] + f.Extension()
 	}
+	if _, _, err := storage.ParseS3(destination); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+
+	job := &models.Job{
+		ID:             id,
+		Type:           models.JobTypeExport,
+		Resource:       req.Resource,
+		Status:         models.JobStatusPending,
+		IdempotencyKey: req.IdempotencyKey,
+		FilePath:       destination,
+		Options:        &models.ImportOptions{Format: f.Name},
+		CreatedAt:      time.Now(),
+	}
+	if err := s.repos.Job.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	s.log.Info().
+		Str("job_id", job.ID).
+		Str("resource", job.Resource).
+		Str("destination", destination).
+		Msg("Export job created")
+
+	return job, nil
 }
 
-// StreamComments streams comments in the specified format
-func (s *exportService) StreamComments(ctx context.Context, w http.ResponseWriter, format string) error {
-	s.log.Info().Str("format", format).Msg("Starting comments export")
-
-	switch format {
-	case "ndjson":
-		return s.streamCommentsNDJSON(ctx, w)
-	case "json":
-		return s.streamCommentsJSON(ctx, w)
-	default:
-		return fmt.Errorf("unsupported format: %s", format)
+// ProcessExport encodes an export job's resource straight into its
+// destination. The encoder writes into a pipe that storage uploads part by
+// part, so the export is never held whole in memory or on disk.
+func (s *exportService) ProcessExport(ctx context.Context, job *models.Job) error {
+	startTime := time.Now()
+	job.Status = models.JobStatusProcessing
+	job.StartedAt = &startTime
+	s.repos.Job.Update(ctx, job)
+
+	count, err := s.exportTo(ctx, job)
+
+	job.TotalRecords = count
+	job.ProcessedCount = count
+	job.SuccessfulCount = count
+	duration := time.Since(startTime)
+	job.DurationMs = duration.Milliseconds()
+	if count > 0 && duration.Seconds() > 0 {
+		job.RowsPerSec = float64(count) / duration.Seconds()
+	}
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+
+	if err != nil {
+		job.Status = models.JobStatusFailed
+		s.log.Error().Err(err).Str("job_id", job.ID).Msg("Export failed")
+	} else {
+		job.Status = models.JobStatusCompleted
+		job.DownloadURL = job.FilePath
+		s.log.Info().
+			Str("job_id", job.ID).
+			Str("destination", job.FilePath).
+			Int("count", count).
+			Int64("duration_ms", job.DurationMs).
+			Msg("Export completed")
 	}
+
+	s.repos.Job.Update(ctx, job)
+	return err
 }
 
-// Users streaming implementations
+// exportTo writes the job's resource to its destination and returns the
+// number of records written
+func (s *exportService) exportTo(ctx context.Context, job *models.Job) (int, error) {
+	formatName := ""
+	if job.Options != nil {
+		formatName = job.Options.Format
+	}
+	f, ok := format.Lookup(formatName)
+	if !ok || f.NewEncoder == nil {
+		return 0, fmt.Errorf("unsupported format: %s", formatName)
+	}
+	schema, produce, err := s.filtered(ctx, job.Resource, models.ExportFilter{})
+	if err != nil {
+		return 0, err
+	}
 
-func (s *exportService) streamUsersNDJSON(ctx context.Context, w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/x-ndjson")
-	w.Header().Set("Content-Disposition", "attachment; filename=users.ndjson")
+	pr, pw := io.Pipe()
+	var count int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var encErr error
+		count, encErr = encodeRecords(pw, f, schema, produce, func() error { return nil })
+		// A failed encode fails the upload too, so no partial object is kept
+		pw.CloseWithError(encErr)
+	}()
+
+	err = s.blobs.Put(ctx, job.FilePath, pr)
+	// Unblock the encoder if the upload stopped reading early
+	pr.CloseWithError(io.ErrClosedPipe)
+	<-done
+	return count, err
+}
 
-	flusher, _ := w.(http.Flusher)
-	count := 0
+// StreamUsers streams users in the specified format
+func (s *exportService) StreamUsers(ctx context.Context, w http.ResponseWriter, formatName string) error {
+	return s.stream(w, formatName, userSchema, &models.PageCursor{}, func(emit func(format.Record) error) error {
+		return s.repos.User.StreamAll(ctx, func(user *models.User) error {
+			return emit(userRecord(user))
+		})
+	})
+}
 
-	err := s.repos.User.StreamAll(ctx, func(user *models.User) error {
-		data, err := json.Marshal(user)
-		if err != nil {
-			return err
-		}
-		w.Write(data)
-		w.Write([]byte("\n"))
-		count++
+// StreamArticles streams articles in the specified format
+func (s *exportService) StreamArticles(ctx context.Context, w http.ResponseWriter, formatName string) error {
+	return s.stream(w, formatName, articleSchema, &models.PageCursor{}, func(emit func(format.Record) error) error {
+		return s.repos.Article.StreamAll(ctx, func(article *models.Article) error {
+			return emit(articleRecord(article))
+		})
+	})
+}
 
-		// Flush every 100 records for streaming
-		if count%100 == 0 && flusher != nil {
-			flusher.Flush()
-		}
-		return nil
+// StreamComments streams comments in the specified format
+func (s *exportService) StreamComments(ctx context.Context, w http.ResponseWriter, formatName string) error {
+	return s.stream(w, formatName, commentSchema, &models.PageCursor{}, func(emit func(format.Record) error) error {
+		return s.repos.Comment.StreamAll(ctx, func(comment *models.Comment) error {
+			return emit(commentRecord(comment))
+		})
 	})
+}
 
-	s.log.Info().Int("count", count).Msg("Users export completed")
-	return err
+// Resumable reports whether an interrupted export in formatName can be
+// continued with a resume token. Only NDJSON writes each record as it goes
+// and can be appended to; CSV would repeat its header, and the other
+// formats are self-contained files that buffer rows.
+func Resumable(formatName string) bool {
+	return formatName == "ndjson"
 }
 
-func (s *exportService) streamUsersJSON(ctx context.Context, w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Content-Disposition", "attachment; filename=users.json")
+// StreamFrom streams a resource in (created_at, id) order starting strictly
+// after a resume token's position, continuing an interrupted full export
+func (s *exportService) StreamFrom(ctx context.Context, w http.ResponseWriter, resource, formatName string, after models.PageCursor) error {
+	if !Resumable(formatName) {
+		return fmt.Errorf("format %s cannot be resumed", formatName)
+	}
+	schema, produce, err := s.filtered(ctx, resource, models.ExportFilter{After: &after})
+	if err != nil {
+		return err
+	}
+	return s.stream(w, formatName, schema, &after, produce)
+}
 
-	w.Write([]byte("["))
-	first := true
+// stream drains a StreamAll-style producer through the encoder registered
+// for formatName, flushing every 100 records so clients receive data while
+// the export runs (formats that buffer row groups flush on their own).
+//
+// A non-nil resume marks an export in (created_at, id) order starting after
+// that position: for a resumable format, the position of the last row
+// written is then sent in the X-Resume-Token trailer, even if the export
+// fails part way.
+func (s *exportService) stream(w http.ResponseWriter, formatName string, schema *format.Schema, resume *models.PageCursor, produce func(emit func(format.Record) error) error) error {
+	s.log.Info().Str("resource", schema.Name).Str("format", formatName).Msg("Starting export")
+
+	f, ok := format.Lookup(formatName)
+	if !ok || f.NewEncoder == nil {
+		return fmt.Errorf("unsupported format: %s", formatName)
+	}
 
-	err := s.repos.User.StreamAll(ctx, func(user *models.User) error {
-		if !first {
-			w.Write([]byte(","))
+	w.Header().Set("Content-Type", f.MIMEType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+schema.Name+f.Extension())
+
+	if resume != nil && Resumable(f.Name) {
+		w.Header().Set("Trailer", ResumeTokenHeader)
+		last := *resume
+		defer func() {
+			if last != (models.PageCursor{}) {
+				w.Header().Set(ResumeTokenHeader, EncodePageCursor(last))
+			}
+		}()
+		inner := produce
+		produce = func(emit func(format.Record) error) error {
+			return inner(func(rec format.Record) error {
+				if err := emit(rec); err != nil {
+					return err
+				}
+				last = models.PageCursor{CreatedAt: rec["created_at"].(time.Time), ID: rec["id"].(string)}
+				return nil
+			})
 		}
-		first = false
+	}
 
-		data, err := json.Marshal(user)
-		if err != nil {
-			return err
+	flusher, _ := w.(http.Flusher)
+	count, err := encodeRecords(w, f, schema, produce, func() error {
+		if flusher != nil {
+			flusher.Flush()
 		}
-		w.Write(data)
 		return nil
 	})
 
-	w.Write([]byte("]"))
+	s.log.Info().Str("resource", schema.Name).Int("count", count).Msg("Export completed")
 	return err
 }
 
-func (s *exportService) streamUsersCSV(ctx context.Context, w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "text/csv")
-	w.Header().Set("Content-Disposition", "attachment; filename=users.csv")
-
-	writer := csv.NewWriter(w)
-	defer writer.Flush()
+// encodeRecords writes every record from produce to w with f's encoder,
+// calling flush after the encoder every 100 records, and returns the number
+// of records written
+func encodeRecords(w io.Writer, f *format.Format, schema *format.Schema, produce func(emit func(format.Record) error) error, flush func() error) (int, error) {
+	encoder, err := f.NewEncoder(w, schema)
+	if err != nil {
+		return 0, err
+	}
 
-	// Write header
-	writer.Write([]string{"id", "email", "name", "role", "active", "created_at", "updated_at"})
+	count := 0
+	err = produce(func(rec format.Record) error {
+		if err := encoder.Encode(rec); err != nil {
+			return err
+		}
+		count++
 
-	return s.repos.User.StreamAll(ctx, func(user *models.User) error {
-		active := "false"
-		if user.Active {
-			active = "true"
+		// Flush every 100 records for streaming
+		if count%100 == 0 {
+			if err := encoder.Flush(); err != nil {
+				return err
+			}
+			return flush()
 		}
-		return writer.Write([]string{
-			user.ID,
-			user.Email,
-			user.Name,
-			user.Role,
-			active,
-			user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-		})
+		return nil
 	})
+
+	// Always close to release encoder resources, but report the producer's
+	// error first
+	if closeErr := encoder.Close(); err == nil {
+		err = closeErr
+	}
+	return count, err
 }
 
-// Articles streaming implementations
+// StreamBundle streams a zip backup of users, articles and comments read
+// from one REPEATABLE READ snapshot, followed by a manifest.json recording
+// each file's row count and SHA-256. The archive is the bundle import
+// format, so uploading it with resource=bundle restores it after the
+// manifest is verified. If the export fails part way the zip is left
+// without its central directory, so a truncated backup cannot be opened.
+func (s *exportService) StreamBundle(ctx context.Context, w http.ResponseWriter, formatName string) error {
+	f, ok := format.Lookup(formatName)
+	if !ok || f.NewEncoder == nil {
+		return fmt.Errorf("unsupported format: %s", formatName)
+	}
+
+	snapshotAt := time.Now().UTC()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=backup-"+snapshotAt.Format("20060102T150405Z")+".zip")
 
-func (s *exportService) streamArticlesNDJSON(ctx context.Context, w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/x-ndjson")
-	w.Header().Set("Content-Disposition", "attachment; filename=articles.ndjson")
+	s.log.Info().Str("format", formatName).Msg("Starting bundle export")
 
+	zw := zip.NewWriter(w)
 	flusher, _ := w.(http.Flusher)
-	count := 0
-
-	err := s.repos.Article.StreamAll(ctx, func(article *models.Article) error {
-		data, err := json.Marshal(article)
-		if err != nil {
+	flush := func() error {
+		if err := zw.Flush(); err != nil {
 			return err
 		}
-		w.Write(data)
-		w.Write([]byte("\n"))
-		count++
-
-		if count%100 == 0 && flusher != nil {
+		if flusher != nil {
 			flusher.Flush()
 		}
 		return nil
+	}
+
+	manifest := bundleManifest{Version: 1, SnapshotAt: &snapshotAt}
+
+	err := s.repos.Snapshot.WithSnapshot(ctx, func(snap repository.Snapshot) error {
+		parts := []struct {
+			schema  *format.Schema
+			produce func(emit func(format.Record) error) error
+		}{
+			{userSchema, func(emit func(format.Record) error) error {
+				return snap.StreamUsers(ctx, func(u *models.User) error { return emit(userRecord(u)) })
+			}},
+			{articleSchema, func(emit func(format.Record) error) error {
+				return snap.StreamArticles(ctx, func(a *models.Article) error { return emit(articleRecord(a)) })
+			}},
+			{commentSchema, func(emit func(format.Record) error) error {
+				return snap.StreamComments(ctx, func(c *models.Comment) error { return emit(commentRecord(c)) })
+			}},
+		}
+
+		for _, part := range parts {
+			name := part.schema.Name + f.Extension()
+			entry, err := zw.Create(name)
+			if err != nil {
+				return err
+			}
+
+			hash := sha256.New()
+			rows, err := encodeRecords(io.MultiWriter(entry, hash), f, part.schema, part.produce, flush)
+			if err != nil {
+				return fmt.Errorf("%s: %w", part.schema.Name, err)
+			}
+
+			manifest.Files = append(manifest.Files, bundleFile{
+				Resource: part.schema.Name,
+				Path:     name,
+				Format:   f.Name,
+				Rows:     &rows,
+				SHA256:   hex.EncodeToString(hash.Sum(nil)),
+			})
+			s.log.Info().Str("resource", part.schema.Name).Int("count", rows).Msg("Bundle part exported")
+		}
+		return nil
 	})
+	if err != nil {
+		return err
+	}
 
-	s.log.Info().Int("count", count).Msg("Articles export completed")
-	return err
+	entry, err := zw.Create(bundleManifestName)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(entry)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	s.log.Info().Int("files", len(manifest.Files)).Msg("Bundle export completed")
+	return nil
 }
 
-func (s *exportService) streamArticlesJSON(ctx context.Context, w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Content-Disposition", "attachment; filename=articles.json")
+// Incremental export response headers. The next cursor is fixed before the
+// body is written, so it can be sent as a header rather than a trailer.
+const (
+	NextCursorHeader = "X-Next-Cursor"
+	WatermarkHeader  = "X-Watermark"
+)
+
+// ResumeTokenHeader is the trailer of full exports holding the position of
+// the last row written; pass it back as ?resume= to continue after it
+const ResumeTokenHeader = "X-Resume-Token"
 
-	w.Write([]byte("["))
-	first := true
+// ErrInvalidCursor is returned for a change cursor that cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
 
-	err := s.repos.Article.StreamAll(ctx, func(article *models.Article) error {
-		if !first {
-			w.Write([]byte(","))
-		}
-		first = false
+// EncodeChangeCursor returns the opaque token clients pass back as ?cursor=
+func EncodeChangeCursor(c models.ChangeCursor) string {
+	return encodeToken(c)
+}
 
-		data, err := json.Marshal(article)
-		if err != nil {
-			return err
-		}
-		w.Write(data)
-		return nil
-	})
+// DecodeChangeCursor parses a token returned in the X-Next-Cursor header
+func DecodeChangeCursor(token string) (models.ChangeCursor, error) {
+	var c models.ChangeCursor
+	return c, decodeToken(token, &c)
+}
 
-	w.Write([]byte("]"))
-	return err
+// EncodePageCursor returns the opaque next_cursor of a resource page
+func EncodePageCursor(c models.PageCursor) string {
+	return encodeToken(c)
 }
 
-// Comments streaming implementations
+// DecodePageCursor parses a next_cursor returned by the resource page API
+func DecodePageCursor(token string) (models.PageCursor, error) {
+	var c models.PageCursor
+	return c, decodeToken(token, &c)
+}
 
-func (s *exportService) streamCommentsNDJSON(ctx context.Context, w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/x-ndjson")
-	w.Header().Set("Content-Disposition", "attachment; filename=comments.ndjson")
+// Cursors are URL-safe base64 of their JSON, opaque to clients
+func encodeToken(v any) string {
+	data, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
 
-	flusher, _ := w.(http.Flusher)
-	count := 0
+func decodeToken(token string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
 
-	err := s.repos.Comment.StreamAll(ctx, func(comment *models.Comment) error {
-		data, err := json.Marshal(comment)
-		if err != nil {
-			return err
+// StreamChanges streams the rows of a resource updated after the cursor, in
+// (updated_at, id) order. The upper bound is the latest change when the
+// export starts: rows written while it runs are left for the next sync, and
+// the cursor to resume from is known before streaming and sent as a header.
+func (s *exportService) StreamChanges(ctx context.Context, w http.ResponseWriter, resource, formatName string, after models.ChangeCursor) error {
+	var (
+		schema *format.Schema
+		latest func(ctx context.Context) (*models.ChangeCursor, error)
+		stream func(until models.ChangeCursor, emit func(format.Record) error) error
+	)
+
+	switch resource {
+	case "users":
+		schema, latest = userSchema, s.repos.User.LatestChange
+		stream = func(until models.ChangeCursor, emit func(format.Record) error) error {
+			return s.repos.User.StreamChanges(ctx, after, until, func(user *models.User) error {
+				return emit(userRecord(user))
+			})
 		}
-		w.Write(data)
-		w.Write([]byte("\n"))
-		count++
+	case "articles":
+		schema, latest = articleSchema, s.repos.Article.LatestChange
+		stream = func(until models.ChangeCursor, emit func(format.Record) error) error {
+			return s.repos.Article.StreamChanges(ctx, after, until, func(article *models.Article) error {
+				return emit(articleRecord(article))
+			})
+		}
+	case "comments":
+		schema, latest = commentSchema, s.repos.Comment.LatestChange
+		stream = func(until models.ChangeCursor, emit func(format.Record) error) error {
+			return s.repos.Comment.StreamChanges(ctx, after, until, func(comment *models.Comment) error {
+				return emit(commentRecord(comment))
+			})
+		}
+	default:
+		return fmt.Errorf("unknown resource: %s", resource)
+	}
 
-		if count%100 == 0 && flusher != nil {
-			flusher.Flush()
+	horizon, err := s.changeHorizon(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Nothing changed: hand the same cursor back
+	until := after
+	last, err := latest(ctx)
+	if err != nil {
+		return err
+	}
+	if last != nil && after.Less(*last) {
+		until = *last
+	}
+	if horizon.Less(until) {
+		until = horizon
+		if !after.Less(until) {
+			until = after
 		}
-		return nil
+	}
+
+	w.Header().Set(NextCursorHeader, EncodeChangeCursor(until))
+	if !until.UpdatedAt.IsZero() {
+		w.Header().Set(WatermarkHeader, until.UpdatedAt.UTC().Format(time.RFC3339Nano))
+	}
+
+	return s.stream(w, formatName, schema, nil, func(emit func(format.Record) error) error {
+		if !after.Less(until) {
+			return nil
+		}
+		return stream(until, emit)
 	})
+}
 
-	s.log.Info().Int("count", count).Msg("Comments export completed")
-	return err
+// changeHorizon is the latest position an incremental export may hand out.
+// Imports stamp updated_at before their batch commits, so a running import
+// can still commit rows older than the latest visible change; rows at or
+// after the oldest running import's start, or after now for imports yet to
+// start, are left for the next sync. The horizon sorts before every row
+// stamped at its time.
+func (s *exportService) changeHorizon(ctx context.Context) (models.ChangeCursor, error) {
+	horizon := models.ChangeCursor{UpdatedAt: time.Now()}
+	started, err := s.repos.Job.OldestRunningImport(ctx)
+	if err != nil {
+		return horizon, err
+	}
+	if started != nil && started.Before(horizon.UpdatedAt) {
+		horizon.UpdatedAt = *started
+	}
+	return horizon, nil
 }
 
-func (s *exportService) streamCommentsJSON(ctx context.Context, w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Content-Disposition", "attachment; filename=comments.json")
+// Resource page sizes
+const (
+	DefaultPageLimit = 1000
+	MaxPageLimit     = 10000
+)
+
+// ListPage returns one page of a resource in (created_at, id) order, for
+// clients that cannot hold a stream open. Rows are encoded as a format=json
+// export would write them, and next_cursor is set while more rows follow.
+func (s *exportService) ListPage(ctx context.Context, resource string, filter models.ExportFilter) (*models.ResourcePage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	// One extra row tells whether another page follows
+	filter.Limit = limit + 1
 
-	w.Write([]byte("["))
-	first := true
+	schema, produce, err := s.filtered(ctx, resource, filter)
+	if err != nil {
+		return nil, err
+	}
 
-	err := s.repos.Comment.StreamAll(ctx, func(comment *models.Comment) error {
-		if !first {
-			w.Write([]byte(","))
-		}
-		first = false
+	f, _ := format.Lookup("json")
+	var buf bytes.Buffer
+	page := &models.ResourcePage{Resource: resource, Limit: limit}
+	var last models.PageCursor
+	more := false
+
+	_, err = encodeRecords(&buf, f, schema, func(emit func(format.Record) error) error {
+		return produce(func(rec format.Record) error {
+			if page.Count == limit {
+				more = true
+				return nil
+			}
+			page.Count++
+			last = models.PageCursor{CreatedAt: rec["created_at"].(time.Time), ID: rec["id"].(string)}
+			return emit(rec)
+		})
+	}, func() error { return nil })
+	if err != nil {
+		return nil, err
+	}
 
-		data, err := json.Marshal(comment)
-		if err != nil {
-			return err
-		}
-		w.Write(data)
-		return nil
-	})
+	page.Data = buf.Bytes()
+	if more {
+		page.NextCursor = EncodePageCursor(last)
+	}
+	return page, nil
+}
 
-	w.Write([]byte("]"))
-	return err
+// filtered returns the schema of a resource and a producer of its rows
+// matching filter
+func (s *exportService) filtered(ctx context.Context, resource string, filter models.ExportFilter) (*format.Schema, func(emit func(format.Record) error) error, error) {
+	switch resource {
+	case "users":
+		return userSchema, func(emit func(format.Record) error) error {
+			return s.repos.User.StreamFiltered(ctx, filter, func(user *models.User) error {
+				return emit(userRecord(user))
+			})
+		}, nil
+	case "articles":
+		return articleSchema, func(emit func(format.Record) error) error {
+			return s.repos.Article.StreamFiltered(ctx, filter, func(article *models.Article) error {
+				return emit(articleRecord(article))
+			})
+		}, nil
+	case "comments":
+		return commentSchema, func(emit func(format.Record) error) error {
+			return s.repos.Comment.StreamFiltered(ctx, filter, func(comment *models.Comment) error {
+				return emit(commentRecord(comment))
+			})
+		}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown resource: %s", resource)
+	}
 }
 
 // GetCount returns count for a resource
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/repository"
+	"github.com/bulk-import-export-api/internal/validation"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidExternalIDQuery is returned for a mapping query that names no
+// resource, source or row
+var ErrInvalidExternalIDQuery = errors.New("invalid external ID query")
+
+// newValidator creates the validator for an import job, with the rules in
+// effect when the job starts. Jobs that name a source system accept its
+// identifiers wherever a UUID is expected.
+func (s *importService) newValidator(job *models.Job, times *validation.TimeParser) *validation.Validator {
+	validator := validation.NewValidator()
+	validator.SetRules(s.rules.Rules())
+	validator.SetTimeParser(times)
+	if job.Options != nil && job.Options.Source != "" {
+		validator.AllowExternalIDs()
+	}
+	return validator
+}
+
+// idMapper maps the non-UUID identifiers of one import onto UUIDs through
+// the external ID table. IDs that already are UUIDs are used as given.
+type idMapper struct {
+	repo   repository.ExternalIDRepository
+	source string
+}
+
+// idMapper returns the mapper for a job's source system
+func (s *importService) idMapper(job *models.Job) *idMapper {
+	source := models.DefaultSource
+	if job.Options != nil && job.Options.Source != "" {
+		source = job.Options.Source
+	}
+	return &idMapper{repo: s.repos.ExternalID, source: source}
+}
+
+func (m *idMapper) mapUsers(ctx context.Context, users []*models.User) (map[int][]validation.ValidationError, error) {
+	ids := make([]*string, len(users))
+	for i, user := range users {
+		ids[i] = &user.ID
+	}
+	return nil, m.assign(ctx, "users", ids)
+}
+
+func (m *idMapper) mapArticles(ctx context.Context, articles []*models.Article) (map[int][]validation.ValidationError, error) {
+	ids := make([]*string, len(articles))
+	authorIDs := make([]*string, len(articles))
+	for i, article := range articles {
+		ids[i] = &article.ID
+		authorIDs[i] = &article.AuthorID
+	}
+	if err := m.assign(ctx, "articles", ids); err != nil {
+		return nil, err
+	}
+	return m.resolve(ctx, "users", "author_id", authorIDs)
+}
+
+func (m *idMapper) mapComments(ctx context.Context, comments []*models.Comment) (map[int][]validation.ValidationError, error) {
+	ids := make([]*string, len(comments))
+	articleIDs := make([]*string, len(comments))
+	userIDs := make([]*string, len(comments))
+	for i, comment := range comments {
+		ids[i] = &comment.ID
+		articleIDs[i] = &comment.ArticleID
+		userIDs[i] = &comment.UserID
+	}
+	if err := m.assign(ctx, "comments", ids); err != nil {
+		return nil, err
+	}
+
+	errs, err := m.resolve(ctx, "articles", "article_id", articleIDs)
+	if err != nil {
+		return nil, err
+	}
+	userErrs, err := m.resolve(ctx, "users", "user_id", userIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range userErrs {
+		errs[i] = append(errs[i], e...)
+	}
+	return errs, nil
+}
+
+// assign replaces each external ID with the UUID mapped to it, minting
+// mappings for new ones. A row rejected later keeps its mapping, so a
+// corrected re-import gets the same UUID.
+func (m *idMapper) assign(ctx context.Context, resource string, ids []*string) error {
+	external := externalIDs(ids)
+	if len(external) == 0 {
+		return nil
+	}
+	mapped, err := m.repo.Assign(ctx, resource, m.source, external)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if internal, ok := mapped[*id]; ok {
+			*id = internal
+		}
+	}
+	return nil
+}
+
+// resolve replaces each external reference with the UUID mapped to it and
+// reports the references that have no mapping
+func (m *idMapper) resolve(ctx context.Context, resource, field string, refs []*string) (map[int][]validation.ValidationError, error) {
+	errs := make(map[int][]validation.ValidationError)
+	external := externalIDs(refs)
+	if len(external) == 0 {
+		return errs, nil
+	}
+	mapped, err := m.repo.Resolve(ctx, resource, m.source, external)
+	if err != nil {
+		return nil, err
+	}
+	for i, ref := range refs {
+		if isUUID(*ref) {
+			continue
+		}
+		internal, ok := mapped[*ref]
+		if !ok {
+			errs[i] = []validation.ValidationError{{
+				Field:   field,
+				Message: fmt.Sprintf("unknown external ID for source %s", m.source),
+				Value:   *ref,
+				Code:    validation.CodeUnknownExternalID,
+			}}
+			continue
+		}
+		*ref = internal
+	}
+	return errs, nil
+}
+
+// externalIDs returns the distinct non-UUID values of ids
+func externalIDs(ids []*string) []string {
+	seen := make(map[string]bool)
+	var external []string
+	for _, id := range ids {
+		if !isUUID(*id) && !seen[*id] {
+			seen[*id] = true
+			external = append(external, *id)
+		}
+	}
+	return external
+}
+
+func isUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil
+}
+
+// externalIDService is the concrete implementation of ExternalIDService
+type externalIDService struct {
+	repos *repository.Repositories
+}
+
+// newExternalIDService creates a new ExternalIDService
+func newExternalIDService(repos *repository.Repositories) *externalIDService {
+	return &externalIDService{repos: repos}
+}
+
+// List returns one page of mappings. A query by internal ID returns every
+// mapping of that row; otherwise a source is required and the page is in
+// external ID order, optionally narrowed to one external ID.
+func (s *externalIDService) List(ctx context.Context, filter models.ExternalIDFilter) (*models.ExternalIDPage, error) {
+	if _, ok := resourceSchemas[filter.Resource]; !ok {
+		return nil, fmt.Errorf("%w: unknown resource: %s", ErrInvalidExternalIDQuery, filter.Resource)
+	}
+	if filter.Source == "" && filter.InternalID == "" {
+		return nil, fmt.Errorf("%w: source or internal_id is required", ErrInvalidExternalIDQuery)
+	}
+	if filter.After != "" && filter.Source == "" {
+		return nil, fmt.Errorf("%w: cursor requires source", ErrInvalidExternalIDQuery)
+	}
+	if filter.InternalID != "" && !isUUID(filter.InternalID) {
+		return nil, fmt.Errorf("%w: internal_id must be a UUID", ErrInvalidExternalIDQuery)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	// One extra row tells whether another page follows
+	filter.Limit = limit + 1
+
+	mappings, err := s.repos.ExternalID.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ExternalIDPage{Resource: filter.Resource, Data: mappings, Limit: limit}
+	if len(mappings) > limit {
+		page.Data = mappings[:limit]
+		// Pages run in external ID order within one source
+		if filter.Source != "" {
+			page.NextCursor = EncodeExternalIDCursor(page.Data[limit-1].ExternalID)
+		}
+	}
+	page.Count = len(page.Data)
+	return page, nil
+}
+
+// EncodeExternalIDCursor returns the opaque next_cursor of a mapping page
+func EncodeExternalIDCursor(externalID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(externalID))
+}
+
+// DecodeExternalIDCursor parses a next_cursor returned by the mapping API
+func DecodeExternalIDCursor(token string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) == 0 {
+		return "", ErrInvalidCursor
+	}
+	return string(data), nil
+}
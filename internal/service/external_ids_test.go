@@ -0,0 +1,139 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+	"github.com/google/uuid"
+)
+
+const crmUsersNDJSON = `{"id":"crm-u1","email":"ada@example.com","name":"Ada","role":"admin","active":true,"created_at":"2024-01-01T00:00:00Z"}
+{"id":"crm-u2","email":"bob@example.com","name":"Bob","role":"viewer","active":true,"created_at":"2024-01-02T00:00:00Z"}
+`
+
+const crmArticlesNDJSON = `{"id":"crm-a1","slug":"first","title":"First","body":"b","author_id":"crm-u1","status":"draft"}
+{"id":"crm-a2","slug":"second","title":"Second","body":"b","author_id":"crm-u404","status":"draft"}
+`
+
+// streamAs imports body as resource from source and returns the results
+func streamAs(t *testing.T, h *testHarness, resource, source, body string) (*models.Job, []models.LineResult) {
+	t.Helper()
+	var results []models.LineResult
+	req := &models.ImportRequest{Resource: resource, Options: &models.ImportOptions{Source: source}}
+	job, err := h.services.Import.StreamImport(context.Background(), req, "ndjson", strings.NewReader(body),
+		func(r models.LineResult) error {
+			results = append(results, r)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+	return job, results
+}
+
+func TestImport_MintsUUIDsAndResolvesReferences(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	if job, _ := streamAs(t, h, "users", "crm", crmUsersNDJSON); job.SuccessfulCount != 2 {
+		t.Fatalf("Expected 2 users imported, got %d", job.SuccessfulCount)
+	}
+	ada := h.idRepo.Mappings["users/crm/crm-u1"]
+	if ada == nil {
+		t.Fatalf("Expected a mapping for crm-u1, got %v", h.idRepo.Mappings)
+	}
+	if _, err := uuid.Parse(ada.InternalID); err != nil || h.userRepo.Users[ada.InternalID] == nil {
+		t.Errorf("Expected crm-u1 stored under its minted UUID %q", ada.InternalID)
+	}
+
+	job, results := streamAs(t, h, "articles", "crm", crmArticlesNDJSON)
+	if job.SuccessfulCount != 1 || job.FailedCount != 1 {
+		t.Errorf("Expected 1 imported and 1 failed, got %d/%d", job.SuccessfulCount, job.FailedCount)
+	}
+	first := h.idRepo.Mappings["articles/crm/crm-a1"]
+	if first == nil || h.articleRepo.Articles[first.InternalID] == nil {
+		t.Fatal("Expected crm-a1 stored under its minted UUID")
+	}
+	if got := h.articleRepo.Articles[first.InternalID].AuthorID; got != ada.InternalID {
+		t.Errorf("Expected author_id resolved to %s, got %s", ada.InternalID, got)
+	}
+	if errs := results[1].Errors; len(errs) != 1 || errs[0].Field != "author_id" || errs[0].Message != "unknown external ID for source crm" {
+		t.Errorf("Expected line 2 rejected for its unknown author, got %+v", results[1])
+	}
+
+	// Mappings are per source: another system's crm-u1 is a new user
+	page, err := h.services.ExternalIDs.List(ctx, models.ExternalIDFilter{Resource: "users", InternalID: ada.InternalID})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if page.Count != 1 || page.Data[0].ExternalID != "crm-u1" || page.Data[0].Source != "crm" {
+		t.Errorf("Expected crm-u1 as the only mapping of %s, got %+v", ada.InternalID, page.Data)
+	}
+}
+
+func TestImport_CommentIDsNeedASource(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+	data := []byte(`{"id":"cm_1","article_id":"` + bundleArticleID + `","user_id":"` + bundleUserID + `","body":"nice","created_at":"2024-01-02T00:00:00Z"}` + "\n")
+
+	job := createTestJob(h, "comments", h.put(t, "comments.ndjson", data))
+	if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+	if job.SuccessfulCount != 0 || job.FailedCount != 1 {
+		t.Errorf("Expected cm_1 rejected without a source, got %d ok / %d failed", job.SuccessfulCount, job.FailedCount)
+	}
+
+	job = createTestJob(h, "comments", h.put(t, "comments.ndjson", data))
+	job.Options = &models.ImportOptions{Source: "legacy"}
+	if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+	mapping := h.idRepo.Mappings["comments/legacy/cm_1"]
+	if job.SuccessfulCount != 1 || mapping == nil || h.commentRepo.Comments[mapping.InternalID] == nil {
+		t.Errorf("Expected cm_1 stored under a UUID minted for source legacy, got %d ok, %v", job.SuccessfulCount, h.idRepo.Mappings)
+	}
+}
+
+func TestExternalIDService_List(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+	if _, err := h.idRepo.Assign(ctx, "users", "crm", []string{"c", "a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []string
+	filter := models.ExternalIDFilter{Resource: "users", Source: "crm", Limit: 2}
+	for {
+		page, err := h.services.ExternalIDs.List(ctx, filter)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		for _, m := range page.Data {
+			seen = append(seen, m.ExternalID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		if filter.After, err = service.DecodeExternalIDCursor(page.NextCursor); err != nil {
+			t.Fatalf("DecodeExternalIDCursor failed: %v", err)
+		}
+	}
+	if strings.Join(seen, ",") != "a,b,c" {
+		t.Errorf("Expected a,b,c across pages, got %v", seen)
+	}
+
+	for _, bad := range []models.ExternalIDFilter{
+		{Resource: "widgets", Source: "crm"},
+		{Resource: "users"},
+		{Resource: "users", InternalID: "not-a-uuid"},
+	} {
+		if _, err := h.services.ExternalIDs.List(ctx, bad); !errors.Is(err, service.ErrInvalidExternalIDQuery) {
+			t.Errorf("Expected ErrInvalidExternalIDQuery for %+v, got %v", bad, err)
+		}
+	}
+}
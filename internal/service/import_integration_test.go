@@ -1,10 +1,13 @@
 package service_test
 
 import (
+	"bytes"
 	"context"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,7 +16,10 @@ import (
 	"github.com/bulk-import-export-api/internal/models"
 	"github.com/bulk-import-export-api/internal/repository"
 	"github.com/bulk-import-export-api/internal/service"
+	"github.com/bulk-import-export-api/internal/storage"
+	"github.com/parquet-go/parquet-go"
 	"github.com/rs/zerolog"
+	"github.com/xuri/excelize/v2"
 )
 
 // testdataPath returns the absolute path to a file in the testdata directory.
@@ -33,10 +39,14 @@ func testdataPath(t testing.TB, filename string) string {
 
 type testHarness struct {
 	services    *service.Services
+	cfg         *config.Config
+	blobs       *storage.Memory
 	userRepo    *mocks.MockUserRepository
 	articleRepo *mocks.MockArticleRepository
 	commentRepo *mocks.MockCommentRepository
 	jobRepo     *mocks.MockJobRepository
+	uploadRepo  *mocks.MockUploadRepository
+	idRepo      *mocks.MockExternalIDRepository
 }
 
 func newTestHarness(t *testing.T) *testHarness {
@@ -46,12 +56,17 @@ func newTestHarness(t *testing.T) *testHarness {
 	articleRepo := mocks.NewMockArticleRepository()
 	commentRepo := mocks.NewMockCommentRepository()
 	jobRepo := mocks.NewMockJobRepository()
+	uploadRepo := mocks.NewMockUploadRepository()
+	idRepo := mocks.NewMockExternalIDRepository()
 
 	repos := &repository.Repositories{
-		User:    userRepo,
-		Article: articleRepo,
-		Comment: commentRepo,
-		Job:     jobRepo,
+		User:       userRepo,
+		Article:    articleRepo,
+		Comment:    commentRepo,
+		Job:        jobRepo,
+		Upload:     uploadRepo,
+		ExternalID: idRepo,
+		Snapshot:   mocks.NewMockSnapshotRepository(userRepo, articleRepo, commentRepo),
 	}
 
 	cfg := &config.Config{
@@ -63,17 +78,41 @@ func newTestHarness(t *testing.T) *testHarness {
 	}
 
 	log := zerolog.Nop()
-	services := service.NewServices(repos, cfg, log)
+	blobs := storage.NewMemory()
+	services := service.NewServices(repos, blobs, cfg, log)
 
 	return &testHarness{
 		services:    services,
+		cfg:         cfg,
+		blobs:       blobs,
 		userRepo:    userRepo,
 		articleRepo: articleRepo,
 		commentRepo: commentRepo,
 		jobRepo:     jobRepo,
+		uploadRepo:  uploadRepo,
+		idRepo:      idRepo,
 	}
 }
 
+// put stores data in the harness's artifact storage and returns its key
+func (h *testHarness) put(t testing.TB, key string, data []byte) string {
+	t.Helper()
+	if err := h.blobs.Put(context.Background(), key, bytes.NewReader(data)); err != nil {
+		t.Fatalf("Failed to store %s: %v", key, err)
+	}
+	return key
+}
+
+// testdata stores a file from the testdata directory under its name
+func (h *testHarness) testdata(t testing.TB, filename string) string {
+	t.Helper()
+	data, err := os.ReadFile(testdataPath(t, filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h.put(t, filename, data)
+}
+
 func createTestJob(h *testHarness, resource, filePath string) *models.Job {
 	now := time.Now()
 	job := &models.Job{
@@ -93,7 +132,7 @@ func createTestJob(h *testHarness, resource, filePath string) *models.Job {
 
 func TestProcessImport_UsersCSV_HugeFile(t *testing.T) {
 	h := newTestHarness(t)
-	filePath := testdataPath(t, "users_huge.csv")
+	filePath := h.testdata(t, "users_huge.csv")
 
 	job := createTestJob(h, "users", filePath)
 
@@ -169,7 +208,7 @@ func TestProcessImport_UsersCSV_HugeFile(t *testing.T) {
 
 func TestProcessImport_UsersCSV_ContinueOnError(t *testing.T) {
 	h := newTestHarness(t)
-	filePath := testdataPath(t, "users_huge.csv")
+	filePath := h.testdata(t, "users_huge.csv")
 
 	job := createTestJob(h, "users", filePath)
 
@@ -197,7 +236,7 @@ func TestProcessImport_UsersCSV_ContinueOnError(t *testing.T) {
 
 func TestProcessImport_UsersCSV_SpecificErrors(t *testing.T) {
 	h := newTestHarness(t)
-	filePath := testdataPath(t, "users_huge.csv")
+	filePath := h.testdata(t, "users_huge.csv")
 
 	job := createTestJob(h, "users", filePath)
 
@@ -240,7 +279,7 @@ func TestProcessImport_UsersCSV_SpecificErrors(t *testing.T) {
 
 func TestProcessImport_UsersCSV_DuplicateEmails(t *testing.T) {
 	h := newTestHarness(t)
-	filePath := testdataPath(t, "users_huge.csv")
+	filePath := h.testdata(t, "users_huge.csv")
 
 	job := createTestJob(h, "users", filePath)
 
@@ -265,17 +304,10 @@ func TestProcessImport_UsersCSV_DuplicateEmails(t *testing.T) {
 func TestProcessImport_UsersCSV_EmptyFile(t *testing.T) {
 	h := newTestHarness(t)
 
-	tmpFile, err := os.CreateTemp("", "test_empty_*.csv")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.Remove(tmpFile.Name())
-	tmpFile.WriteString("id,email,name,role,active,created_at,updated_at\n")
-	tmpFile.Close()
-
-	job := createTestJob(h, "users", tmpFile.Name())
+	filePath := h.put(t, "empty.csv", []byte("id,email,name,role,active,created_at,updated_at\n"))
+	job := createTestJob(h, "users", filePath)
 
-	err = h.services.Import.ProcessImport(context.Background(), job)
+	err := h.services.Import.ProcessImport(context.Background(), job)
 	if err != nil {
 		t.Fatalf("ProcessImport should handle empty file: %v", err)
 	}
@@ -291,23 +323,17 @@ func TestProcessImport_UsersCSV_EmptyFile(t *testing.T) {
 func TestProcessImport_UsersCSV_BatchInsertError(t *testing.T) {
 	h := newTestHarness(t)
 
-	tmpFile, err := os.CreateTemp("", "test_batch_err_*.csv")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.Remove(tmpFile.Name())
-	tmpFile.WriteString("id,email,name,role,active,created_at,updated_at\n")
-	tmpFile.WriteString("550e8400-e29b-41d4-a716-446655440000,test@example.com,Test User,admin,true,2024-01-01T00:00:00Z,2024-01-01T00:00:00Z\n")
-	tmpFile.Close()
+	filePath := h.put(t, "batch_err.csv", []byte("id,email,name,role,active,created_at,updated_at\n"+
+		"550e8400-e29b-41d4-a716-446655440000,test@example.com,Test User,admin,true,2024-01-01T00:00:00Z,2024-01-01T00:00:00Z\n"))
 
 	// Make BatchInsert return an error
 	h.userRepo.BatchInsertFunc = func(ctx context.Context, users []*models.User) (int, error) {
 		return 0, context.DeadlineExceeded
 	}
 
-	job := createTestJob(h, "users", tmpFile.Name())
+	job := createTestJob(h, "users", filePath)
 
-	err = h.services.Import.ProcessImport(context.Background(), job)
+	err := h.services.Import.ProcessImport(context.Background(), job)
 	if err != nil {
 		t.Fatalf("ProcessImport should not return error on batch failure: %v", err)
 	}
@@ -325,7 +351,7 @@ func TestProcessImport_UsersCSV_BatchInsertError(t *testing.T) {
 
 func TestProcessImport_ArticlesNDJSON_HugeFile(t *testing.T) {
 	h := newTestHarness(t)
-	filePath := testdataPath(t, "articles_huge.ndjson")
+	filePath := h.testdata(t, "articles_huge.ndjson")
 
 	job := createTestJob(h, "articles", filePath)
 
@@ -390,7 +416,7 @@ func TestProcessImport_ArticlesNDJSON_HugeFile(t *testing.T) {
 
 func TestProcessImport_ArticlesNDJSON_DraftWithPublishedAt(t *testing.T) {
 	h := newTestHarness(t)
-	filePath := testdataPath(t, "articles_huge.ndjson")
+	filePath := h.testdata(t, "articles_huge.ndjson")
 
 	job := createTestJob(h, "articles", filePath)
 
@@ -415,19 +441,13 @@ func TestProcessImport_ArticlesNDJSON_DraftWithPublishedAt(t *testing.T) {
 func TestProcessImport_ArticlesNDJSON_MalformedJSON(t *testing.T) {
 	h := newTestHarness(t)
 
-	tmpFile, err := os.CreateTemp("", "test_malformed_*.ndjson")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.Remove(tmpFile.Name())
-	tmpFile.WriteString("{\"id\":\"550e8400-e29b-41d4-a716-446655440000\",\"slug\":\"valid-slug\",\"title\":\"Valid\",\"body\":\"Valid body\",\"author_id\":\"550e8400-e29b-41d4-a716-446655440001\",\"status\":\"draft\"}\n")
-	tmpFile.WriteString("{broken json here\n")
-	tmpFile.WriteString("{\"id\":\"550e8400-e29b-41d4-a716-446655440002\",\"slug\":\"another-valid\",\"title\":\"Valid2\",\"body\":\"Valid body2\",\"author_id\":\"550e8400-e29b-41d4-a716-446655440001\",\"status\":\"draft\"}\n")
-	tmpFile.Close()
-
-	job := createTestJob(h, "articles", tmpFile.Name())
+	filePath := h.put(t, "malformed.ndjson", []byte(
+		"{\"id\":\"550e8400-e29b-41d4-a716-446655440000\",\"slug\":\"valid-slug\",\"title\":\"Valid\",\"body\":\"Valid body\",\"author_id\":\"550e8400-e29b-41d4-a716-446655440001\",\"status\":\"draft\"}\n"+
+			"{broken json here\n"+
+			"{\"id\":\"550e8400-e29b-41d4-a716-446655440002\",\"slug\":\"another-valid\",\"title\":\"Valid2\",\"body\":\"Valid body2\",\"author_id\":\"550e8400-e29b-41d4-a716-446655440001\",\"status\":\"draft\"}\n"))
+	job := createTestJob(h, "articles", filePath)
 
-	err = h.services.Import.ProcessImport(context.Background(), job)
+	err := h.services.Import.ProcessImport(context.Background(), job)
 	if err != nil {
 		t.Fatalf("Should handle malformed JSON without fatal error: %v", err)
 	}
@@ -455,7 +475,7 @@ func TestProcessImport_ArticlesNDJSON_MalformedJSON(t *testing.T) {
 
 func TestProcessImport_CommentsNDJSON_HugeFile(t *testing.T) {
 	h := newTestHarness(t)
-	filePath := testdataPath(t, "comments_huge.ndjson")
+	filePath := h.testdata(t, "comments_huge.ndjson")
 
 	job := createTestJob(h, "comments", filePath)
 
@@ -518,7 +538,7 @@ func TestProcessImport_CommentsNDJSON_HugeFile(t *testing.T) {
 
 func TestProcessImport_CommentsNDJSON_InvalidForeignKeys(t *testing.T) {
 	h := newTestHarness(t)
-	filePath := testdataPath(t, "comments_huge.ndjson")
+	filePath := h.testdata(t, "comments_huge.ndjson")
 
 	job := createTestJob(h, "comments", filePath)
 
@@ -542,7 +562,7 @@ func TestProcessImport_CommentsNDJSON_InvalidForeignKeys(t *testing.T) {
 
 func TestProcessImport_CommentsNDJSON_MissingBody(t *testing.T) {
 	h := newTestHarness(t)
-	filePath := testdataPath(t, "comments_huge.ndjson")
+	filePath := h.testdata(t, "comments_huge.ndjson")
 
 	job := createTestJob(h, "comments", filePath)
 
@@ -564,6 +584,372 @@ func TestProcessImport_CommentsNDJSON_MissingBody(t *testing.T) {
 	t.Logf("Found %d missing body errors", missingBodyCount)
 }
 
+// --- Parquet Integration Tests ---
+
+func TestProcessImport_ArticlesParquet(t *testing.T) {
+	h := newTestHarness(t)
+
+	authorID := "550e8400-e29b-41d4-a716-446655440001"
+	h.userRepo.Create(context.Background(), &models.User{ID: authorID, Email: "author@test.com"})
+
+	published := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)
+	rows := []models.Article{
+		{
+			ID: "550e8400-e29b-41d4-a716-446655440010", Slug: "first-post", Title: "First", Body: "Body",
+			AuthorID: authorID, Tags: []string{"go"}, Status: "published", PublishedAt: &published,
+		},
+		{
+			// Invalid: draft with published_at
+			ID: "550e8400-e29b-41d4-a716-446655440011", Slug: "second-post", Title: "Second", Body: "Body",
+			AuthorID: authorID, Status: "draft", PublishedAt: &published,
+		},
+		{
+			// Invalid: unknown author
+			ID: "550e8400-e29b-41d4-a716-446655440012", Slug: "third-post", Title: "Third", Body: "Body",
+			AuthorID: "550e8400-e29b-41d4-a716-446655449999", Status: "draft",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	filePath := h.put(t, "articles.parquet", buf.Bytes())
+
+	job := createTestJob(h, "articles", filePath)
+
+	err := h.services.Import.ProcessImport(context.Background(), job)
+	if err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+
+	if job.TotalRecords != 3 {
+		t.Errorf("Expected 3 total records, got %d", job.TotalRecords)
+	}
+	if job.SuccessfulCount != 1 {
+		t.Errorf("Expected 1 successful record, got %d", job.SuccessfulCount)
+	}
+	if job.FailedCount != 2 {
+		t.Errorf("Expected 2 failed records, got %d", job.FailedCount)
+	}
+
+	imported := h.articleRepo.Articles["550e8400-e29b-41d4-a716-446655440010"]
+	if imported == nil {
+		t.Fatal("Expected valid article to be inserted")
+	}
+	if len(imported.Tags) != 1 || imported.Tags[0] != "go" {
+		t.Errorf("Expected tags [go], got %v", imported.Tags)
+	}
+	if imported.PublishedAt == nil || !imported.PublishedAt.Equal(published) {
+		t.Errorf("Expected published_at %v, got %v", published, imported.PublishedAt)
+	}
+
+	// Row numbers are reported 1-based
+	lines := map[int]string{}
+	for _, e := range h.jobRepo.Errors[job.ID] {
+		lines[e.Line] = e.Field
+	}
+	if lines[2] != "published_at" {
+		t.Errorf("Expected published_at error on row 2, got %v", lines)
+	}
+	if lines[3] != "author_id" {
+		t.Errorf("Expected author_id error on row 3, got %v", lines)
+	}
+}
+
+func TestProcessImport_UsersParquet_RoundTrip(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	// Export users to Parquet, then import the file into a fresh harness
+	for i := 0; i < 2500; i++ {
+		h.userRepo.Create(ctx, &models.User{
+			ID:        "550e8400-e29b-41d4-a716-" + padInt(i, 12),
+			Email:     "user" + padInt(i, 6) + "@test.com",
+			Name:      "User",
+			Role:      "editor",
+			Active:    i%3 == 0,
+			CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		})
+	}
+
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamUsers(ctx, w, "parquet"); err != nil {
+		t.Fatalf("StreamUsers returned error: %v", err)
+	}
+	target := newTestHarness(t)
+	job := createTestJob(target, "users", target.put(t, "users.parquet", w.Body.Bytes()))
+	if err := target.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+
+	if job.TotalRecords != 2500 || job.SuccessfulCount != 2500 {
+		t.Errorf("Expected 2500/2500 records imported, got %d/%d", job.SuccessfulCount, job.TotalRecords)
+	}
+	if errs := target.jobRepo.Errors[job.ID]; len(errs) > 0 {
+		t.Errorf("Expected no validation errors, got %v", errs[0])
+	}
+
+	original := h.userRepo.Users["550e8400-e29b-41d4-a716-000000000003"]
+	imported := target.userRepo.Users[original.ID]
+	if imported == nil || imported.Active != original.Active || !imported.CreatedAt.Equal(original.CreatedAt) {
+		t.Errorf("Round-tripped user mismatch: %+v vs %+v", imported, original)
+	}
+}
+
+func TestProcessImport_InvalidParquetFile(t *testing.T) {
+	h := newTestHarness(t)
+
+	filePath := h.put(t, "comments.parquet", []byte("not a parquet file"))
+
+	job := createTestJob(h, "comments", filePath)
+
+	err := h.services.Import.ProcessImport(context.Background(), job)
+	if err == nil {
+		t.Fatal("Expected error for corrupt parquet file")
+	}
+	if job.Status != models.JobStatusFailed {
+		t.Errorf("Expected job status failed, got %s", job.Status)
+	}
+}
+
+// --- XLSX Integration Tests ---
+
+// writeUsersWorkbook creates a workbook whose "Team" sheet holds typed cells:
+// booleans for active and Excel dates for created_at
+func writeUsersWorkbook(t *testing.T, h *testHarness) string {
+	t.Helper()
+
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetCellValue("Sheet1", "A1", "ignored sheet")
+	f.NewSheet("Team")
+
+	created := time.Date(2024, 1, 5, 10, 30, 0, 0, time.UTC)
+	dateFormat := "yyyy-mm-dd hh:mm"
+	dateStyle, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+
+	rows := [][]interface{}{
+		{"ID", "Email", "Name", "Role", "Active", "Created_At"},
+		{"550e8400-e29b-41d4-a716-446655440000", "alice@test.com", "Alice", "admin", true, created},
+		{"550e8400-e29b-41d4-a716-446655440001", "bob@test.com", "Bob", "viewer", false, "2024-02-01T09:00:00Z"},
+		{},
+		{"550e8400-e29b-41d4-a716-446655440002", "carol@test.com", "Carol", "superuser", true, created},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		f.SetSheetRow("Team", cell, &row)
+	}
+	f.SetCellStyle("Team", "F2", "F5", dateStyle)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h.put(t, "users.xlsx", buf.Bytes())
+}
+
+func TestProcessImport_UsersXLSX_TypedCells(t *testing.T) {
+	h := newTestHarness(t)
+	job := createTestJob(h, "users", writeUsersWorkbook(t, h))
+	job.Options = &models.ImportOptions{Sheet: "Team"}
+
+	err := h.services.Import.ProcessImport(context.Background(), job)
+	if err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+
+	if job.TotalRecords != 3 {
+		t.Errorf("Expected 3 total records (blank row skipped), got %d", job.TotalRecords)
+	}
+	if job.SuccessfulCount != 2 {
+		t.Errorf("Expected 2 successful records, got %d", job.SuccessfulCount)
+	}
+
+	alice := h.userRepo.Users["550e8400-e29b-41d4-a716-446655440000"]
+	if alice == nil {
+		t.Fatal("Expected alice to be imported")
+	}
+	if !alice.Active {
+		t.Error("Expected boolean TRUE cell to import as active")
+	}
+	if want := time.Date(2024, 1, 5, 10, 30, 0, 0, time.UTC); !alice.CreatedAt.Equal(want) {
+		t.Errorf("Expected created_at %v from date cell, got %v", want, alice.CreatedAt)
+	}
+
+	bob := h.userRepo.Users["550e8400-e29b-41d4-a716-446655440001"]
+	if bob == nil || bob.Active {
+		t.Errorf("Expected bob imported as inactive, got %+v", bob)
+	}
+
+	// Errors are reported by spreadsheet row number
+	storedErrors := h.jobRepo.Errors[job.ID]
+	if len(storedErrors) != 1 || storedErrors[0].Line != 5 || storedErrors[0].Field != "role" {
+		t.Errorf("Expected a single role error on row 5, got %v", storedErrors)
+	}
+}
+
+func TestProcessImport_UsersXLSX_DefaultsToFirstSheet(t *testing.T) {
+	h := newTestHarness(t)
+	job := createTestJob(h, "users", writeUsersWorkbook(t, h))
+
+	err := h.services.Import.ProcessImport(context.Background(), job)
+	if err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+
+	// Sheet1 only has a header cell and no data rows
+	if job.TotalRecords != 0 {
+		t.Errorf("Expected first sheet to be read (0 records), got %d", job.TotalRecords)
+	}
+}
+
+func TestProcessImport_UsersXLSX_UnknownSheet(t *testing.T) {
+	h := newTestHarness(t)
+	job := createTestJob(h, "users", writeUsersWorkbook(t, h))
+	job.Options = &models.ImportOptions{Sheet: "Missing"}
+
+	err := h.services.Import.ProcessImport(context.Background(), job)
+	if err == nil || !strings.Contains(err.Error(), "Missing") {
+		t.Fatalf("Expected sheet not found error, got %v", err)
+	}
+	if job.Status != models.JobStatusFailed {
+		t.Errorf("Expected job status failed, got %s", job.Status)
+	}
+}
+
+func TestProcessImport_UsersXLSX_RoundTrip(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	created := time.Date(2023, 6, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 50; i++ {
+		h.userRepo.Create(ctx, &models.User{
+			ID:        "550e8400-e29b-41d4-a716-" + padInt(i, 12),
+			Email:     "user" + padInt(i, 4) + "@test.com",
+			Name:      "User",
+			Role:      "editor",
+			Active:    i%2 == 0,
+			CreatedAt: created,
+			UpdatedAt: created,
+		})
+	}
+
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamUsers(ctx, w, "xlsx"); err != nil {
+		t.Fatalf("StreamUsers returned error: %v", err)
+	}
+	target := newTestHarness(t)
+	job := createTestJob(target, "users", target.put(t, "users.xlsx", w.Body.Bytes()))
+	if err := target.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+
+	if job.SuccessfulCount != 50 {
+		t.Errorf("Expected 50 users imported, got %d (errors: %v)", job.SuccessfulCount, target.jobRepo.Errors[job.ID])
+	}
+	imported := target.userRepo.Users["550e8400-e29b-41d4-a716-000000000001"]
+	if imported == nil || imported.Active || !imported.CreatedAt.Equal(created) {
+		t.Errorf("Round-tripped user mismatch: %+v", imported)
+	}
+}
+
+// --- Cross-format Integration Tests ---
+
+func TestProcessImport_ArticlesCSV_RoundTrip(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	authorID := "550e8400-e29b-41d4-a716-446655440000"
+	h.userRepo.Create(ctx, &models.User{ID: authorID, Email: "author@test.com", Name: "Author", Role: "author", Active: true})
+
+	published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 50; i++ {
+		article := &models.Article{
+			ID:        "6f9619ff-8b86-d011-b42d-" + padInt(i, 12),
+			Slug:      "article-" + padInt(i, 3),
+			Title:     "Article",
+			Body:      "Body, with \"quotes\" and commas",
+			AuthorID:  authorID,
+			Tags:      []string{"go", "csv"},
+			Status:    "draft",
+			CreatedAt: published,
+			UpdatedAt: published,
+		}
+		if i%2 == 0 {
+			article.Status = "published"
+			article.PublishedAt = &published
+		}
+		h.articleRepo.Create(ctx, article)
+	}
+
+	// CSV used to be users-only; every format now works for every resource
+	w := httptest.NewRecorder()
+	if err := h.services.Export.StreamArticles(ctx, w, "csv"); err != nil {
+		t.Fatalf("StreamArticles returned error: %v", err)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Expected text/csv content type, got %s", ct)
+	}
+	target := newTestHarness(t)
+	filePath := target.put(t, "articles.csv", w.Body.Bytes())
+	target.userRepo.Create(ctx, &models.User{ID: authorID, Email: "author@test.com", Name: "Author", Role: "author", Active: true})
+	job := createTestJob(target, "articles", filePath)
+	if err := target.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+
+	if job.TotalRecords != 50 || job.SuccessfulCount != 50 {
+		t.Errorf("Expected 50/50 records imported, got %d/%d (errors: %v)", job.SuccessfulCount, job.TotalRecords, target.jobRepo.Errors[job.ID])
+	}
+
+	imported := target.articleRepo.Articles["6f9619ff-8b86-d011-b42d-000000000000"]
+	if imported == nil {
+		t.Fatal("Expected article to be imported")
+	}
+	if len(imported.Tags) != 2 || imported.Tags[1] != "csv" {
+		t.Errorf("Expected tags [go csv], got %v", imported.Tags)
+	}
+	if imported.PublishedAt == nil || !imported.PublishedAt.Equal(published) {
+		t.Errorf("Expected published_at %v, got %v", published, imported.PublishedAt)
+	}
+}
+
+func TestProcessImport_InvalidTagsReported(t *testing.T) {
+	h := newTestHarness(t)
+
+	content := "{\"id\":\"6f9619ff-8b86-d011-b42d-00cf4fc964ff\",\"slug\":\"a\",\"title\":\"A\",\"body\":\"b\",\"author_id\":\"550e8400-e29b-41d4-a716-446655440000\",\"tags\":[1,2]}\n"
+	filePath := h.put(t, "articles.ndjson", []byte(content))
+
+	job := createTestJob(h, "articles", filePath)
+	if err := h.services.Import.ProcessImport(context.Background(), job); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+
+	errs := h.jobRepo.Errors[job.ID]
+	if job.FailedCount != 1 || len(errs) != 1 {
+		t.Fatalf("Expected 1 failed record with 1 error, got %d with %v", job.FailedCount, errs)
+	}
+	if errs[0].Field != "tags" || errs[0].Line != 1 {
+		t.Errorf("Expected tags error on line 1, got %+v", errs[0])
+	}
+}
+
+func TestProcessImport_UnsupportedFileFormat(t *testing.T) {
+	h := newTestHarness(t)
+
+	filePath := h.put(t, "users.txt", []byte("id,email\n"))
+
+	job := createTestJob(h, "users", filePath)
+	if err := h.services.Import.ProcessImport(context.Background(), job); err == nil {
+		t.Fatal("Expected error for unsupported file format")
+	}
+	if job.Status != models.JobStatusFailed {
+		t.Errorf("Expected job status failed, got %s", job.Status)
+	}
+}
+
 // --- Benchmark ---
 
 func BenchmarkProcessImport_UsersCSV(b *testing.B) {
@@ -584,10 +970,11 @@ func BenchmarkProcessImport_UsersCSV(b *testing.B) {
 		jobRepo := mocks.NewMockJobRepository()
 
 		repos := &repository.Repositories{
-			User:    userRepo,
-			Article: articleRepo,
-			Comment: commentRepo,
-			Job:     jobRepo,
+			User:       userRepo,
+			Article:    articleRepo,
+			Comment:    commentRepo,
+			Job:        jobRepo,
+			ExternalID: mocks.NewMockExternalIDRepository(),
 		}
 
 		cfg := &config.Config{
@@ -599,7 +986,7 @@ func BenchmarkProcessImport_UsersCSV(b *testing.B) {
 		}
 
 		log := zerolog.Nop()
-		services := service.NewServices(repos, cfg, log)
+		services := service.NewServices(repos, storage.NewLocal(""), cfg, log)
 
 		now := time.Now()
 		job := &models.Job{
@@ -634,10 +1021,11 @@ func BenchmarkProcessImport_ArticlesNDJSON(b *testing.B) {
 		jobRepo := mocks.NewMockJobRepository()
 
 		repos := &repository.Repositories{
-			User:    userRepo,
-			Article: articleRepo,
-			Comment: commentRepo,
-			Job:     jobRepo,
+			User:       userRepo,
+			Article:    articleRepo,
+			Comment:    commentRepo,
+			Job:        jobRepo,
+			ExternalID: mocks.NewMockExternalIDRepository(),
 		}
 
 		cfg := &config.Config{
@@ -649,7 +1037,7 @@ func BenchmarkProcessImport_ArticlesNDJSON(b *testing.B) {
 		}
 
 		log := zerolog.Nop()
-		services := service.NewServices(repos, cfg, log)
+		services := service.NewServices(repos, storage.NewLocal(""), cfg, log)
 
 		now := time.Now()
 		job := &models.Job{
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/validation"
+)
+
+// ErrInvalidDateOptions is returned for import options with an unknown
+// date layout or timezone
+var ErrInvalidDateOptions = errors.New("invalid date options")
+
+// checkImportOptions rejects import options the job could not run with,
+// before it is created
+func checkImportOptions(store *validation.RuleStore, resource string, opts *models.ImportOptions) error {
+	if _, err := buildTransforms(store, resource, opts); err != nil {
+		return err
+	}
+	_, err := timeParser(opts)
+	return err
+}
+
+// timeParser builds the timestamp parser of an import's options, shared by
+// its validator and converters
+func timeParser(opts *models.ImportOptions) (*validation.TimeParser, error) {
+	if opts == nil {
+		return validation.DefaultTimeParser(), nil
+	}
+	times, err := validation.NewTimeParser(opts.DateLayouts, opts.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDateOptions, err)
+	}
+	return times, nil
+}
@@ -1,19 +1,20 @@
 package service
 
 import (
-	"bufio"
 	"context"
-	"encoding/csv"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"os"
+	"maps"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/bulk-import-export-api/internal/config"
+	"github.com/bulk-import-export-api/internal/format"
 	"github.com/bulk-import-export-api/internal/models"
 	"github.com/bulk-import-export-api/internal/repository"
+	"github.com/bulk-import-export-api/internal/storage"
 	"github.com/bulk-import-export-api/internal/validation"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -22,16 +23,20 @@ import (
 // importService is the concrete implementation of ImportService
 type importService struct {
 	repos      *repository.Repositories
+	blobs      storage.Blob
 	jobService JobService
+	rules      *validation.RuleStore
 	cfg        *config.Config
 	log        zerolog.Logger
 }
 
 // newImportService creates a new ImportService
-func newImportService(repos *repository.Repositories, jobService JobService, cfg *config.Config, log zerolog.Logger) *importService {
+func newImportService(repos *repository.Repositories, blobs storage.Blob, jobService JobService, rules *validation.RuleStore, cfg *config.Config, log zerolog.Logger) *importService {
 	return &importService{
 		repos:      repos,
+		blobs:      blobs,
 		jobService: jobService,
+		rules:      rules,
 		cfg:        cfg,
 		log:        log.With().Str("service", "import").Logger(),
 	}
@@ -39,6 +44,10 @@ func newImportService(repos *repository.Repositories, jobService JobService, cfg
 
 // CreateImportJob creates a new import job
 func (s *importService) CreateImportJob(ctx context.Context, req *models.ImportRequest, filePath string) (*models.Job, error) {
+	if err := checkImportOptions(s.rules, req.Resource, req.Options); err != nil {
+		return nil, err
+	}
+
 	job := &models.Job{
 		ID:             uuid.New().String(),
 		Type:           models.JobTypeImport,
@@ -46,6 +55,7 @@ func (s *importService) CreateImportJob(ctx context.Context, req *models.ImportR
 		Status:         models.JobStatusPending,
 		IdempotencyKey: req.IdempotencyKey,
 		FilePath:       filePath,
+		Options:        req.Options,
 		CreatedAt:      time.Now(),
 	}
 
@@ -62,8 +72,80 @@ func (s *importService) CreateImportJob(ctx context.Context, req *models.ImportR
 	return job, nil
 }
 
-// ProcessImport processes an import job
+// ProcessImport processes an import job; a retry's imported rows are then
+// rolled up into the import it retries
 func (s *importService) ProcessImport(ctx context.Context, job *models.Job) error {
+	err := s.execute(ctx, job, s.fileSource(ctx, job))
+	s.rollUpRetry(ctx, job)
+	return err
+}
+
+// StreamImport imports records decoded straight from body, without storing
+// the upload. The job is created already processing, so the job processor
+// never picks it up; when report is set it receives the outcome of every
+// record once that outcome is final.
+func (s *importService) StreamImport(ctx context.Context, req *models.ImportRequest, formatName string, body io.Reader, report func(models.LineResult) error) (*models.Job, error) {
+	f, ok := format.Lookup(formatName)
+	if !ok || !streamable(f.Name) {
+		return nil, fmt.Errorf("unsupported stream import format: %s", formatName)
+	}
+	if err := checkImportOptions(s.rules, req.Resource, req.Options); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &models.Job{
+		ID:             uuid.New().String(),
+		Type:           models.JobTypeImport,
+		Resource:       req.Resource,
+		Status:         models.JobStatusProcessing,
+		IdempotencyKey: req.IdempotencyKey,
+		Options:        req.Options,
+		CreatedAt:      now,
+		StartedAt:      &now,
+	}
+	if err := s.repos.Job.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	src := recordSource{
+		open: func(schema *format.Schema) (format.RecordDecoder, error) {
+			var opts format.DecodeOptions
+			if req.Options != nil {
+				opts.Sheet = req.Options.Sheet
+			}
+			return f.NewDecoder(body, schema, opts)
+		},
+		report: report,
+	}
+	return job, s.execute(ctx, job, src)
+}
+
+// streamable reports whether a format can be decoded in one pass from a
+// request body; the others need random access to a stored file
+func streamable(formatName string) bool {
+	return formatName == "ndjson" || formatName == "csv"
+}
+
+// recordSource supplies an import's records and, optionally, receives the
+// outcome of each one
+type recordSource struct {
+	open   func(*format.Schema) (format.RecordDecoder, error)
+	report func(models.LineResult) error // nil when nobody is listening
+}
+
+// fileSource reads the job's stored file
+func (s *importService) fileSource(ctx context.Context, job *models.Job) recordSource {
+	return recordSource{
+		open: func(schema *format.Schema) (format.RecordDecoder, error) {
+			return openDecoder(ctx, s.blobs, job, schema)
+		},
+	}
+}
+
+// execute runs an import from src, recording status, counters and metrics
+// on the job
+func (s *importService) execute(ctx context.Context, job *models.Job, src recordSource) error {
 	startTime := time.Now()
 	now := startTime
 	job.Status = models.JobStatusProcessing
@@ -78,11 +160,13 @@ func (s *importService) ProcessImport(ctx context.Context, job *models.Job) erro
 	var err error
 	switch job.Resource {
 	case "users":
-		err = s.processUsersCSV(ctx, job)
+		err = s.processUsers(ctx, job, src)
 	case "articles":
-		err = s.processArticlesNDJSON(ctx, job)
+		err = s.processArticles(ctx, job, src)
 	case "comments":
-		err = s.processCommentsNDJSON(ctx, job)
+		err = s.processComments(ctx, job, src)
+	case models.ResourceBundle:
+		err = s.processBundle(ctx, job)
 	default:
 		err = fmt.Errorf("unknown resource type: %s", job.Resource)
 	}
@@ -103,6 +187,10 @@ func (s *importService) ProcessImport(ctx context.Context, job *models.Job) erro
 		errorRate = float64(job.FailedCount) / float64(job.TotalRecords) * 100
 	}
 
+	// A cancelled import stopped early, whatever its last step returned
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
 	if err != nil {
 		job.Status = models.JobStatusFailed
 		s.log.Error().Err(err).Str("job_id", job.ID).Msg("Import failed")
@@ -113,362 +201,497 @@ func (s *importService) ProcessImport(ctx context.Context, job *models.Job) erro
 			Int("total", job.TotalRecords).
 			Int("successful", job.SuccessfulCount).
 			Int("failed", job.FailedCount).
+			Int("warnings", job.WarningCount).
 			Float64("error_rate_pct", errorRate).
 			Int64("duration_ms", job.DurationMs).
 			Float64("rows_per_sec", job.RowsPerSec).
 			Msg("Import completed")
 	}
 
-	s.repos.Job.Update(ctx, job)
+	store, cancel := bookkeeping(ctx)
+	defer cancel()
+	if uerr := s.repos.Job.Update(store, job); uerr != nil {
+		s.log.Error().Err(uerr).Str("job_id", job.ID).Msg("Failed to record import outcome")
+		if err == nil {
+			err = uerr
+		}
+	}
 
 	return err
 }
 
-// processUsersCSV processes a users CSV file
-func (s *importService) processUsersCSV(ctx context.Context, job *models.Job) error {
-	file, err := os.Open(job.FilePath)
+// bookkeepingTimeout bounds each write that records an import's progress
+// or outcome
+const bookkeepingTimeout = 30 * time.Second
+
+// bookkeeping returns the context for recording an import's errors,
+// rejects and outcome. It outlives cancellation of ctx, so a stream import
+// whose client disconnects still ends failed with what it had read.
+func bookkeeping(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), bookkeepingTimeout)
+}
+
+// importPipeline describes how one resource is imported: how decoded
+// records map onto its import struct, how that is validated, and how valid
+// rows are converted and stored. Every format shares the same loop.
+type importPipeline[T, M any] struct {
+	schema   *format.Schema
+	bind     func(format.Record) (*T, []validation.ValidationError)
+	validate func(*T, int) []validation.ValidationError
+	// accept records a valid row in the validator caches and converts it;
+	// release, when set, takes the row back out of the caches if a batch
+	// check rejects it or its insert fails, so it blocks no later row
+	accept  func(*T) *M
+	release func(*T)
+	// mapIDs replaces a batch's external IDs with UUIDs, and validateBatch,
+	// when set, checks a batch against stored rows. Both run before the
+	// batch is inserted and key errors by index in the batch.
+	mapIDs        batchCheck[M]
+	validateBatch batchCheck[M]
+	insert        func(context.Context, []*M) (int, error)
+}
+
+// batchCheck checks or rewrites a batch of rows, returning the errors of
+// the rows it rejects by index
+type batchCheck[M any] func(context.Context, []*M) (map[int][]validation.ValidationError, error)
+
+// processUsers imports users
+func (s *importService) processUsers(ctx context.Context, job *models.Job, src recordSource) error {
+	times, err := timeParser(job.Options)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
-	validator := validation.NewValidator()
-	batchSize := s.cfg.Import.BatchSize
+	validator := s.newValidator(job, times)
+	validator.SetEmailLookup(s.repos.User.ExistingEmails)
+	ids := s.idMapper(job)
+
+	return runImport(ctx, s, job, src, importPipeline[models.UserCSV, models.User]{
+		schema:   userSchema,
+		bind:     userFromRecord,
+		validate: validator.ValidateUser,
+		accept: func(user *models.UserCSV) *models.User {
+			validator.AddUserEmail(user.Email)
+			validator.AddUserID(user.ID)
+			return convertCSVToUser(user, times)
+		},
+		release: func(user *models.UserCSV) {
+			validator.RemoveUserEmail(user.Email)
+			validator.RemoveUserID(user.ID)
+		},
+		mapIDs:        ids.mapUsers,
+		validateBatch: validator.ValidateUserBatch,
+		insert:        s.repos.User.BatchInsert,
+	})
+}
 
-	// Read header
-	header, err := reader.Read()
+// processArticles imports articles
+func (s *importService) processArticles(ctx context.Context, job *models.Job, src recordSource) error {
+	times, err := timeParser(job.Options)
 	if err != nil {
 		return err
 	}
-	headerMap := make(map[string]int)
-	for i, h := range header {
-		headerMap[strings.ToLower(strings.TrimSpace(h))] = i
-	}
-
-	var batch []*models.User
-	var validationErrors []models.ValidationError
-	lineNum := 1 // Start after header
-
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			continue
-		}
-		lineNum++
-		job.TotalRecords++
-
-		// Respect context cancellation for long-running imports
-		if lineNum%10000 == 0 {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
-		}
-
-		// Parse CSV row into UserCSV struct
-		userCSV := &models.UserCSV{
-			ID:        getField(record, headerMap, "id"),
-			Email:     getField(record, headerMap, "email"),
-			Name:      getField(record, headerMap, "name"),
-			Role:      getField(record, headerMap, "role"),
-			Active:    getField(record, headerMap, "active"),
-			CreatedAt: getField(record, headerMap, "created_at"),
-			UpdatedAt: getField(record, headerMap, "updated_at"),
-		}
+	validator := s.newValidator(job, times)
+	validator.SetSlugLookup(s.repos.Article.ExistingSlugs)
+	ids := s.idMapper(job)
+	s.referenceCheck(ctx, job, "users", s.repos.User, validator.SetUserIDCache, validator.SetUserIDLookup)
+
+	return runImport(ctx, s, job, src, importPipeline[models.ArticleNDJSON, models.Article]{
+		schema:   articleSchema,
+		bind:     articleFromRecord,
+		validate: validator.ValidateArticle,
+		accept: func(article *models.ArticleNDJSON) *models.Article {
+			validator.AddArticleSlug(article.Slug)
+			validator.AddArticleID(article.ID)
+			return convertNDJSONToArticle(article, times)
+		},
+		release: func(article *models.ArticleNDJSON) {
+			validator.RemoveArticleSlug(article.Slug)
+			validator.RemoveArticleID(article.ID)
+		},
+		mapIDs:        ids.mapArticles,
+		validateBatch: validator.ValidateArticleBatch,
+		insert:        s.repos.Article.BatchInsert,
+	})
+}
 
-		// Validate
-		errors := validator.ValidateUser(userCSV, lineNum)
-		if len(errors) > 0 {
-			job.FailedCount++
-			job.ProcessedCount++
-			for _, e := range errors {
-				validationErrors = append(validationErrors, models.ValidationError{
-					Line:    lineNum,
-					Field:   e.Field,
-					Message: e.Message,
-					Value:   e.Value,
-				})
-			}
-			// Flush errors periodically to prevent unbounded memory growth
-			if len(validationErrors) >= errorFlushThreshold {
-				s.flushValidationErrors(ctx, job.ID, &validationErrors)
-			}
-			continue
-		}
+// processComments imports comments
+func (s *importService) processComments(ctx context.Context, job *models.Job, src recordSource) error {
+	times, err := timeParser(job.Options)
+	if err != nil {
+		return err
+	}
+	validator := s.newValidator(job, times)
+	ids := s.idMapper(job)
+	s.referenceCheck(ctx, job, "articles", s.repos.Article, validator.SetArticleIDCache, validator.SetArticleIDLookup)
+	s.referenceCheck(ctx, job, "users", s.repos.User, validator.SetUserIDCache, validator.SetUserIDLookup)
+
+	return runImport(ctx, s, job, src, importPipeline[models.CommentNDJSON, models.Comment]{
+		schema:   commentSchema,
+		bind:     commentFromRecord,
+		validate: validator.ValidateComment,
+		accept: func(comment *models.CommentNDJSON) *models.Comment {
+			return convertNDJSONToComment(comment, times)
+		},
+		mapIDs:        ids.mapComments,
+		validateBatch: validator.ValidateCommentBatch,
+		insert:        s.repos.Comment.BatchInsert,
+	})
+}
 
-		// Convert to User model
-		user := convertCSVToUser(userCSV)
-		batch = append(batch, user)
-		validator.AddUserEmail(userCSV.Email)
-		validator.AddUserID(userCSV.ID)
+// FK validation strategies, chosen per referenced resource and import
+const (
+	fkStrategyNone   = "none"         // no stored parents, so references are not checked
+	fkStrategyCache  = "cache"        // every stored ID held in memory
+	fkStrategyLookup = "batch_lookup" // one id = ANY($1) query per batch
+)
 
-		// Process batch
-		if len(batch) >= batchSize {
-			inserted, err := s.repos.User.BatchInsert(ctx, batch)
-			if err != nil {
-				s.log.Error().Err(err).Int("batch_size", len(batch)).Msg("Batch insert failed")
-				job.FailedCount += len(batch)
-			} else {
-				job.SuccessfulCount += inserted
-			}
-			job.ProcessedCount += len(batch)
-			batch = batch[:0]
+// defaultFKCacheLimit is used when the config does not set one
+const defaultFKCacheLimit = 100000
 
-			s.log.Debug().
-				Str("job_id", job.ID).
-				Int("processed", job.ProcessedCount).
-				Float64("rows_per_sec", float64(job.ProcessedCount)/time.Since(*job.StartedAt).Seconds()).
-				Msg("Batch processed")
-		}
-	}
+// parentRepository is the part of a repository FK validation reads
+type parentRepository interface {
+	Count(ctx context.Context) (int, error)
+	GetAllIDs(ctx context.Context) ([]string, error)
+	ExistingIDs(ctx context.Context, ids []string) ([]string, error)
+}
 
-	// Process remaining batch
-	if len(batch) > 0 {
-		inserted, err := s.repos.User.BatchInsert(ctx, batch)
-		if err != nil {
-			s.log.Error().Err(err).Int("batch_size", len(batch)).Msg("Batch insert failed")
-			job.FailedCount += len(batch)
+// referenceCheck sets up validation of references to the parent resource.
+// Up to FKCacheLimit stored IDs are loaded into the validator's cache and
+// checked per row; beyond that, or if they cannot be counted or loaded,
+// each batch's references are looked up in one query, which scales with
+// the batch rather than the table. The strategy is logged.
+func (s *importService) referenceCheck(ctx context.Context, job *models.Job, parent string, repo parentRepository,
+	setCache func([]string), setLookup func(validation.StoredLookup)) {
+	limit := s.cfg.Import.FKCacheLimit
+	if limit <= 0 {
+		limit = defaultFKCacheLimit
+	}
+
+	strategy := fkStrategyLookup
+	count, err := repo.Count(ctx)
+	switch {
+	case err != nil:
+		s.log.Warn().Err(err).Str("job_id", job.ID).Str("parent", parent).Msg("Failed to count parents for FK validation")
+	case count == 0:
+		strategy = fkStrategyNone
+	case count <= limit:
+		if ids, err := repo.GetAllIDs(ctx); err == nil {
+			setCache(ids)
+			strategy = fkStrategyCache
 		} else {
-			job.SuccessfulCount += inserted
+			s.log.Warn().Err(err).Str("job_id", job.ID).Str("parent", parent).Msg("Failed to load parent IDs for FK validation")
 		}
-		job.ProcessedCount += len(batch)
 	}
-
-	// Store validation errors
-	if len(validationErrors) > 0 {
-		s.repos.Job.AddErrors(ctx, job.ID, validationErrors)
+	if strategy == fkStrategyLookup {
+		setLookup(repo.ExistingIDs)
 	}
 
-	return nil
+	s.log.Info().
+		Str("job_id", job.ID).
+		Str("parent", parent).
+		Int("parent_count", count).
+		Int("cache_limit", limit).
+		Str("fk_strategy", strategy).
+		Msg("FK validation strategy selected")
 }
 
-// processArticlesNDJSON processes articles NDJSON file
-func (s *importService) processArticlesNDJSON(ctx context.Context, job *models.Job) error {
-	file, err := os.Open(job.FilePath)
+// runImport decodes the records from src, validates each one and inserts
+// valid rows in batches. Undecodable and invalid records are stored as
+// validation errors, as are rows a batch check finds conflicting with
+// stored ones; the source record of each rejected row is kept for its
+// rejects file. A fatal read error is returned after the rows read so far
+// have been inserted.
+func runImport[T, M any](ctx context.Context, s *importService, job *models.Job, src recordSource, p importPipeline[T, M]) error {
+	decoder, err := src.open(p.schema)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	// Increase buffer size for long lines
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+	defer decoder.Close()
+	if h, ok := decoder.(format.HeaderDecoder); ok {
+		job.SourceHeader = h.Header()
+	}
 
-	validator := validation.NewValidator()
 	batchSize := s.cfg.Import.BatchSize
 
-	// Pre-load user IDs for FK validation (if not too many)
-	userIDs, _ := s.repos.User.GetAllIDs(ctx)
-	if len(userIDs) < 100000 {
-		validator.SetUserIDCache(userIDs)
+	transforms, err := s.transforms(job.Resource, job.Options)
+	if err != nil {
+		return err
 	}
+	var applied []models.TransformRecord
 
-	var batch []*models.Article
+	var batch []*M
+	var batchSources []*T            // bound record of each row in batch
+	var batchLines []int             // line number of each row in batch
+	var batchRecords []format.Record // source record of each row in batch
 	var validationErrors []models.ValidationError
-	lineNum := 0
+	var readErr error
+
+	// Outcomes are reported in input order, so a rejected record waits
+	// behind valid rows whose batch has not been inserted yet. pending
+	// holds at most one batch of rows plus the rejects among them.
+	var pending []models.LineResult
+	var batchResults []int // indexes into pending of the rows in batch
+	flushResults := func() error {
+		for _, r := range pending {
+			if err := src.report(r); err != nil {
+				return err
+			}
+		}
+		pending = pending[:0]
+		return nil
+	}
 
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
+	// record stores the issues found in a record, counting its warnings
+	record := func(lineNum int, errs []validation.ValidationError) []models.ValidationError {
+		issues := make([]models.ValidationError, 0, len(errs))
+		for _, e := range errs {
+			severity := e.Severity
+			if severity == "" {
+				severity = models.SeverityError
+			}
+			if severity == models.SeverityWarning {
+				job.WarningCount++
+			}
+			issues = append(issues, models.ValidationError{
+				Line:     lineNum,
+				Field:    e.Field,
+				Message:  e.Message,
+				Value:    e.Value,
+				Severity: severity,
+				Code:     e.Code,
+			})
+		}
+		validationErrors = append(validationErrors, issues...)
+		// Flush errors periodically to prevent unbounded memory growth
+		if len(validationErrors) >= errorFlushThreshold {
+			s.flushValidationErrors(ctx, job.ID, &validationErrors)
+		}
+		return issues
+	}
 
-		if strings.TrimSpace(line) == "" {
-			continue
+	// Rejected records are kept as read, before transforms, so they can be
+	// fixed and uploaded again. Records that could not be decoded have no
+	// fields to keep.
+	var rejects []models.RejectedRecord
+	keep := func(lineNum int, raw format.Record, issues []models.ValidationError) {
+		if raw == nil {
+			return
 		}
+		rejects = append(rejects, models.RejectedRecord{
+			Line:      lineNum,
+			Record:    raw,
+			Errors:    rejectReason(issues),
+			Retryable: retryable(issues),
+		})
+		if len(rejects) >= errorFlushThreshold {
+			s.flushRejects(ctx, job.ID, &rejects)
+		}
+	}
 
-		job.TotalRecords++
+	// fail counts a rejected record and stores its errors
+	fail := func(lineNum int, raw format.Record, errs []validation.ValidationError) models.LineResult {
+		job.FailedCount++
+		job.ProcessedCount++
+		issues := record(lineNum, errs)
+		keep(lineNum, raw, issues)
+		return models.LineResult{Line: lineNum, Status: models.LineFailed, Errors: issues}
+	}
 
-		// Respect context cancellation for long-running imports
-		if lineNum%10000 == 0 {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
+	reject := func(lineNum int, raw format.Record, errs []validation.ValidationError) error {
+		result := fail(lineNum, raw, errs)
+		if src.report == nil {
+			return nil
+		}
+		pending = append(pending, result)
+		if len(batch) == 0 {
+			return flushResults()
+		}
+		return nil
+	}
+
+	// accept queues a valid row for insertion, storing its warnings
+	accept := func(lineNum int, raw format.Record, source *T, row *M, notes []validation.ValidationError) {
+		batch = append(batch, row)
+		batchSources = append(batchSources, source)
+		batchLines = append(batchLines, lineNum)
+		batchRecords = append(batchRecords, raw)
+		var warnings []models.ValidationError
+		if len(notes) > 0 {
+			warnings = record(lineNum, notes)
 		}
+		if src.report != nil {
+			batchResults = append(batchResults, len(pending))
+			pending = append(pending, models.LineResult{Line: lineNum, Status: models.LineImported, Warnings: warnings})
+		}
+	}
 
-		var article models.ArticleNDJSON
-		if err := json.Unmarshal([]byte(line), &article); err != nil {
-			job.FailedCount++
-			job.ProcessedCount++
-			validationErrors = append(validationErrors, models.ValidationError{
-				Line:    lineNum,
-				Field:   "json",
-				Message: fmt.Sprintf("invalid JSON: %v", err),
-			})
-			if len(validationErrors) >= errorFlushThreshold {
-				s.flushValidationErrors(ctx, job.ID, &validationErrors)
+	// checkBatch maps the batch's external IDs and rejects the rows that
+	// cannot be mapped or conflict with stored rows, so they are reported
+	// by line instead of failing the whole insert. If the check itself
+	// fails, no row of the batch is inserted unchecked: each is rejected
+	// for a retry.
+	checkBatch := func(check batchCheck[M]) {
+		if check == nil || len(batch) == 0 {
+			return
+		}
+		conflicts, err := check(ctx, batch)
+		if err != nil {
+			s.log.Error().Err(err).Str("job_id", job.ID).Int("batch_size", len(batch)).Msg("Batch check failed")
+			conflicts = make(map[int][]validation.ValidationError, len(batch))
+			for i := range batch {
+				conflicts[i] = []validation.ValidationError{{
+					Message:  "batch check failed",
+					Severity: models.SeverityError,
+					Code:     validation.CodeCheckFailed,
+				}}
 			}
-			continue
+		}
+		if len(conflicts) == 0 {
+			return
 		}
 
-		// Validate
-		errors := validator.ValidateArticle(&article, lineNum)
-		if len(errors) > 0 {
-			job.FailedCount++
-			job.ProcessedCount++
-			for _, e := range errors {
-				validationErrors = append(validationErrors, models.ValidationError{
-					Line:    lineNum,
-					Field:   e.Field,
-					Message: e.Message,
-					Value:   e.Value,
-				})
+		kept := 0
+		for i, row := range batch {
+			if errs, ok := conflicts[i]; ok {
+				if p.release != nil {
+					p.release(batchSources[i])
+				}
+				result := fail(batchLines[i], batchRecords[i], errs)
+				if src.report != nil {
+					result.Errors = append(result.Errors, pending[batchResults[i]].Warnings...)
+					pending[batchResults[i]] = result
+				}
+				continue
 			}
-			if len(validationErrors) >= errorFlushThreshold {
-				s.flushValidationErrors(ctx, job.ID, &validationErrors)
+			batch[kept] = row
+			batchSources[kept] = batchSources[i]
+			batchLines[kept] = batchLines[i]
+			batchRecords[kept] = batchRecords[i]
+			if src.report != nil {
+				batchResults[kept] = batchResults[i]
 			}
-			continue
+			kept++
 		}
+		batch = batch[:kept]
+		batchSources = batchSources[:kept]
+		batchLines = batchLines[:kept]
+		batchRecords = batchRecords[:kept]
+		if src.report != nil {
+			batchResults = batchResults[:kept]
+		}
+	}
 
-		// Convert to Article model
-		articleModel := convertNDJSONToArticle(&article)
-		batch = append(batch, articleModel)
-		validator.AddArticleSlug(article.Slug)
-		validator.AddArticleID(article.ID)
-
-		// Process batch
-		if len(batch) >= batchSize {
-			inserted, err := s.repos.Article.BatchInsert(ctx, batch)
-			if err != nil {
-				s.log.Error().Err(err).Int("batch_size", len(batch)).Msg("Batch insert failed")
-				job.FailedCount += len(batch)
-			} else {
-				job.SuccessfulCount += inserted
+	insertBatch := func() error {
+		checkBatch(p.mapIDs)
+		checkBatch(p.validateBatch)
+		if len(batch) == 0 {
+			if src.report == nil {
+				return nil
 			}
-			job.ProcessedCount += len(batch)
-			batch = batch[:0]
-
-			s.log.Debug().
-				Str("job_id", job.ID).
-				Int("processed", job.ProcessedCount).
-				Float64("rows_per_sec", float64(job.ProcessedCount)/time.Since(*job.StartedAt).Seconds()).
-				Msg("Batch processed")
+			return flushResults()
 		}
-	}
 
-	// Process remaining batch
-	if len(batch) > 0 {
-		inserted, err := s.repos.Article.BatchInsert(ctx, batch)
+		inserted, err := p.insert(ctx, batch)
 		if err != nil {
 			s.log.Error().Err(err).Int("batch_size", len(batch)).Msg("Batch insert failed")
 			job.FailedCount += len(batch)
+			if p.release != nil {
+				for _, source := range batchSources {
+					p.release(source)
+				}
+			}
+			for i, line := range batchLines {
+				keep(line, batchRecords[i], []models.ValidationError{{
+					Line:     line,
+					Message:  "batch insert failed",
+					Severity: models.SeverityError,
+					Code:     validation.CodeInsertFailed,
+				}})
+			}
+			for _, i := range batchResults {
+				pending[i].Status = models.LineFailed
+				pending[i].Errors = append([]models.ValidationError{{
+					Line:     pending[i].Line,
+					Message:  "batch insert failed",
+					Severity: models.SeverityError,
+					Code:     validation.CodeInsertFailed,
+				}}, pending[i].Warnings...)
+				pending[i].Warnings = nil
+			}
 		} else {
 			job.SuccessfulCount += inserted
 		}
 		job.ProcessedCount += len(batch)
+		batch = batch[:0]
+		clear(batchSources)
+		batchSources = batchSources[:0]
+		batchLines = batchLines[:0]
+		clear(batchRecords) // release the records with the batch
+		batchRecords = batchRecords[:0]
+		batchResults = batchResults[:0]
+		if src.report == nil {
+			return nil
+		}
+		return flushResults()
 	}
 
-	// Store validation errors
-	if len(validationErrors) > 0 {
-		s.repos.Job.AddErrors(ctx, job.ID, validationErrors)
-	}
-
-	return scanner.Err()
-}
-
-// processCommentsNDJSON processes comments NDJSON file
-func (s *importService) processCommentsNDJSON(ctx context.Context, job *models.Job) error {
-	file, err := os.Open(job.FilePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-
-	validator := validation.NewValidator()
-	batchSize := s.cfg.Import.BatchSize
-
-	// Pre-load IDs for FK validation
-	userIDs, _ := s.repos.User.GetAllIDs(ctx)
-	articleIDs, _ := s.repos.Article.GetAllIDs(ctx)
-	if len(userIDs) < 100000 {
-		validator.SetUserIDCache(userIDs)
-	}
-	if len(articleIDs) < 100000 {
-		validator.SetArticleIDCache(articleIDs)
-	}
-
-	var batch []*models.Comment
-	var validationErrors []models.ValidationError
-	lineNum := 0
+	for {
+		rec, lineNum, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
 
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
+		var recErr *format.RecordError
+		if err != nil && !errors.As(err, &recErr) {
+			readErr = err
+			break
+		}
 
-		if strings.TrimSpace(line) == "" {
-			continue
+		// Respect context cancellation for long-running imports; the rows
+		// read so far are still accounted for
+		if lineNum%10000 == 0 && ctx.Err() != nil {
+			readErr = ctx.Err()
+			break
 		}
 
 		job.TotalRecords++
 
-		// Respect context cancellation for long-running imports
-		if lineNum%10000 == 0 {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
+		if recErr != nil {
+			malformed := validation.ValidationError{Field: recErr.Field, Message: recErr.Message, Code: validation.CodeMalformedRecord}
+			if readErr = reject(recErr.Line, nil, []validation.ValidationError{malformed}); readErr != nil {
+				break
 			}
+			continue
 		}
 
-		var comment models.CommentNDJSON
-		if err := json.Unmarshal([]byte(line), &comment); err != nil {
-			job.FailedCount++
-			job.ProcessedCount++
-			validationErrors = append(validationErrors, models.ValidationError{
-				Line:    lineNum,
-				Field:   "json",
-				Message: fmt.Sprintf("invalid JSON: %v", err),
-			})
-			if len(validationErrors) >= errorFlushThreshold {
-				s.flushValidationErrors(ctx, job.ID, &validationErrors)
+		raw := rec
+		if transforms != nil {
+			raw = maps.Clone(rec)
+			applied = append(applied, transforms.Apply(rec, lineNum)...)
+			if len(applied) >= errorFlushThreshold {
+				s.flushTransforms(ctx, job.ID, &applied)
 			}
-			continue
 		}
 
-		// Validate
-		errors := validator.ValidateComment(&comment, lineNum)
-		if len(errors) > 0 {
-			job.FailedCount++
-			job.ProcessedCount++
-			for _, e := range errors {
-				validationErrors = append(validationErrors, models.ValidationError{
-					Line:    lineNum,
-					Field:   e.Field,
-					Message: e.Message,
-					Value:   e.Value,
-				})
-			}
-			if len(validationErrors) >= errorFlushThreshold {
-				s.flushValidationErrors(ctx, job.ID, &validationErrors)
+		row, errs := p.bind(rec)
+		if len(errs) == 0 {
+			errs = p.validate(row, lineNum)
+		}
+		// Warnings and info alone do not reject the record
+		if validation.HasErrors(errs) {
+			if readErr = reject(lineNum, raw, errs); readErr != nil {
+				break
 			}
 			continue
 		}
 
-		// Convert to Comment model
-		commentModel := convertNDJSONToComment(&comment)
-		batch = append(batch, commentModel)
+		accept(lineNum, raw, row, p.accept(row), errs)
 
 		// Process batch
 		if len(batch) >= batchSize {
-			inserted, err := s.repos.Comment.BatchInsert(ctx, batch)
-			if err != nil {
-				s.log.Error().Err(err).Int("batch_size", len(batch)).Msg("Batch insert failed")
-				job.FailedCount += len(batch)
-			} else {
-				job.SuccessfulCount += inserted
+			if readErr = insertBatch(); readErr != nil {
+				break
 			}
-			job.ProcessedCount += len(batch)
-			batch = batch[:0]
 
 			s.log.Debug().
 				Str("job_id", job.ID).
@@ -478,24 +701,74 @@ func (s *importService) processCommentsNDJSON(ctx context.Context, job *models.J
 		}
 	}
 
-	// Process remaining batch
+	// Process remaining batch, keeping the first error
 	if len(batch) > 0 {
-		inserted, err := s.repos.Comment.BatchInsert(ctx, batch)
-		if err != nil {
-			s.log.Error().Err(err).Int("batch_size", len(batch)).Msg("Batch insert failed")
-			job.FailedCount += len(batch)
-		} else {
-			job.SuccessfulCount += inserted
+		if err := insertBatch(); err != nil && readErr == nil {
+			readErr = err
 		}
-		job.ProcessedCount += len(batch)
 	}
 
-	// Store validation errors
-	if len(validationErrors) > 0 {
-		s.repos.Job.AddErrors(ctx, job.ID, validationErrors)
+	// Store validation errors, rejected records and the transform audit
+	s.flushValidationErrors(ctx, job.ID, &validationErrors)
+	s.flushRejects(ctx, job.ID, &rejects)
+	s.flushTransforms(ctx, job.ID, &applied)
+
+	return readErr
+}
+
+// blobDecoder closes the stored file along with its decoder
+type blobDecoder struct {
+	format.RecordDecoder
+	file io.ReadCloser
+}
+
+// Header passes on the header of a tabular file, nil for other formats
+func (d *blobDecoder) Header() []string {
+	if h, ok := d.RecordDecoder.(format.HeaderDecoder); ok {
+		return h.Header()
 	}
+	return nil
+}
 
-	return scanner.Err()
+func (d *blobDecoder) Close() error {
+	err := d.RecordDecoder.Close()
+	d.file.Close()
+	return err
+}
+
+// openDecoder opens the job's file from storage with the decoder named by
+// its options or, failing that, registered for its extension
+func openDecoder(ctx context.Context, blobs storage.Blob, job *models.Job, schema *format.Schema) (format.RecordDecoder, error) {
+	var opts format.DecodeOptions
+	var f *format.Format
+	var ok bool
+	if job.Options != nil && job.Options.Format != "" {
+		f, ok = format.Lookup(job.Options.Format)
+		if !ok || f.NewDecoder == nil {
+			return nil, fmt.Errorf("unsupported import file format: %s", job.Options.Format)
+		}
+	} else {
+		ext := fileExt(job.FilePath)
+		f, ok = format.ForExtension(ext)
+		if !ok || f.NewDecoder == nil {
+			return nil, fmt.Errorf("unsupported import file format: %s", ext)
+		}
+	}
+	if job.Options != nil {
+		opts.Sheet = job.Options.Sheet
+	}
+
+	file, err := blobs.Get(ctx, job.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := f.NewDecoder(file, schema, opts)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &blobDecoder{RecordDecoder: decoder, file: file}, nil
 }
 
 // flushValidationErrors writes accumulated errors to the database and resets the slice.
@@ -503,10 +776,39 @@ func (s *importService) processCommentsNDJSON(ctx context.Context, job *models.J
 // each error is ~200 bytes → flushing every 1000 caps memory at ~200KB instead of ~200MB.
 const errorFlushThreshold = 1000
 
+// flushTransforms writes the accumulated transform audit and resets the slice
+func (s *importService) flushTransforms(ctx context.Context, jobID string, applied *[]models.TransformRecord) {
+	if len(*applied) == 0 {
+		return
+	}
+	ctx, cancel := bookkeeping(ctx)
+	defer cancel()
+	if err := s.repos.Job.AddTransforms(ctx, jobID, *applied); err != nil {
+		s.log.Error().Err(err).Int("count", len(*applied)).Msg("Failed to store transform audit")
+	}
+	*applied = (*applied)[:0]
+}
+
+// flushRejects writes the accumulated rejected records and resets the slice
+func (s *importService) flushRejects(ctx context.Context, jobID string, rejects *[]models.RejectedRecord) {
+	if len(*rejects) == 0 {
+		return
+	}
+	ctx, cancel := bookkeeping(ctx)
+	defer cancel()
+	if err := s.repos.Job.AddRejects(ctx, jobID, *rejects); err != nil {
+		s.log.Error().Err(err).Int("count", len(*rejects)).Msg("Failed to store rejected records")
+	}
+	*rejects = (*rejects)[:0]
+}
+
+// flushValidationErrors writes the accumulated errors and resets the slice
 func (s *importService) flushValidationErrors(ctx context.Context, jobID string, errors *[]models.ValidationError) {
 	if len(*errors) == 0 {
 		return
 	}
+	ctx, cancel := bookkeeping(ctx)
+	defer cancel()
 	if err := s.repos.Job.AddErrors(ctx, jobID, *errors); err != nil {
 		s.log.Error().Err(err).Int("count", len(*errors)).Msg("Failed to flush validation errors")
 	}
@@ -515,26 +817,50 @@ func (s *importService) flushValidationErrors(ctx context.Context, jobID string,
 
 // Helper functions
 
-func getField(record []string, headerMap map[string]int, field string) string {
-	if idx, ok := headerMap[field]; ok && idx < len(record) {
-		return strings.TrimSpace(record[idx])
+// fileExt returns the lower-cased extension of an uploaded file, which
+// selects the decoder used to parse it
+func fileExt(path string) string {
+	return strings.ToLower(filepath.Ext(path))
+}
+
+// timestampOrNow parses an optional timestamp, falling back to the import
+// time when it is missing or, as validation warned, unreadable
+func timestampOrNow(times *validation.TimeParser, value string) time.Time {
+	if t, err := times.Parse(value); err == nil {
+		return t
+	}
+	return time.Now()
+}
+
+// sourceTime parses an optional timestamp, nil when it is missing or, as
+// validation warned, unreadable
+func sourceTime(times *validation.TimeParser, value string) *time.Time {
+	if t, err := times.Parse(value); err == nil {
+		return &t
 	}
-	return ""
+	return nil
 }
 
-func convertCSVToUser(csv *models.UserCSV) *models.User {
-	createdAt, _ := time.Parse(time.RFC3339, csv.CreatedAt)
+// The converters read timestamps with the parser validation accepted them
+// with, so a row that passed is stored with the instant that was checked.
+// updated_at is stamped when the row is written, so incremental exports see
+// every imported row; the file's own value is kept as source_updated_at.
+
+func convertCSVToUser(csv *models.UserCSV, times *validation.TimeParser) *models.User {
+	createdAt, _ := times.Parse(csv.CreatedAt)
 	return &models.User{
-		ID:        csv.ID,
-		Email:     csv.Email,
-		Name:      csv.Name,
-		Role:      csv.Role,
-		Active:    csv.Active == "true",
-		CreatedAt: createdAt,
+		ID:              csv.ID,
+		Email:           csv.Email,
+		Name:            csv.Name,
+		Role:            csv.Role,
+		Active:          csv.Active == "true",
+		CreatedAt:       createdAt,
+		UpdatedAt:       time.Now(),
+		SourceUpdatedAt: sourceTime(times, csv.UpdatedAt),
 	}
 }
 
-func convertNDJSONToArticle(ndjson *models.ArticleNDJSON) *models.Article {
+func convertNDJSONToArticle(ndjson *models.ArticleNDJSON, times *validation.TimeParser) *models.Article {
 	article := &models.Article{
 		ID:       ndjson.ID,
 		Slug:     ndjson.Slug,
@@ -548,20 +874,24 @@ func convertNDJSONToArticle(ndjson *models.ArticleNDJSON) *models.Article {
 		article.Status = "draft"
 	}
 	if ndjson.PublishedAt != "" {
-		t, _ := time.Parse(time.RFC3339, ndjson.PublishedAt)
+		t, _ := times.Parse(ndjson.PublishedAt)
 		article.PublishedAt = &t
 	}
-	article.CreatedAt = time.Now()
+	article.CreatedAt = timestampOrNow(times, ndjson.CreatedAt)
+	article.UpdatedAt = time.Now()
+	article.SourceUpdatedAt = sourceTime(times, ndjson.UpdatedAt)
 	return article
 }
 
-func convertNDJSONToComment(ndjson *models.CommentNDJSON) *models.Comment {
-	createdAt, _ := time.Parse(time.RFC3339, ndjson.CreatedAt)
+func convertNDJSONToComment(ndjson *models.CommentNDJSON, times *validation.TimeParser) *models.Comment {
+	createdAt, _ := times.Parse(ndjson.CreatedAt)
 	return &models.Comment{
-		ID:        ndjson.ID,
-		ArticleID: ndjson.ArticleID,
-		UserID:    ndjson.UserID,
-		Body:      ndjson.Body,
-		CreatedAt: createdAt,
+		ID:              ndjson.ID,
+		ArticleID:       ndjson.ArticleID,
+		UserID:          ndjson.UserID,
+		Body:            ndjson.Body,
+		CreatedAt:       createdAt,
+		UpdatedAt:       time.Now(),
+		SourceUpdatedAt: sourceTime(times, ndjson.UpdatedAt),
 	}
 }
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/config"
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/repository"
+	"github.com/bulk-import-export-api/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// janitorPageSize bounds how many jobs are loaded per query
+	janitorPageSize = 500
+	// maxReportedArtifacts bounds the removed list in a cleanup report
+	maxReportedArtifacts = 100
+)
+
+// retentionRule says how long the source files of imports in a set of
+// statuses are kept after the import finishes. Export jobs write to a
+// client's bucket and leave no file of ours behind.
+type retentionRule struct {
+	statuses []models.JobStatus
+	keep     time.Duration
+}
+
+type janitorService struct {
+	repos *repository.Repositories
+	blobs storage.Blob
+	cfg   *config.Config
+	log   zerolog.Logger
+}
+
+// newJanitorService creates a new JanitorService
+func newJanitorService(repos *repository.Repositories, blobs storage.Blob, cfg *config.Config, log zerolog.Logger) *janitorService {
+	return &janitorService{
+		repos: repos,
+		blobs: blobs,
+		cfg:   cfg,
+		log:   log.With().Str("service", "janitor").Logger(),
+	}
+}
+
+// rules returns the file retention rules from config
+func (s *janitorService) rules() []retentionRule {
+	r := s.cfg.Retention
+	return []retentionRule{
+		{[]models.JobStatus{models.JobStatusCompleted}, r.CompletedUploads},
+		{[]models.JobStatus{models.JobStatusFailed, models.JobStatusCancelled}, r.FailedUploads},
+	}
+}
+
+// Run deletes the files of finished jobs past their retention, then the
+// job rows past theirs. A file that cannot be deleted is counted as a
+// failure and its job is kept for the next run.
+func (s *janitorService) Run(ctx context.Context) (*models.CleanupReport, error) {
+	report := &models.CleanupReport{StartedAt: time.Now()}
+
+	for _, rule := range s.rules() {
+		if rule.keep < 0 {
+			continue
+		}
+		if err := s.applyRule(ctx, rule, report); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.cfg.Retention.Jobs >= 0 {
+		deleted, err := s.repos.Job.DeleteFinishedBefore(ctx, report.StartedAt.Add(-s.cfg.Retention.Jobs))
+		if err != nil {
+			return nil, err
+		}
+		report.JobsDeleted = deleted
+	}
+
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+	if report.UploadsDeleted+report.JobsDeleted+report.Failures > 0 {
+		s.log.Info().
+			Int("uploads_deleted", report.UploadsDeleted).
+			Int("jobs_deleted", report.JobsDeleted).
+			Int64("bytes_freed", report.BytesFreed).
+			Int("failures", report.Failures).
+			Msg("Cleanup completed")
+	}
+	return report, nil
+}
+
+// applyRule removes the files of every job matched by rule, a page at a time
+func (s *janitorService) applyRule(ctx context.Context, rule retentionRule, report *models.CleanupReport) error {
+	before := report.StartedAt.Add(-rule.keep)
+	afterID := ""
+	for {
+		jobs, err := s.repos.Job.GetFinishedWithFiles(ctx, models.JobTypeImport, rule.statuses, before, afterID, janitorPageSize)
+		if err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			afterID = job.ID
+			// Objects in a client's bucket are theirs, not ours to delete
+			if storage.IsS3(job.FilePath) {
+				continue
+			}
+
+			size, err := s.removeFile(ctx, job.FilePath)
+			if err == nil {
+				err = s.repos.Job.ClearFilePath(ctx, job.ID)
+			}
+			if err != nil {
+				s.log.Error().Err(err).Str("job_id", job.ID).Str("location", job.FilePath).Msg("Failed to remove job file")
+				report.Failures++
+				continue
+			}
+
+			report.UploadsDeleted++
+			report.BytesFreed += size
+			if len(report.Removed) < maxReportedArtifacts {
+				report.Removed = append(report.Removed, models.RemovedArtifact{
+					JobID:    job.ID,
+					Status:   job.Status,
+					Location: job.FilePath,
+					Size:     size,
+				})
+			}
+		}
+		if len(jobs) < janitorPageSize {
+			return nil
+		}
+	}
+}
+
+// removeFile deletes the artifact at location and returns its size. A
+// bundle job's location is the directory its archive was extracted into,
+// so everything left under it goes too. A file already gone is not an
+// error.
+func (s *janitorService) removeFile(ctx context.Context, location string) (int64, error) {
+	info, err := s.blobs.Stat(ctx, location)
+	if err == nil {
+		return info.Size, s.blobs.Delete(ctx, location)
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return 0, err
+	}
+
+	infos, err := s.blobs.List(ctx, location+"/")
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, info := range infos {
+		if err := s.blobs.Delete(ctx, info.Key); err != nil {
+			return size, err
+		}
+		size += info.Size
+	}
+	// Removes the directory itself once empty on a local disk
+	return size, s.blobs.Delete(ctx, location)
+}
+
+// Start runs the janitor periodically until ctx is done
+func (s *janitorService) Start(ctx context.Context) {
+	if s.cfg.Retention.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.cfg.Retention.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Run(ctx); err != nil {
+				s.log.Error().Err(err).Msg("Cleanup run failed")
+			}
+		}
+	}
+}
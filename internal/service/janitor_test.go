@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/storage"
+)
+
+// finishedJob records a finished job holding file, completed age ago
+func finishedJob(h *testHarness, id string, jobType models.JobType, status models.JobStatus, file string, age time.Duration) *models.Job {
+	completedAt := time.Now().Add(-age)
+	job := &models.Job{
+		ID:          id,
+		Type:        jobType,
+		Resource:    "users",
+		Status:      status,
+		FilePath:    file,
+		CreatedAt:   completedAt,
+		CompletedAt: &completedAt,
+	}
+	h.jobRepo.Create(context.Background(), job)
+	return job
+}
+
+func TestJanitor_AppliesRetentionPerStatus(t *testing.T) {
+	h := newTestHarness(t)
+	h.cfg.Retention.CompletedUploads = 0
+	h.cfg.Retention.FailedUploads = 7 * 24 * time.Hour
+	h.cfg.Retention.Jobs = -1
+	day := 24 * time.Hour
+
+	done := finishedJob(h, "a-done", models.JobTypeImport, models.JobStatusCompleted, h.put(t, "done.csv", []byte("12345")), time.Minute)
+	recentFail := finishedJob(h, "b-failed-recent", models.JobTypeImport, models.JobStatusFailed, h.put(t, "recent.csv", []byte("x")), day)
+	oldFail := finishedJob(h, "c-failed-old", models.JobTypeImport, models.JobStatusFailed, h.put(t, "old.csv", []byte("xyz")), 8*day)
+	remote := finishedJob(h, "d-remote", models.JobTypeImport, models.JobStatusCompleted, h.put(t, "s3://in/users.csv", []byte("x")), day)
+	remoteExport := finishedJob(h, "e-remote-export", models.JobTypeExport, models.JobStatusCompleted, h.put(t, "s3://out/users.ndjson", []byte("xy")), 2*day)
+	running := finishedJob(h, "f-running", models.JobTypeImport, models.JobStatusProcessing, h.put(t, "running.csv", []byte("x")), day)
+
+	report, err := h.services.Janitor.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if report.UploadsDeleted != 2 {
+		t.Errorf("Expected 2 uploads deleted, got %+v", report)
+	}
+	if report.BytesFreed != 8 {
+		t.Errorf("Expected 8 bytes freed, got %d", report.BytesFreed)
+	}
+	if len(report.Removed) != 2 || report.Removed[0].JobID != done.ID {
+		t.Errorf("Expected removed artifacts listed by job, got %+v", report.Removed)
+	}
+
+	for _, job := range []*models.Job{done, oldFail} {
+		if job.FilePath != "" {
+			t.Errorf("Expected file path of %s cleared, got %q", job.ID, job.FilePath)
+		}
+	}
+	for _, key := range []string{"done.csv", "old.csv"} {
+		if _, err := h.blobs.Stat(context.Background(), key); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected %s deleted, got %v", key, err)
+		}
+	}
+	for _, job := range []*models.Job{recentFail, remote, remoteExport, running} {
+		if _, err := h.blobs.Stat(context.Background(), job.FilePath); err != nil {
+			t.Errorf("Expected file of %s kept, got %v", job.ID, err)
+		}
+	}
+}
+
+func TestJanitor_RemovesBundleDirectoriesAndOldJobs(t *testing.T) {
+	h := newTestHarness(t)
+	h.cfg.Retention.Jobs = time.Hour
+
+	parent := finishedJob(h, "bundle", models.JobTypeImport, models.JobStatusCompleted, "bundle_1", 2*time.Hour)
+	h.put(t, "bundle_1/entry_000", []byte("abc"))
+	h.put(t, "bundle_1/entry_001", []byte("de"))
+	child := finishedJob(h, "bundle-child", models.JobTypeImport, models.JobStatusCompleted, "bundle_1/entry_000", 2*time.Hour)
+	child.ParentJobID = parent.ID
+	recent := finishedJob(h, "recent", models.JobTypeImport, models.JobStatusCompleted, "", time.Minute)
+	h.jobRepo.AddError(context.Background(), parent.ID, &models.ValidationError{Line: 1, Message: "bad"})
+
+	report, err := h.services.Janitor.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if report.UploadsDeleted != 2 || report.BytesFreed != 5 {
+		t.Errorf("Expected bundle directory and child file removed, got %+v", report)
+	}
+	if infos, _ := h.blobs.List(context.Background(), "bundle_1/"); len(infos) != 0 {
+		t.Errorf("Expected bundle directory emptied, got %v", infos)
+	}
+	if report.JobsDeleted != 1 {
+		t.Errorf("Expected 1 job deleted, got %d", report.JobsDeleted)
+	}
+	if _, ok := h.jobRepo.Jobs[child.ID]; ok {
+		t.Error("Expected child job deleted with its parent")
+	}
+	if len(h.jobRepo.Errors[parent.ID]) != 0 {
+		t.Error("Expected errors deleted with their job")
+	}
+	if _, ok := h.jobRepo.Jobs[recent.ID]; !ok {
+		t.Error("Expected recent job kept")
+	}
+}
+
+// Jobs that only name objects in a client's bucket hold nothing of ours:
+// their rows expire while the objects are left alone
+func TestJanitor_DeletesJobsOfClientObjects(t *testing.T) {
+	h := newTestHarness(t)
+	h.cfg.Retention.Jobs = time.Hour
+
+	export := finishedJob(h, "export", models.JobTypeExport, models.JobStatusCompleted, h.put(t, "s3://out/users.ndjson", []byte("xy")), 2*time.Hour)
+	remote := finishedJob(h, "remote", models.JobTypeImport, models.JobStatusFailed, h.put(t, "s3://in/users.csv", []byte("x")), 2*time.Hour)
+
+	report, err := h.services.Janitor.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.JobsDeleted != 2 || report.UploadsDeleted != 0 {
+		t.Errorf("Expected both jobs deleted and no file removed, got %+v", report)
+	}
+	for _, job := range []*models.Job{export, remote} {
+		if _, err := h.blobs.Stat(context.Background(), job.FilePath); err != nil {
+			t.Errorf("Expected %s kept, got %v", job.FilePath, err)
+		}
+	}
+}
+
+func TestJanitor_NegativeRetentionKeepsForever(t *testing.T) {
+	h := newTestHarness(t)
+	h.cfg.Retention.CompletedUploads = -1
+	h.cfg.Retention.Jobs = -1
+
+	job := finishedJob(h, "kept", models.JobTypeImport, models.JobStatusCompleted, h.put(t, "kept.csv", []byte("x")), 365*24*time.Hour)
+
+	report, err := h.services.Janitor.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.UploadsDeleted != 0 || report.JobsDeleted != 0 || job.FilePath == "" {
+		t.Errorf("Expected nothing removed, got %+v", report)
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bulk-import-export-api/internal/models"
+)
+
+// ErrInvalidErrorQuery is returned for an error report filter that cannot
+// be served
+var ErrInvalidErrorQuery = errors.New("invalid error query")
+
+// CheckErrorFilter validates the conditions of an error report filter
+func CheckErrorFilter(filter models.ErrorFilter) error {
+	if filter.FromLine < 0 || filter.ToLine < 0 {
+		return fmt.Errorf("%w: lines must be positive", ErrInvalidErrorQuery)
+	}
+	if filter.ToLine > 0 && filter.FromLine > filter.ToLine {
+		return fmt.Errorf("%w: from_line must not be after to_line", ErrInvalidErrorQuery)
+	}
+	switch filter.Severity {
+	case "", models.SeverityError, models.SeverityWarning, models.SeverityInfo:
+		return nil
+	default:
+		return fmt.Errorf("%w: severity must be %s, %s or %s", ErrInvalidErrorQuery,
+			models.SeverityError, models.SeverityWarning, models.SeverityInfo)
+	}
+}
+
+// ListJobErrors returns one page of the issues a filter selects, in line
+// order, with the counts of all of them by severity. Only the page is read
+// into memory.
+func (s *jobService) ListJobErrors(ctx context.Context, filter models.ErrorFilter) (*models.ErrorPage, error) {
+	if err := CheckErrorFilter(filter); err != nil {
+		return nil, err
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	// One extra row tells whether another page follows
+	filter.Limit = limit + 1
+
+	page := &models.ErrorPage{JobID: filter.JobID, Errors: []models.ValidationError{}, Limit: limit}
+	err := s.jobRepo.StreamErrors(ctx, filter, func(e *models.ValidationError) error {
+		page.Errors = append(page.Errors, *e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Errors) > limit {
+		page.Errors = page.Errors[:limit]
+		last := page.Errors[limit-1]
+		page.NextCursor = EncodeErrorCursor(models.ErrorCursor{Line: last.Line, ID: last.ID})
+	}
+	page.Count = len(page.Errors)
+
+	counts, err := s.jobRepo.CountErrors(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	page.ErrorCount = counts[models.SeverityError]
+	page.WarningCount = counts[models.SeverityWarning]
+	return page, nil
+}
+
+// StreamJobErrors calls callback for each issue a filter selects, in line
+// order, as they are read
+func (s *jobService) StreamJobErrors(ctx context.Context, filter models.ErrorFilter, callback func(*models.ValidationError) error) error {
+	if err := CheckErrorFilter(filter); err != nil {
+		return err
+	}
+	return s.jobRepo.StreamErrors(ctx, filter, callback)
+}
+
+// SummarizeJobErrors counts the issues a filter selects by severity and
+// groups them by field, message, severity and code, the most frequent of
+// up to filter.Limit groups first
+func (s *jobService) SummarizeJobErrors(ctx context.Context, filter models.ErrorFilter) (*models.ErrorSummary, error) {
+	if err := CheckErrorFilter(filter); err != nil {
+		return nil, err
+	}
+	filter.After = nil
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+
+	counts, err := s.jobRepo.CountErrors(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	// One extra group tells whether the summary is cut short
+	groups, err := s.jobRepo.SummarizeErrors(ctx, filter, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ErrorSummary{
+		JobID:        filter.JobID,
+		ErrorCount:   counts[models.SeverityError],
+		WarningCount: counts[models.SeverityWarning],
+		InfoCount:    counts[models.SeverityInfo],
+		Groups:       groups,
+	}
+	if summary.Groups == nil {
+		summary.Groups = []models.ErrorGroup{}
+	}
+	if len(groups) > limit {
+		summary.Groups = groups[:limit]
+		summary.Truncated = true
+	}
+	return summary, nil
+}
+
+// EncodeErrorCursor returns the opaque next_cursor of an error report page
+func EncodeErrorCursor(c models.ErrorCursor) string {
+	return encodeToken(c)
+}
+
+// DecodeErrorCursor parses a next_cursor returned by the error report API
+func DecodeErrorCursor(token string) (models.ErrorCursor, error) {
+	var c models.ErrorCursor
+	return c, decodeToken(token, &c)
+}
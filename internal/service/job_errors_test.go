@@ -0,0 +1,164 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+)
+
+func seedErrors(h *testHarness, jobID string) {
+	h.jobRepo.Errors[jobID] = []models.ValidationError{
+		{Line: 2, Field: "email", Message: "invalid email format", Code: "pattern"},
+		{Line: 3, Field: "role", Message: "invalid role", Code: "enum"},
+		{Line: 2, Field: "role", Message: "invalid role", Code: "enum"},
+		{Line: 5, Field: "email", Message: "invalid email format", Code: "pattern"},
+		{Line: 7, Field: "updated_at", Message: "import time used instead", Severity: models.SeverityWarning, Code: "timestamp"},
+		{Line: 9, Field: "email", Message: "invalid email format", Code: "pattern"},
+	}
+}
+
+func TestListJobErrors_PagesInLineOrder(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+	seedErrors(h, "job-1")
+
+	filter := models.ErrorFilter{JobID: "job-1", Limit: 2}
+	var lines []int
+	pages := 0
+	for {
+		page, err := h.services.Job.ListJobErrors(ctx, filter)
+		if err != nil {
+			t.Fatalf("ListJobErrors failed: %v", err)
+		}
+		pages++
+		if page.ErrorCount != 5 || page.WarningCount != 1 {
+			t.Errorf("Expected the counts of every issue on each page, got %d errors and %d warnings", page.ErrorCount, page.WarningCount)
+		}
+		for _, e := range page.Errors {
+			lines = append(lines, e.Line)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		after, err := service.DecodeErrorCursor(page.NextCursor)
+		if err != nil {
+			t.Fatalf("Expected a valid cursor, got %v", err)
+		}
+		filter.After = &after
+	}
+
+	// Both issues of line 2 come in the order they were found, across a page break
+	want := []int{2, 2, 3, 5, 7, 9}
+	if pages != 3 || len(lines) != len(want) {
+		t.Fatalf("Expected %v in 3 pages, got %v in %d", want, lines, pages)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("Expected lines %v, got %v", want, lines)
+			break
+		}
+	}
+}
+
+func TestListJobErrors_Filters(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+	seedErrors(h, "job-1")
+
+	tests := []struct {
+		name   string
+		filter models.ErrorFilter
+		want   int
+	}{
+		{"field", models.ErrorFilter{Field: "email"}, 3},
+		{"message", models.ErrorFilter{Message: "invalid role"}, 2},
+		{"severity", models.ErrorFilter{Severity: models.SeverityError}, 5},
+		{"code", models.ErrorFilter{Code: "timestamp"}, 1},
+		{"line range", models.ErrorFilter{FromLine: 3, ToLine: 7}, 3},
+		{"combined", models.ErrorFilter{Field: "email", FromLine: 3}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.JobID = "job-1"
+			page, err := h.services.Job.ListJobErrors(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("ListJobErrors failed: %v", err)
+			}
+			if page.Count != tt.want || page.ErrorCount+page.WarningCount != tt.want {
+				t.Errorf("Expected %d issues counted, got %d (%d errors, %d warnings)", tt.want, page.Count, page.ErrorCount, page.WarningCount)
+			}
+		})
+	}
+}
+
+func TestStreamJobErrors(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+	seedErrors(h, "job-1")
+
+	var lines []int
+	err := h.services.Job.StreamJobErrors(ctx, models.ErrorFilter{JobID: "job-1", Field: "email"}, func(e *models.ValidationError) error {
+		lines = append(lines, e.Line)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamJobErrors failed: %v", err)
+	}
+	if len(lines) != 3 || lines[0] != 2 || lines[2] != 9 {
+		t.Errorf("Expected the email issues of lines 2, 5 and 9, got %v", lines)
+	}
+}
+
+func TestSummarizeJobErrors(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+	seedErrors(h, "job-1")
+
+	summary, err := h.services.Job.SummarizeJobErrors(ctx, models.ErrorFilter{JobID: "job-1"})
+	if err != nil {
+		t.Fatalf("SummarizeJobErrors failed: %v", err)
+	}
+	if summary.ErrorCount != 5 || summary.WarningCount != 1 || summary.Truncated {
+		t.Errorf("Expected 5 errors and 1 warning in full, got %+v", summary)
+	}
+	if len(summary.Groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %+v", summary.Groups)
+	}
+	first := summary.Groups[0]
+	if first.Field != "email" || first.Count != 3 || first.FirstLine != 2 || first.LastLine != 9 {
+		t.Errorf("Expected the email errors first, on lines 2 to 9, got %+v", first)
+	}
+
+	// The most frequent groups are kept
+	summary, _ = h.services.Job.SummarizeJobErrors(ctx, models.ErrorFilter{JobID: "job-1", Limit: 1})
+	if len(summary.Groups) != 1 || !summary.Truncated || summary.ErrorCount != 5 {
+		t.Errorf("Expected 1 group of a truncated summary with full counts, got %+v", summary)
+	}
+}
+
+func TestJobErrors_InvalidFilter(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		filter models.ErrorFilter
+	}{
+		{"reversed lines", models.ErrorFilter{JobID: "job-1", FromLine: 9, ToLine: 2}},
+		{"negative line", models.ErrorFilter{JobID: "job-1", FromLine: -1}},
+		{"unknown severity", models.ErrorFilter{JobID: "job-1", Severity: "fatal"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := h.services.Job.ListJobErrors(ctx, tt.filter); !errors.Is(err, service.ErrInvalidErrorQuery) {
+				t.Errorf("Expected ErrInvalidErrorQuery, got %v", err)
+			}
+			if _, err := h.services.Job.SummarizeJobErrors(ctx, tt.filter); !errors.Is(err, service.ErrInvalidErrorQuery) {
+				t.Errorf("Expected ErrInvalidErrorQuery from the summary, got %v", err)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 type jobService struct {
 	jobRepo       repository.JobRepository
 	importService ImportService
+	exportService ExportService
 	log           zerolog.Logger
 	ctx           context.Context
 	cancel        context.CancelFunc
@@ -53,6 +54,11 @@ func (s *jobService) SetImportService(importService ImportService) {
 	s.importService = importService
 }
 
+// SetExportService sets the export service for job processing
+func (s *jobService) SetExportService(exportService ExportService) {
+	s.exportService = exportService
+}
+
 // StartProcessor starts the background job processor
 func (s *jobService) StartProcessor(ctx context.Context) {
 	s.mu.Lock()
@@ -163,8 +169,12 @@ func (s *jobService) processJob(job *models.Job) {
 			}
 		}
 	case models.JobTypeExport:
-		// Export jobs are handled synchronously for streaming
-		s.log.Warn().Str("job_id", job.ID).Msg("Export job not processed in background")
+		// Only exports to a destination are queued; downloads stream
+		if s.exportService != nil {
+			if err := s.exportService.ProcessExport(s.ctx, job); err != nil {
+				s.log.Error().Err(err).Str("job_id", job.ID).Msg("Export processing failed")
+			}
+		}
 	}
 }
 
@@ -190,20 +200,55 @@ func (s *jobService) GetJob(ctx context.Context, id string) (*models.JobResponse
 		ErrorCount: job.FailedCount,
 	}
 
-	// Add error report URL if there are errors
-	if job.FailedCount > 0 {
+	// Add error report URL if there are errors or warnings
+	if job.FailedCount > 0 || job.WarningCount > 0 {
 		response.ErrorReport = "/v1/imports/" + job.ID + "/errors"
 	}
+	if job.Type == models.JobTypeImport && job.FailedCount > 0 {
+		response.RejectsURL = "/v1/imports/" + job.ID + "/rejects"
+	}
+
+	// A bundle's errors are recorded on its children, each with its own
+	// report; an import's children are its retries
+	if job.Type == models.JobTypeImport {
+		children, err := s.jobRepo.GetChildren(ctx, id)
+		if err != nil {
+			s.log.Error().Err(err).Str("job_id", id).Msg("Failed to get child jobs")
+		}
+		if job.Resource == models.ResourceBundle {
+			response.ErrorReport = ""
+			response.RejectsURL = ""
+			response.Children = childResponses(children)
+		} else {
+			response.Retries = childResponses(children)
+		}
+	}
 
 	return response, nil
 }
 
+// childResponses summarizes child jobs, each with its own reports
+func childResponses(children []*models.Job) []*models.JobResponse {
+	var responses []*models.JobResponse
+	for _, child := range children {
+		childResponse := &models.JobResponse{Job: *child, ErrorCount: child.FailedCount}
+		if child.FailedCount > 0 || child.WarningCount > 0 {
+			childResponse.ErrorReport = "/v1/imports/" + child.ID + "/errors"
+		}
+		if child.FailedCount > 0 {
+			childResponse.RejectsURL = "/v1/imports/" + child.ID + "/rejects"
+		}
+		responses = append(responses, childResponse)
+	}
+	return responses
+}
+
 // GetJobByIdempotencyKey retrieves a job by idempotency key
 func (s *jobService) GetJobByIdempotencyKey(ctx context.Context, key string) (*models.Job, error) {
 	return s.jobRepo.GetByIdempotencyKey(ctx, key)
 }
 
-// GetJobErrors retrieves all validation errors for a job
-func (s *jobService) GetJobErrors(ctx context.Context, id string) ([]models.ValidationError, error) {
-	return s.jobRepo.GetErrors(ctx, id, 0)
+// GetJobTransforms retrieves the transform audit of a job
+func (s *jobService) GetJobTransforms(ctx context.Context, id string) ([]models.TransformRecord, error) {
+	return s.jobRepo.GetTransforms(ctx, id, 0)
 }
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+
+	"github.com/bulk-import-export-api/internal/format"
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/repository"
+	"github.com/bulk-import-export-api/internal/validation"
+)
+
+// ErrInvalidRejects is returned when a job's rejects cannot be written as
+// requested
+var ErrInvalidRejects = errors.New("invalid rejects request")
+
+// Formats of a rejects file
+const (
+	RejectsCSV    = "csv"
+	RejectsNDJSON = "ndjson"
+)
+
+// RejectsErrorsColumn holds why each record of a rejects file was rejected.
+// Imports ignore unknown columns, so the file can be fixed and uploaded
+// again as it is.
+const RejectsErrorsColumn = "_errors"
+
+// RejectsFormat returns the format a job's rejects file is written in:
+// requested if set, otherwise the job's source format as far as it can be
+// repeated (CSV for tabular files, whose header is kept, NDJSON for the
+// rest)
+func RejectsFormat(job *models.Job, requested string) (string, error) {
+	if job.Type != models.JobTypeImport {
+		return "", fmt.Errorf("%w: only import jobs reject records", ErrInvalidRejects)
+	}
+	if job.Resource == models.ResourceBundle {
+		return "", fmt.Errorf("%w: a bundle's rejects are kept per child job", ErrInvalidRejects)
+	}
+	switch requested {
+	case RejectsCSV, RejectsNDJSON:
+		return requested, nil
+	case "":
+		if len(job.SourceHeader) > 0 {
+			return RejectsCSV, nil
+		}
+		return RejectsNDJSON, nil
+	default:
+		return "", fmt.Errorf("%w: format must be %s or %s", ErrInvalidRejects, RejectsCSV, RejectsNDJSON)
+	}
+}
+
+// WriteRejects writes the records a job rejected to w in line order, each
+// with its errors in the _errors column
+func (s *jobService) WriteRejects(ctx context.Context, job *models.Job, formatName string, w io.Writer) error {
+	_, err := writeRejects(ctx, s.jobRepo, job, formatName, false, w)
+	return err
+}
+
+// writeRejects writes a job's rejects, or only the retryable ones, and
+// returns how many it wrote
+func writeRejects(ctx context.Context, jobs repository.JobRepository, job *models.Job, formatName string, retryableOnly bool, w io.Writer) (int, error) {
+	if formatName == RejectsCSV {
+		return writeRejectsCSV(ctx, jobs, job, retryableOnly, w)
+	}
+
+	count := 0
+	buf := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buf)
+	err := jobs.StreamRejects(ctx, job.ID, retryableOnly, func(rej *models.RejectedRecord) error {
+		obj := maps.Clone(rej.Record)
+		if obj == nil {
+			obj = map[string]any{}
+		}
+		obj[RejectsErrorsColumn] = rej.Errors
+		count++
+		return encoder.Encode(obj)
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, buf.Flush()
+}
+
+// writeRejectsCSV writes rejects under the source file's header as
+// written, or the resource's fields when the source was not tabular
+func writeRejectsCSV(ctx context.Context, jobs repository.JobRepository, job *models.Job, retryableOnly bool, w io.Writer) (int, error) {
+	columns := rejectColumns(job)
+	keys := format.NormalizeHeader(columns)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append(columns, RejectsErrorsColumn)); err != nil {
+		return 0, err
+	}
+	count := 0
+	row := make([]string, len(columns)+1)
+	err := jobs.StreamRejects(ctx, job.ID, retryableOnly, func(rej *models.RejectedRecord) error {
+		rec := format.Record(rej.Record)
+		for i, key := range keys {
+			row[i] = rec.Text(key)
+		}
+		row[len(columns)] = rej.Errors
+		count++
+		return writer.Write(row)
+	})
+	if err != nil {
+		return count, err
+	}
+	writer.Flush()
+	return count, writer.Error()
+}
+
+// rejectColumns returns the columns of a CSV rejects file before _errors.
+// An _errors column of a re-uploaded rejects file is dropped, as the new
+// errors replace it.
+func rejectColumns(job *models.Job) []string {
+	var columns []string
+	if len(job.SourceHeader) > 0 {
+		for _, col := range job.SourceHeader {
+			if strings.ToLower(strings.TrimSpace(col)) != RejectsErrorsColumn {
+				columns = append(columns, col)
+			}
+		}
+		return columns
+	}
+	if schema, ok := resourceSchemas[job.Resource]; ok {
+		for _, f := range schema.Fields {
+			columns = append(columns, f.Name)
+		}
+	}
+	return columns
+}
+
+// rejectReason summarizes the errors that rejected a record as
+// "field: message" pairs; warnings are left to the error report
+func rejectReason(issues []models.ValidationError) string {
+	var reasons []string
+	for _, e := range issues {
+		if e.Severity != "" && e.Severity != models.SeverityError {
+			continue
+		}
+		if e.Field == "" {
+			reasons = append(reasons, e.Message)
+		} else {
+			reasons = append(reasons, e.Field+": "+e.Message)
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// retryable reports whether every error that rejected a record came from
+// the state of the database
+func retryable(issues []models.ValidationError) bool {
+	found := false
+	for _, e := range issues {
+		if e.Severity != "" && e.Severity != models.SeverityError {
+			continue
+		}
+		if !validation.Retryable(e.Code) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
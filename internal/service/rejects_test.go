@@ -0,0 +1,176 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+)
+
+const rejectsUsersCSV = "ID,Email,Name,Role,Active,Created_At\n" +
+	"11111111-1111-1111-1111-111111111111,ada@example.com,Ada,admin,true,2024-01-01T00:00:00Z\n" +
+	"22222222-2222-2222-2222-222222222222,not-an-email,Bob,viewer,true,2024-01-01T00:00:00Z\n" +
+	"33333333-3333-3333-3333-333333333333,cy@example.com,Cy,wizard,false,2024-01-01T00:00:00Z\n"
+
+func TestRejects_CSVKeepsHeaderAndReuploads(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	job := createTestJob(h, "users", h.put(t, "users.csv", []byte(rejectsUsersCSV)))
+	if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport failed: %v", err)
+	}
+	if job.FailedCount != 2 {
+		t.Fatalf("Expected 2 rejected rows, got %d", job.FailedCount)
+	}
+
+	formatName, err := service.RejectsFormat(job, "")
+	if err != nil || formatName != service.RejectsCSV {
+		t.Fatalf("Expected CSV rejects for a CSV source, got %q (%v)", formatName, err)
+	}
+	var out bytes.Buffer
+	if err := h.services.Job.WriteRejects(ctx, job, formatName, &out); err != nil {
+		t.Fatalf("WriteRejects failed: %v", err)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected a valid CSV file, got %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 rejected rows, got %q", rows)
+	}
+	if got := strings.Join(rows[0], ","); got != "ID,Email,Name,Role,Active,Created_At,_errors" {
+		t.Errorf("Expected the original header plus _errors, got %s", got)
+	}
+	if rows[1][1] != "not-an-email" || !strings.HasPrefix(rows[1][6], "email: ") {
+		t.Errorf("Expected line 2 as read with its email error, got %q", rows[1])
+	}
+	if rows[2][3] != "wizard" || !strings.HasPrefix(rows[2][6], "role: ") {
+		t.Errorf("Expected line 3 as read with its role error, got %q", rows[2])
+	}
+
+	// Fixed in place, the file is uploaded again; _errors is ignored
+	fixed := strings.NewReplacer("not-an-email", "bob@example.com", "wizard", "viewer").Replace(out.String())
+	retry := createTestJob(h, "users", h.put(t, "users_fixed.csv", []byte(fixed)))
+	if err := h.services.Import.ProcessImport(ctx, retry); err != nil {
+		t.Fatalf("ProcessImport of the rejects file failed: %v", err)
+	}
+	if retry.SuccessfulCount != 2 || retry.FailedCount != 0 {
+		t.Errorf("Expected both fixed rows imported, got %d/%d: %+v", retry.SuccessfulCount, retry.FailedCount, h.jobRepo.Errors[retry.ID])
+	}
+}
+
+func TestRejects_KeepsRowsOfFailedInserts(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+	h.userRepo.BatchInsertFunc = func(ctx context.Context, users []*models.User) (int, error) {
+		return 0, context.DeadlineExceeded
+	}
+
+	job := createTestJob(h, "users", h.put(t, "users.csv", []byte(rejectsUsersCSV)))
+	if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport failed: %v", err)
+	}
+
+	rejects := h.jobRepo.Rejects[job.ID]
+	if len(rejects) != 3 {
+		t.Fatalf("Expected every row rejected, got %+v", rejects)
+	}
+	if rejects[2].Line != 2 || rejects[2].Errors != "batch insert failed" {
+		t.Errorf("Expected line 2 rejected by the failed insert, got %+v", rejects[2])
+	}
+}
+
+const rejectsArticlesNDJSON = `{"id":"aaaaaaaa-0000-0000-0000-000000000001","slug":"kept","title":"Kept","body":"b","author_id":"bbbbbbbb-0000-0000-0000-000000000001","status":"draft","tags":["go"]}
+{"id":"aaaaaaaa-0000-0000-0000-000000000002","slug":"Bad Slug","title":"  Bad  ","body":"b","author_id":"bbbbbbbb-0000-0000-0000-000000000001","status":"draft","tags":["go","sql"]}
+not json
+`
+
+func TestRejects_NDJSONKeepsRecordsAsRead(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+	req := &models.ImportRequest{Resource: "articles", Options: &models.ImportOptions{Transforms: []models.Transform{{Name: "trim"}}}}
+
+	job, err := h.services.Import.StreamImport(ctx, req, "ndjson", strings.NewReader(rejectsArticlesNDJSON), nil)
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+	if job.FailedCount != 2 {
+		t.Fatalf("Expected 2 rejected lines, got %d", job.FailedCount)
+	}
+
+	formatName, err := service.RejectsFormat(job, "")
+	if err != nil || formatName != service.RejectsNDJSON {
+		t.Fatalf("Expected NDJSON rejects for an NDJSON source, got %q (%v)", formatName, err)
+	}
+	var out bytes.Buffer
+	if err := h.services.Job.WriteRejects(ctx, job, formatName, &out); err != nil {
+		t.Fatalf("WriteRejects failed: %v", err)
+	}
+
+	// The undecodable line has no fields to keep
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 rejected record, got %q", lines)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("Expected a JSON object, got %v", err)
+	}
+	if rec["title"] != "  Bad  " {
+		t.Errorf("Expected the title as read, before transforms, got %q", rec["title"])
+	}
+	if reason, _ := rec["_errors"].(string); !strings.HasPrefix(reason, "slug: ") {
+		t.Errorf("Expected the slug error in _errors, got %q", rec["_errors"])
+	}
+
+	// Written as CSV instead, lists stay JSON arrays
+	out.Reset()
+	if err := h.services.Job.WriteRejects(ctx, job, service.RejectsCSV, &out); err != nil {
+		t.Fatalf("WriteRejects failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"[""go"",""sql""]"`) {
+		t.Errorf("Expected tags written as a JSON array, got %s", out.String())
+	}
+}
+
+func TestRejectsFormat(t *testing.T) {
+	csvJob := &models.Job{Type: models.JobTypeImport, Resource: "users", SourceHeader: []string{"id", "email"}}
+	ndjsonJob := &models.Job{Type: models.JobTypeImport, Resource: "users"}
+
+	tests := []struct {
+		name      string
+		job       *models.Job
+		requested string
+		want      string
+		wantErr   bool
+	}{
+		{"tabular source", csvJob, "", service.RejectsCSV, false},
+		{"other source", ndjsonJob, "", service.RejectsNDJSON, false},
+		{"requested", csvJob, "ndjson", service.RejectsNDJSON, false},
+		{"unknown format", csvJob, "xlsx", "", true},
+		{"bundle", &models.Job{Type: models.JobTypeImport, Resource: models.ResourceBundle}, "", "", true},
+		{"export job", &models.Job{Type: models.JobTypeExport, Resource: "users"}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.RejectsFormat(tt.job, tt.requested)
+			if tt.wantErr {
+				if !errors.Is(err, service.ErrInvalidRejects) {
+					t.Errorf("Expected ErrInvalidRejects, got %v", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Expected %s, got %q (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
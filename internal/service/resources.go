@@ -0,0 +1,153 @@
+package service
+
+import (
+	"github.com/bulk-import-export-api/internal/format"
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/validation"
+)
+
+// Resource schemas shared by every import and export format. Field order is
+// the column order of exported files and must only ever be extended.
+var (
+	userSchema = &format.Schema{
+		Name: "users",
+		Fields: []format.Field{
+			{Name: "id", Type: format.String},
+			{Name: "email", Type: format.String},
+			{Name: "name", Type: format.String},
+			{Name: "role", Type: format.String},
+			{Name: "active", Type: format.Bool},
+			{Name: "created_at", Type: format.Timestamp},
+			{Name: "updated_at", Type: format.Timestamp},
+		},
+	}
+
+	articleSchema = &format.Schema{
+		Name: "articles",
+		Fields: []format.Field{
+			{Name: "id", Type: format.String},
+			{Name: "slug", Type: format.String},
+			{Name: "title", Type: format.String},
+			{Name: "body", Type: format.String},
+			{Name: "author_id", Type: format.String},
+			{Name: "tags", Type: format.StringList},
+			{Name: "status", Type: format.String},
+			{Name: "published_at", Type: format.Timestamp, Nullable: true},
+			{Name: "created_at", Type: format.Timestamp},
+			{Name: "updated_at", Type: format.Timestamp},
+		},
+	}
+
+	commentSchema = &format.Schema{
+		Name: "comments",
+		Fields: []format.Field{
+			{Name: "id", Type: format.String},
+			{Name: "article_id", Type: format.String},
+			{Name: "user_id", Type: format.String},
+			{Name: "body", Type: format.String},
+			{Name: "created_at", Type: format.Timestamp},
+			{Name: "updated_at", Type: format.Timestamp},
+		},
+	}
+)
+
+// resourceSchemas looks up a schema by resource name
+var resourceSchemas = map[string]*format.Schema{
+	"users":    userSchema,
+	"articles": articleSchema,
+	"comments": commentSchema,
+}
+
+// Model to record mappings (export)
+
+func userRecord(u *models.User) format.Record {
+	return format.Record{
+		"id":         u.ID,
+		"email":      u.Email,
+		"name":       u.Name,
+		"role":       u.Role,
+		"active":     u.Active,
+		"created_at": u.CreatedAt,
+		"updated_at": u.UpdatedAt,
+	}
+}
+
+func articleRecord(a *models.Article) format.Record {
+	rec := format.Record{
+		"id":         a.ID,
+		"slug":       a.Slug,
+		"title":      a.Title,
+		"body":       a.Body,
+		"author_id":  a.AuthorID,
+		"tags":       a.Tags,
+		"status":     a.Status,
+		"created_at": a.CreatedAt,
+		"updated_at": a.UpdatedAt,
+	}
+	if a.PublishedAt != nil {
+		rec["published_at"] = *a.PublishedAt
+	}
+	return rec
+}
+
+func commentRecord(c *models.Comment) format.Record {
+	return format.Record{
+		"id":         c.ID,
+		"article_id": c.ArticleID,
+		"user_id":    c.UserID,
+		"body":       c.Body,
+		"created_at": c.CreatedAt,
+		"updated_at": c.UpdatedAt,
+	}
+}
+
+// Record to import struct mappings. Values are read as text so typed
+// sources (Parquet, Arrow, XLSX) go through the same validation as CSV and
+// NDJSON.
+
+func userFromRecord(rec format.Record) (*models.UserCSV, []validation.ValidationError) {
+	return &models.UserCSV{
+		ID:        rec.Text("id"),
+		Email:     rec.Text("email"),
+		Name:      rec.Text("name"),
+		Role:      rec.Text("role"),
+		Active:    rec.Text("active"),
+		CreatedAt: rec.Text("created_at"),
+		UpdatedAt: rec.Text("updated_at"),
+	}, nil
+}
+
+func articleFromRecord(rec format.Record) (*models.ArticleNDJSON, []validation.ValidationError) {
+	tags, err := rec.List("tags")
+	if err != nil {
+		return nil, []validation.ValidationError{{
+			Field:   "tags",
+			Message: err.Error(),
+			Value:   rec["tags"],
+			Code:    validation.CodeInvalidValue,
+		}}
+	}
+	return &models.ArticleNDJSON{
+		ID:          rec.Text("id"),
+		Slug:        rec.Text("slug"),
+		Title:       rec.Text("title"),
+		Body:        rec.Text("body"),
+		AuthorID:    rec.Text("author_id"),
+		Tags:        tags,
+		Status:      rec.Text("status"),
+		PublishedAt: rec.Text("published_at"),
+		CreatedAt:   rec.Text("created_at"),
+		UpdatedAt:   rec.Text("updated_at"),
+	}, nil
+}
+
+func commentFromRecord(rec format.Record) (*models.CommentNDJSON, []validation.ValidationError) {
+	return &models.CommentNDJSON{
+		ID:        rec.Text("id"),
+		ArticleID: rec.Text("article_id"),
+		UserID:    rec.Text("user_id"),
+		Body:      rec.Text("body"),
+		CreatedAt: rec.Text("created_at"),
+		UpdatedAt: rec.Text("updated_at"),
+	}, nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrJobNotFound is returned for a job ID that does not exist
+	ErrJobNotFound = errors.New("job not found")
+	// ErrInvalidRetry is returned when a job cannot be retried as asked
+	ErrInvalidRetry = errors.New("invalid retry")
+)
+
+// RetryImport creates a retry of a finished import as a pending child job.
+// With a file (typically the fixed rejects file) that file is imported;
+// without one, the rejected rows whose errors came from the state of the
+// database are imported again unchanged. Options given with the retry
+// replace the retried job's; the others carry over. Rows a retry imports
+// are rolled up into the import they were first rejected by.
+func (s *importService) RetryImport(ctx context.Context, jobID string, req *models.ImportRequest, filePath string) (*models.Job, error) {
+	target, err := s.repos.Job.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrJobNotFound
+	}
+	if target.Type != models.JobTypeImport || target.Resource == models.ResourceBundle {
+		return nil, fmt.Errorf("%w: only imports of one resource can be retried; retry a bundle's child jobs", ErrInvalidRetry)
+	}
+	if target.Status != models.JobStatusCompleted && target.Status != models.JobStatusFailed {
+		return nil, fmt.Errorf("%w: job is %s, only finished imports can be retried", ErrInvalidRetry, target.Status)
+	}
+
+	parent, err := s.retryParent(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := retryOptions(target.Options, req.Options)
+	if err := checkImportOptions(s.rules, target.Resource, opts); err != nil {
+		return nil, err
+	}
+	if filePath == "" {
+		if filePath, err = s.storeRetryable(ctx, target); err != nil {
+			return nil, err
+		}
+		// The stored rejects file's extension picks its decoder
+		opts.Format, opts.Sheet = "", ""
+	}
+
+	job := &models.Job{
+		ID:             uuid.New().String(),
+		Type:           models.JobTypeImport,
+		Resource:       target.Resource,
+		Status:         models.JobStatusPending,
+		IdempotencyKey: req.IdempotencyKey,
+		ParentJobID:    parent.ID,
+		FilePath:       filePath,
+		Options:        opts,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.repos.Job.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	s.log.Info().
+		Str("job_id", job.ID).
+		Str("parent_job_id", parent.ID).
+		Str("retried_job_id", target.ID).
+		Str("file", filePath).
+		Msg("Import retry created")
+
+	return job, nil
+}
+
+// retryParent returns the import a retry of target rolls up into: target
+// itself, or for a retry of a retry, the import both retry
+func (s *importService) retryParent(ctx context.Context, target *models.Job) (*models.Job, error) {
+	if target.ParentJobID == "" {
+		return target, nil
+	}
+	parent, err := s.repos.Job.GetByID(ctx, target.ParentJobID)
+	if err != nil {
+		return nil, err
+	}
+	// A bundle's child is retried on its own
+	if parent == nil || parent.Resource == models.ResourceBundle {
+		return target, nil
+	}
+	return parent, nil
+}
+
+// retryOptions merges the options given with a retry over those of the
+// retried job. Format and sheet describe a file, so they are never carried
+// over to another one.
+func retryOptions(retried, given *models.ImportOptions) *models.ImportOptions {
+	opts := &models.ImportOptions{}
+	if retried != nil {
+		opts.Source = retried.Source
+		opts.Transforms = retried.Transforms
+		opts.DateLayouts = retried.DateLayouts
+		opts.Timezone = retried.Timezone
+	}
+	if given == nil {
+		return opts
+	}
+	opts.Format = given.Format
+	opts.Sheet = given.Sheet
+	if given.Source != "" {
+		opts.Source = given.Source
+	}
+	if given.Transforms != nil {
+		opts.Transforms = given.Transforms
+	}
+	if len(given.DateLayouts) > 0 || given.Timezone != "" {
+		opts.DateLayouts = given.DateLayouts
+		opts.Timezone = given.Timezone
+	}
+	return opts
+}
+
+// storeRetryable stores the retryable rejects of job as a rejects file in
+// the upload directory, for a retry to import
+func (s *importService) storeRetryable(ctx context.Context, job *models.Job) (string, error) {
+	formatName, err := RejectsFormat(job, "")
+	if err != nil {
+		return "", err
+	}
+	key := filepath.Join(s.cfg.Import.UploadDir,
+		fmt.Sprintf("%s_%s_retry.%s", job.Resource, uuid.New().String()[:8], formatName))
+
+	pr, pw := io.Pipe()
+	var count int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var writeErr error
+		count, writeErr = writeRejects(ctx, s.repos.Job, job, formatName, true, pw)
+		// A failed write fails the upload too, so no partial file is kept
+		pw.CloseWithError(writeErr)
+	}()
+
+	err = s.blobs.Put(ctx, key, pr)
+	// Unblock the writer if the upload stopped reading early
+	pr.CloseWithError(io.ErrClosedPipe)
+	<-done
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		s.blobs.Delete(ctx, key)
+		return "", fmt.Errorf("%w: no rejected row failed for a reason a retry can fix; upload the fixed rejects file", ErrInvalidRetry)
+	}
+	return key, nil
+}
+
+// rollUpRetry moves the rows a finished retry imported from failed to
+// successful on the import it retries. Bundle children are rolled up by
+// their bundle instead.
+func (s *importService) rollUpRetry(ctx context.Context, job *models.Job) {
+	if job.ParentJobID == "" || job.SuccessfulCount == 0 {
+		return
+	}
+	parent, err := s.repos.Job.GetByID(ctx, job.ParentJobID)
+	if err != nil || parent == nil || parent.Resource == models.ResourceBundle {
+		return
+	}
+	if err := s.repos.Job.AddRecovered(ctx, parent.ID, job.SuccessfulCount); err != nil {
+		s.log.Error().Err(err).Str("job_id", job.ID).Str("parent_job_id", parent.ID).Msg("Failed to roll up retry")
+	}
+}
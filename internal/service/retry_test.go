@@ -0,0 +1,192 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+)
+
+func TestRetry_UnchangedAfterParentsImported(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	archive := writeZip(t, h, []string{"users.csv", "articles.ndjson", "comments.ndjson"}, bundleFiles)
+	parent := createBundle(t, h, archive)
+	if err := h.services.Import.ProcessImport(ctx, parent); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+	children, _ := h.jobRepo.GetChildren(ctx, parent.ID)
+	comments := children[2]
+	if comments.FailedCount != 1 {
+		t.Fatalf("Expected the orphan comment rejected, got %d failed", comments.FailedCount)
+	}
+
+	// The missing article arrives later
+	orphanArticle := "7f9619ff-8b86-d011-b42d-00cf4fc964ff"
+	h.articleRepo.Articles[orphanArticle] = &models.Article{ID: orphanArticle, AuthorID: bundleUserID}
+
+	retry, err := h.services.Import.RetryImport(ctx, comments.ID, &models.ImportRequest{}, "")
+	if err != nil {
+		t.Fatalf("RetryImport failed: %v", err)
+	}
+	if retry.ParentJobID != comments.ID || retry.Resource != "comments" || retry.Status != models.JobStatusPending {
+		t.Errorf("Expected a pending comments retry of the child job, got %+v", retry)
+	}
+
+	// Retries are picked up by the job processor, unlike bundle children
+	pending, _ := h.jobRepo.GetPendingJobs(ctx)
+	if len(pending) != 1 || pending[0].ID != retry.ID {
+		t.Errorf("Expected only the retry pending, got %d jobs", len(pending))
+	}
+
+	if err := h.services.Import.ProcessImport(ctx, retry); err != nil {
+		t.Fatalf("ProcessImport of the retry failed: %v", err)
+	}
+	if retry.TotalRecords != 1 || retry.SuccessfulCount != 1 {
+		t.Errorf("Expected the orphan comment imported unchanged, got %d/%d: %+v",
+			retry.SuccessfulCount, retry.TotalRecords, h.jobRepo.Errors[retry.ID])
+	}
+	if comments.SuccessfulCount != 2 || comments.FailedCount != 0 {
+		t.Errorf("Expected the retry rolled up into the child, got %d ok / %d failed", comments.SuccessfulCount, comments.FailedCount)
+	}
+	if parent.SuccessfulCount != 4 || parent.FailedCount != 0 {
+		t.Errorf("Expected the retry rolled up into the bundle, got %d ok / %d failed", parent.SuccessfulCount, parent.FailedCount)
+	}
+
+	resp, _ := h.services.Job.GetJob(ctx, comments.ID)
+	if len(resp.Retries) != 1 || resp.Retries[0].ID != retry.ID {
+		t.Errorf("Expected the retry listed on the child job, got %+v", resp.Retries)
+	}
+}
+
+func TestRetry_FixedFile(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	job := createTestJob(h, "users", h.put(t, "users.csv", []byte(rejectsUsersCSV)))
+	if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport failed: %v", err)
+	}
+
+	// Content errors are not retried unchanged
+	if _, err := h.services.Import.RetryImport(ctx, job.ID, &models.ImportRequest{}, ""); !errors.Is(err, service.ErrInvalidRetry) {
+		t.Errorf("Expected ErrInvalidRetry with nothing retryable, got %v", err)
+	}
+
+	var rejects bytes.Buffer
+	if err := h.services.Job.WriteRejects(ctx, job, service.RejectsCSV, &rejects); err != nil {
+		t.Fatalf("WriteRejects failed: %v", err)
+	}
+
+	// Only the email is fixed; the role stays wrong
+	fixed := strings.Replace(rejects.String(), "not-an-email", "bob@example.com", 1)
+	retry, err := h.services.Import.RetryImport(ctx, job.ID, &models.ImportRequest{}, h.put(t, "retry_1.csv", []byte(fixed)))
+	if err != nil {
+		t.Fatalf("RetryImport failed: %v", err)
+	}
+	if err := h.services.Import.ProcessImport(ctx, retry); err != nil {
+		t.Fatalf("ProcessImport of the retry failed: %v", err)
+	}
+	if retry.SuccessfulCount != 1 || retry.FailedCount != 1 {
+		t.Errorf("Expected 1 row fixed and 1 still rejected, got %d/%d", retry.SuccessfulCount, retry.FailedCount)
+	}
+	if job.SuccessfulCount != 2 || job.FailedCount != 1 {
+		t.Errorf("Expected the fixed row rolled up, got %d ok / %d failed", job.SuccessfulCount, job.FailedCount)
+	}
+
+	// A retry of the retry rolls up into the same import
+	rejects.Reset()
+	h.services.Job.WriteRejects(ctx, retry, service.RejectsCSV, &rejects)
+	fixed = strings.Replace(rejects.String(), "wizard", "viewer", 1)
+	second, err := h.services.Import.RetryImport(ctx, retry.ID, &models.ImportRequest{}, h.put(t, "retry_2.csv", []byte(fixed)))
+	if err != nil {
+		t.Fatalf("RetryImport of the retry failed: %v", err)
+	}
+	if second.ParentJobID != job.ID {
+		t.Errorf("Expected the second retry linked to the import, got parent %s", second.ParentJobID)
+	}
+	if err := h.services.Import.ProcessImport(ctx, second); err != nil {
+		t.Fatalf("ProcessImport of the second retry failed: %v", err)
+	}
+	if job.SuccessfulCount != 3 || job.FailedCount != 0 {
+		t.Errorf("Expected every row imported in the end, got %d ok / %d failed", job.SuccessfulCount, job.FailedCount)
+	}
+
+	resp, _ := h.services.Job.GetJob(ctx, job.ID)
+	if len(resp.Retries) != 2 {
+		t.Errorf("Expected both retries listed, got %+v", resp.Retries)
+	}
+}
+
+func TestRetry_InheritsOptions(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	job := createTestJob(h, "users", "users.xlsx")
+	job.Status = models.JobStatusCompleted
+	job.Options = &models.ImportOptions{Sheet: "People", Source: "crm", Timezone: "Europe/Berlin"}
+
+	retry, err := h.services.Import.RetryImport(ctx, job.ID, &models.ImportRequest{
+		Options: &models.ImportOptions{Source: "erp"},
+	}, "retry.csv")
+	if err != nil {
+		t.Fatalf("RetryImport failed: %v", err)
+	}
+	opts := retry.Options
+	if opts == nil || opts.Source != "erp" || opts.Timezone != "Europe/Berlin" || opts.Sheet != "" {
+		t.Errorf("Expected the given source, the inherited timezone and no sheet, got %+v", opts)
+	}
+}
+
+func TestRetry_AlreadyExistsNotRetried(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+	h.userRepo.Create(ctx, &models.User{ID: "11111111-1111-1111-1111-111111111111", Email: "author@test.com", Name: "Taken", Role: "viewer"})
+
+	job := createTestJob(h, "users", h.put(t, "users.csv", []byte(bundleFiles["users.csv"])))
+	if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport returned error: %v", err)
+	}
+	rejects := h.jobRepo.Rejects[job.ID]
+	if len(rejects) != 1 || rejects[0].Retryable {
+		t.Fatalf("Expected the stored email's row rejected as not retryable, got %+v", rejects)
+	}
+
+	// An unchanged retry would clash with the same row again
+	if _, err := h.services.Import.RetryImport(ctx, job.ID, &models.ImportRequest{}, ""); !errors.Is(err, service.ErrInvalidRetry) {
+		t.Errorf("Expected ErrInvalidRetry with nothing retryable, got %v", err)
+	}
+}
+
+func TestRetry_Invalid(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	pending := createTestJob(h, "users", "users.csv")
+	h.jobRepo.Create(ctx, &models.Job{ID: "bundle-job", Type: models.JobTypeImport, Resource: models.ResourceBundle, Status: models.JobStatusCompleted})
+	h.jobRepo.Create(ctx, &models.Job{ID: "export-job", Type: models.JobTypeExport, Resource: "users", Status: models.JobStatusCompleted})
+
+	tests := []struct {
+		name  string
+		jobID string
+		want  error
+	}{
+		{"unknown job", "missing", service.ErrJobNotFound},
+		{"unfinished", pending.ID, service.ErrInvalidRetry},
+		{"bundle", "bundle-job", service.ErrInvalidRetry},
+		{"export", "export-job", service.ErrInvalidRetry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := h.services.Import.RetryImport(ctx, tt.jobID, &models.ImportRequest{}, "retry.csv")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
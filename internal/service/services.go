@@ -2,17 +2,23 @@ package service
 
 import (
 	"context"
+	"io"
 	"net/http"
 
 	"github.com/bulk-import-export-api/internal/config"
 	"github.com/bulk-import-export-api/internal/models"
 	"github.com/bulk-import-export-api/internal/repository"
+	"github.com/bulk-import-export-api/internal/storage"
+	"github.com/bulk-import-export-api/internal/validation"
 	"github.com/rs/zerolog"
 )
 
 // ImportService defines the interface for import operations
 type ImportService interface {
 	CreateImportJob(ctx context.Context, req *models.ImportRequest, filePath string) (*models.Job, error)
+	CreateBundleImportJob(ctx context.Context, req *models.ImportRequest, archivePath string) (*models.Job, error)
+	StreamImport(ctx context.Context, req *models.ImportRequest, format string, body io.Reader, report func(models.LineResult) error) (*models.Job, error)
+	RetryImport(ctx context.Context, jobID string, req *models.ImportRequest, filePath string) (*models.Job, error)
 	ProcessImport(ctx context.Context, job *models.Job) error
 }
 
@@ -21,7 +27,13 @@ type ExportService interface {
 	StreamUsers(ctx context.Context, w http.ResponseWriter, format string) error
 	StreamArticles(ctx context.Context, w http.ResponseWriter, format string) error
 	StreamComments(ctx context.Context, w http.ResponseWriter, format string) error
+	StreamBundle(ctx context.Context, w http.ResponseWriter, format string) error
+	StreamFrom(ctx context.Context, w http.ResponseWriter, resource, format string, after models.PageCursor) error
+	StreamChanges(ctx context.Context, w http.ResponseWriter, resource, format string, after models.ChangeCursor) error
+	ListPage(ctx context.Context, resource string, filter models.ExportFilter) (*models.ResourcePage, error)
 	GetCount(ctx context.Context, resource string) (int, error)
+	CreateExportJob(ctx context.Context, req *models.ExportRequest) (*models.Job, error)
+	ProcessExport(ctx context.Context, job *models.Job) error
 }
 
 // JobService defines the interface for job management
@@ -30,29 +42,70 @@ type JobService interface {
 	StopProcessor()
 	GetJob(ctx context.Context, id string) (*models.JobResponse, error)
 	GetJobByIdempotencyKey(ctx context.Context, key string) (*models.Job, error)
-	GetJobErrors(ctx context.Context, id string) ([]models.ValidationError, error)
+	ListJobErrors(ctx context.Context, filter models.ErrorFilter) (*models.ErrorPage, error)
+	StreamJobErrors(ctx context.Context, filter models.ErrorFilter, callback func(*models.ValidationError) error) error
+	SummarizeJobErrors(ctx context.Context, filter models.ErrorFilter) (*models.ErrorSummary, error)
+	GetJobTransforms(ctx context.Context, id string) ([]models.TransformRecord, error)
+	WriteRejects(ctx context.Context, job *models.Job, format string, w io.Writer) error
 	SetImportService(importService ImportService)
+	SetExportService(exportService ExportService)
+}
+
+// UploadService defines the interface for resumable chunked uploads
+type UploadService interface {
+	CreateUpload(ctx context.Context, req *models.UploadRequest) (*models.Upload, error)
+	GetUpload(ctx context.Context, id string) (*models.Upload, error)
+	WriteChunk(ctx context.Context, id string, offset int64, chunk io.Reader) (*models.Upload, error)
+	CompleteUpload(ctx context.Context, id string) (*models.Job, error)
+	DeleteUpload(ctx context.Context, id string) error
+	ExpireUploads(ctx context.Context) (int, error)
+	StartSweeper(ctx context.Context)
+}
+
+// JanitorService defines the interface for retention cleanup
+type JanitorService interface {
+	Run(ctx context.Context) (*models.CleanupReport, error)
+	Start(ctx context.Context)
+}
+
+// ExternalIDService defines the interface for querying external ID mappings
+type ExternalIDService interface {
+	List(ctx context.Context, filter models.ExternalIDFilter) (*models.ExternalIDPage, error)
 }
 
 // Services holds all service interfaces
 type Services struct {
-	Import ImportService
-	Export ExportService
-	Job    JobService
+	Import      ImportService
+	Export      ExportService
+	Job         JobService
+	Upload      UploadService
+	Janitor     JanitorService
+	ExternalIDs ExternalIDService
+	Storage     storage.Blob          // where uploaded and exported artifacts live
+	Rules       *validation.RuleStore // validation rules applied to new imports
 }
 
-// NewServices creates all services
-func NewServices(repos *repository.Repositories, cfg *config.Config, log zerolog.Logger) *Services {
+// NewServices creates all services. Every import and export artifact is
+// read and written through blobs.
+func NewServices(repos *repository.Repositories, blobs storage.Blob, cfg *config.Config, log zerolog.Logger) *Services {
+	rules := validation.NewRuleStore(cfg.Import.RulesFile)
 	jobSvc := newJobService(repos.Job, log)
-	importSvc := newImportService(repos, jobSvc, cfg, log)
-	exportSvc := newExportService(repos, log)
+	importSvc := newImportService(repos, blobs, jobSvc, rules, cfg, log)
+	exportSvc := newExportService(repos, blobs, log)
+	uploadSvc := newUploadService(repos, blobs, importSvc, rules, cfg, log)
 
-	// Wire up job processor to import service
+	// Wire up job processor to import and export services
 	jobSvc.SetImportService(importSvc)
+	jobSvc.SetExportService(exportSvc)
 
 	return &Services{
-		Import: importSvc,
-		Export: exportSvc,
-		Job:    jobSvc,
+		Import:      importSvc,
+		Export:      exportSvc,
+		Job:         jobSvc,
+		Upload:      uploadSvc,
+		Janitor:     newJanitorService(repos, blobs, cfg, log),
+		ExternalIDs: newExternalIDService(repos),
+		Storage:     blobs,
+		Rules:       rules,
 	}
 }
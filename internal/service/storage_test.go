@@ -0,0 +1,110 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+)
+
+func TestProcessImport_FormatOption(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	// The key says nothing about the format, as with many object keys
+	job := createTestJob(h, "users", h.put(t, "s3://imports/users-export", []byte(uploadCSV)))
+	job.Options = &models.ImportOptions{Format: "csv"}
+	if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport failed: %v", err)
+	}
+	if job.SuccessfulCount != 2 {
+		t.Errorf("Expected 2 users imported, got %d", job.SuccessfulCount)
+	}
+}
+
+func TestProcessImport_MissingFile(t *testing.T) {
+	h := newTestHarness(t)
+
+	job := createTestJob(h, "users", "s3://imports/gone.csv")
+	if err := h.services.Import.ProcessImport(context.Background(), job); err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+	if job.Status != models.JobStatusFailed {
+		t.Errorf("Expected failed job, got %s", job.Status)
+	}
+}
+
+func TestCreateExportJob(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	job, err := h.services.Export.CreateExportJob(ctx, &models.ExportRequest{
+		Resource: "users", Format: "csv", Destination: "s3://exports/nightly/",
+	})
+	if err != nil {
+		t.Fatalf("CreateExportJob failed: %v", err)
+	}
+	if job.Type != models.JobTypeExport || job.Status != models.JobStatusPending {
+		t.Errorf("Expected pending export job, got %s %s", job.Type, job.Status)
+	}
+	if !strings.HasPrefix(job.FilePath, "s3://exports/nightly/users_") || !strings.HasSuffix(job.FilePath, ".csv") {
+		t.Errorf("Expected a generated key under the prefix, got %s", job.FilePath)
+	}
+
+	invalid := []models.ExportRequest{
+		{Resource: "users", Format: "csv", Destination: "/tmp/users.csv"},
+		{Resource: "users", Format: "csv", Destination: "s3://exports"},
+		{Resource: "users", Format: "yaml", Destination: "s3://exports/users.yaml"},
+		{Resource: "posts", Format: "csv", Destination: "s3://exports/posts.csv"},
+	}
+	for _, req := range invalid {
+		if _, err := h.services.Export.CreateExportJob(ctx, &req); !errors.Is(err, service.ErrInvalidExport) {
+			t.Errorf("Expected ErrInvalidExport for %+v, got %v", req, err)
+		}
+	}
+}
+
+func TestProcessExport_WritesDestination(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	for _, u := range []*models.User{
+		{ID: "11111111-1111-1111-1111-111111111111", Email: "ada@example.com", Name: "Ada", Role: "admin", Active: true, CreatedAt: time.Now()},
+		{ID: "22222222-2222-2222-2222-222222222222", Email: "bob@example.com", Name: "Bob", Role: "viewer", CreatedAt: time.Now()},
+	} {
+		h.userRepo.Create(ctx, u)
+	}
+
+	dest := "s3://exports/users.ndjson"
+	job := &models.Job{
+		ID:       "export-job",
+		Type:     models.JobTypeExport,
+		Resource: "users",
+		Status:   models.JobStatusPending,
+		FilePath: dest,
+		Options:  &models.ImportOptions{Format: "ndjson"},
+	}
+	h.jobRepo.Create(ctx, job)
+
+	if err := h.services.Export.ProcessExport(ctx, job); err != nil {
+		t.Fatalf("ProcessExport failed: %v", err)
+	}
+	if job.Status != models.JobStatusCompleted || job.TotalRecords != 2 || job.DownloadURL != dest {
+		t.Errorf("Expected completed job with 2 records at %s, got %s %d %s", dest, job.Status, job.TotalRecords, job.DownloadURL)
+	}
+
+	r, err := h.blobs.Get(ctx, dest)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	defer r.Close()
+	data, _ := io.ReadAll(r)
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Expected 2 NDJSON lines, got %d", lines)
+	}
+}
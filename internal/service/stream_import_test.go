@@ -0,0 +1,346 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/validation"
+)
+
+const streamUsersNDJSON = `{"id":"11111111-1111-1111-1111-111111111111","email":"ada@example.com","name":"Ada","role":"admin","active":true,"created_at":"2024-01-01T00:00:00Z"}
+{"id":"22222222-2222-2222-2222-222222222222","email":"not-an-email","name":"Bob","role":"viewer","active":true,"created_at":"2024-01-02T00:00:00Z"}
+{not json
+{"id":"33333333-3333-3333-3333-333333333333","email":"cy@example.com","name":"Cy","role":"editor","active":false,"created_at":"2024-01-03T00:00:00Z"}
+`
+
+func TestStreamImport_ReportsEveryLineInOrder(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	var results []models.LineResult
+	job, err := h.services.Import.StreamImport(ctx, &models.ImportRequest{Resource: "users"}, "ndjson",
+		strings.NewReader(streamUsersNDJSON), func(r models.LineResult) error {
+			results = append(results, r)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+
+	if job.Status != models.JobStatusCompleted || job.TotalRecords != 4 || job.SuccessfulCount != 2 || job.FailedCount != 2 {
+		t.Errorf("Expected completed job with 2 of 4 imported, got %s %d/%d/%d",
+			job.Status, job.TotalRecords, job.SuccessfulCount, job.FailedCount)
+	}
+	if len(h.userRepo.Users) != 2 {
+		t.Errorf("Expected 2 users stored, got %d", len(h.userRepo.Users))
+	}
+	if stored := h.jobRepo.Jobs[job.ID]; stored == nil || stored.FilePath != "" {
+		t.Errorf("Expected a job record without a stored file, got %+v", stored)
+	}
+	if len(h.jobRepo.Errors[job.ID]) != 2 {
+		t.Errorf("Expected 2 stored validation errors, got %d", len(h.jobRepo.Errors[job.ID]))
+	}
+
+	expected := []struct {
+		line   int
+		status string
+	}{
+		{1, models.LineImported},
+		{2, models.LineFailed},
+		{3, models.LineFailed},
+		{4, models.LineImported},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d: %+v", len(expected), len(results), results)
+	}
+	for i, want := range expected {
+		if results[i].Line != want.line || results[i].Status != want.status {
+			t.Errorf("Result %d: expected line %d %s, got line %d %s", i, want.line, want.status, results[i].Line, results[i].Status)
+		}
+	}
+	if len(results[1].Errors) == 0 || results[1].Errors[0].Field != "email" {
+		t.Errorf("Expected the email error on line 2, got %+v", results[1].Errors)
+	}
+}
+
+// disconnectingReader yields r, then fails the way a request body does once
+// its client has gone
+type disconnectingReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (d *disconnectingReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err == io.EOF {
+		d.cancel()
+		return n, context.Canceled
+	}
+	return n, err
+}
+
+func TestStreamImport_ClientDisconnectFailsJob(t *testing.T) {
+	h := newTestHarness(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	body := &disconnectingReader{r: strings.NewReader(streamUsersNDJSON), cancel: cancel}
+	job, err := h.services.Import.StreamImport(ctx, &models.ImportRequest{Resource: "users"}, "ndjson", body, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if got := h.jobRepo.Statuses[job.ID]; got != models.JobStatusFailed {
+		t.Errorf("Expected the job stored as failed, got %s", got)
+	}
+	if len(h.jobRepo.Errors[job.ID]) != 2 || len(h.jobRepo.Rejects[job.ID]) != 1 {
+		t.Errorf("Expected the errors and rejects read before the disconnect stored, got %d / %d",
+			len(h.jobRepo.Errors[job.ID]), len(h.jobRepo.Rejects[job.ID]))
+	}
+}
+
+func TestStreamImport_CSVWithoutReport(t *testing.T) {
+	h := newTestHarness(t)
+
+	job, err := h.services.Import.StreamImport(context.Background(), &models.ImportRequest{Resource: "users"}, "csv",
+		strings.NewReader(uploadCSV), nil)
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+	if job.SuccessfulCount != 2 {
+		t.Errorf("Expected 2 users imported, got %d", job.SuccessfulCount)
+	}
+}
+
+func TestStreamImport_BatchFailureReported(t *testing.T) {
+	h := newTestHarness(t)
+	h.userRepo.BatchInsertFunc = func(ctx context.Context, users []*models.User) (int, error) {
+		return 0, errors.New("connection reset")
+	}
+
+	var results []models.LineResult
+	job, err := h.services.Import.StreamImport(context.Background(), &models.ImportRequest{Resource: "users"}, "csv",
+		strings.NewReader(uploadCSV), func(r models.LineResult) error {
+			results = append(results, r)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+	if job.FailedCount != 2 || len(results) != 2 {
+		t.Errorf("Expected both rows failed and reported, got %d failed, %d results", job.FailedCount, len(results))
+	}
+	for _, r := range results {
+		if r.Status != models.LineFailed {
+			t.Errorf("Expected line %d reported failed, got %s", r.Line, r.Status)
+		}
+	}
+}
+
+func TestStreamImport_ReportErrorStopsImport(t *testing.T) {
+	h := newTestHarness(t)
+	gone := errors.New("client went away")
+
+	job, err := h.services.Import.StreamImport(context.Background(), &models.ImportRequest{Resource: "users"}, "ndjson",
+		strings.NewReader(streamUsersNDJSON), func(r models.LineResult) error { return gone })
+	if !errors.Is(err, gone) {
+		t.Fatalf("Expected the report error, got %v", err)
+	}
+	if job.Status != models.JobStatusFailed {
+		t.Errorf("Expected failed job, got %s", job.Status)
+	}
+}
+
+func TestStreamImport_UnsupportedFormat(t *testing.T) {
+	h := newTestHarness(t)
+
+	job, err := h.services.Import.StreamImport(context.Background(), &models.ImportRequest{Resource: "users"}, "parquet",
+		strings.NewReader(""), nil)
+	if err == nil || job != nil {
+		t.Errorf("Expected parquet to be refused for stream imports, got %v, %v", job, err)
+	}
+}
+
+func TestStreamImport_StoredEmailRejectedByLine(t *testing.T) {
+	h := newTestHarness(t)
+	h.userRepo.Create(context.Background(), &models.User{ID: "stored", Email: "cy@example.com"})
+
+	var results []models.LineResult
+	job, err := h.services.Import.StreamImport(context.Background(), &models.ImportRequest{Resource: "users"}, "ndjson",
+		strings.NewReader(streamUsersNDJSON), func(r models.LineResult) error {
+			results = append(results, r)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+
+	if job.SuccessfulCount != 1 || job.FailedCount != 3 || job.ProcessedCount != 4 {
+		t.Errorf("Expected 1 imported and 3 failed, got %d/%d/%d", job.SuccessfulCount, job.FailedCount, job.ProcessedCount)
+	}
+	if h.userRepo.BatchInsertCalls != 1 {
+		t.Errorf("Expected the rest of the batch inserted, got %d inserts", h.userRepo.BatchInsertCalls)
+	}
+	if len(results) != 4 || results[3].Line != 4 || results[3].Status != models.LineFailed {
+		t.Fatalf("Expected line 4 reported failed, got %+v", results)
+	}
+	if errs := results[3].Errors; len(errs) != 1 || errs[0].Field != "email" || errs[0].Message != "email already exists" {
+		t.Errorf("Expected 'email already exists' on line 4, got %+v", errs)
+	}
+
+	found := false
+	for _, e := range h.jobRepo.Errors[job.ID] {
+		found = found || (e.Line == 4 && e.Message == "email already exists")
+	}
+	if !found {
+		t.Error("Expected the conflict stored as a line-level validation error")
+	}
+}
+
+func TestProcessImport_StoredSlugRejectedByLine(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+	h.userRepo.Create(ctx, &models.User{ID: "550e8400-e29b-41d4-a716-446655440001", Email: "author@example.com"})
+	h.articleRepo.Create(ctx, &models.Article{ID: "stored", Slug: "taken"})
+
+	data := `{"id":"550e8400-e29b-41d4-a716-446655440010","slug":"taken","title":"T","body":"B","author_id":"550e8400-e29b-41d4-a716-446655440001"}
+{"id":"550e8400-e29b-41d4-a716-446655440011","slug":"fresh","title":"T","body":"B","author_id":"550e8400-e29b-41d4-a716-446655440001"}
+`
+	job := createTestJob(h, "articles", h.put(t, "articles.ndjson", []byte(data)))
+	if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport failed: %v", err)
+	}
+
+	if job.SuccessfulCount != 1 || job.FailedCount != 1 {
+		t.Errorf("Expected 1 imported and 1 failed, got %d/%d", job.SuccessfulCount, job.FailedCount)
+	}
+	errs := h.jobRepo.Errors[job.ID]
+	if len(errs) != 1 || errs[0].Line != 1 || errs[0].Field != "slug" || errs[0].Message != "slug already exists" {
+		t.Errorf("Expected 'slug already exists' on line 1, got %+v", errs)
+	}
+}
+
+// articlesReferencing returns one NDJSON article per author ID
+func articlesReferencing(authorIDs ...string) string {
+	var b strings.Builder
+	for i, authorID := range authorIDs {
+		fmt.Fprintf(&b, `{"id":"550e8400-e29b-41d4-a716-4466554400%02d","slug":"article-%d","title":"T","body":"B","author_id":"%s"}`+"\n", i, i, authorID)
+	}
+	return b.String()
+}
+
+func TestProcessImport_ForeignKeyStrategies(t *testing.T) {
+	const stored, missing = "550e8400-e29b-41d4-a716-446655440001", "550e8400-e29b-41d4-a716-446655440002"
+
+	tests := []struct {
+		name        string
+		cacheLimit  int
+		wantLookups int
+	}{
+		{"parents fit in the cache", 10, 0},
+		{"parents beyond the cache limit", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHarness(t)
+			ctx := context.Background()
+			h.cfg.Import.FKCacheLimit = tt.cacheLimit
+			h.userRepo.Create(ctx, &models.User{ID: stored, Email: "a@example.com"})
+			h.userRepo.Create(ctx, &models.User{ID: "550e8400-e29b-41d4-a716-446655440003", Email: "b@example.com"})
+
+			job := createTestJob(h, "articles", h.put(t, "articles.ndjson", []byte(articlesReferencing(stored, missing, stored))))
+			if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+				t.Fatalf("ProcessImport failed: %v", err)
+			}
+
+			if h.userRepo.ExistingIDsCalls != tt.wantLookups {
+				t.Errorf("Expected %d batch lookups, got %d", tt.wantLookups, h.userRepo.ExistingIDsCalls)
+			}
+			if job.SuccessfulCount != 2 || job.FailedCount != 1 {
+				t.Errorf("Expected 2 imported and 1 failed, got %d/%d", job.SuccessfulCount, job.FailedCount)
+			}
+			errs := h.jobRepo.Errors[job.ID]
+			if len(errs) != 1 || errs[0].Line != 2 || errs[0].Field != "author_id" || errs[0].Message != "referenced user does not exist" {
+				t.Errorf("Expected missing author reported on line 2, got %+v", errs)
+			}
+		})
+	}
+}
+
+func TestProcessImport_FailedBatchCheckRejectsBatch(t *testing.T) {
+	const stored = "550e8400-e29b-41d4-a716-446655440001"
+	h := newTestHarness(t)
+	ctx := context.Background()
+	h.cfg.Import.FKCacheLimit = 1
+	h.userRepo.Create(ctx, &models.User{ID: stored, Email: "a@example.com"})
+	h.userRepo.Create(ctx, &models.User{ID: "550e8400-e29b-41d4-a716-446655440003", Email: "b@example.com"})
+	h.userRepo.LookupError = context.DeadlineExceeded
+
+	job := createTestJob(h, "articles", h.put(t, "articles.ndjson", []byte(articlesReferencing(stored, stored))))
+	if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport failed: %v", err)
+	}
+
+	// Unchecked rows are never inserted
+	if len(h.articleRepo.Articles) != 0 || job.SuccessfulCount != 0 || job.FailedCount != 2 {
+		t.Errorf("Expected the unchecked batch rejected, got %d inserted and %d/%d", len(h.articleRepo.Articles), job.SuccessfulCount, job.FailedCount)
+	}
+	errs := h.jobRepo.Errors[job.ID]
+	if len(errs) != 2 || errs[0].Line != 1 || errs[0].Code != validation.CodeCheckFailed {
+		t.Errorf("Expected a check_failed error per line, got %+v", errs)
+	}
+	if rejects := h.jobRepo.Rejects[job.ID]; len(rejects) != 2 || !rejects[0].Retryable {
+		t.Errorf("Expected both rows kept as retryable rejects, got %+v", rejects)
+	}
+}
+
+// A row rejected once its batch is checked or inserted was never stored, so
+// it does not make a later row with the same email or slug a duplicate
+func TestProcessImport_RejectedRowFreesItsKeys(t *testing.T) {
+	t.Run("failed insert", func(t *testing.T) {
+		h := newTestHarness(t)
+		h.cfg.Import.BatchSize = 1
+		calls := 0
+		h.userRepo.BatchInsertFunc = func(ctx context.Context, users []*models.User) (int, error) {
+			if calls++; calls == 1 {
+				return 0, errors.New("connection reset")
+			}
+			for _, u := range users {
+				h.userRepo.Users[u.ID] = u
+			}
+			return len(users), nil
+		}
+		csv := "id,email,name,role,active,created_at\n" +
+			"11111111-1111-1111-1111-111111111111,ada@example.com,Ada,admin,true,2024-01-01T00:00:00Z\n" +
+			"22222222-2222-2222-2222-222222222222,ada@example.com,Ada,admin,true,2024-01-01T00:00:00Z\n"
+
+		job := createTestJob(h, "users", h.put(t, "users.csv", []byte(csv)))
+		if err := h.services.Import.ProcessImport(context.Background(), job); err != nil {
+			t.Fatalf("ProcessImport failed: %v", err)
+		}
+		if job.SuccessfulCount != 1 || h.userRepo.Users["22222222-2222-2222-2222-222222222222"] == nil {
+			t.Errorf("Expected line 2 imported after line 1 failed to insert, got %d ok: %+v", job.SuccessfulCount, h.jobRepo.Errors[job.ID])
+		}
+	})
+
+	t.Run("rejected by batch check", func(t *testing.T) {
+		h := newTestHarness(t)
+		h.cfg.Import.BatchSize = 1
+		ndjson := `{"id":"a1","slug":"hello","title":"Hello","body":"b","author_id":"crm-missing","status":"draft"}` + "\n" +
+			`{"id":"a2","slug":"hello","title":"Hello","body":"b","author_id":"` + bundleUserID + `","status":"draft"}` + "\n"
+
+		job := createTestJob(h, "articles", h.put(t, "articles.ndjson", []byte(ndjson)))
+		job.Options = &models.ImportOptions{Source: "crm"}
+		if err := h.services.Import.ProcessImport(context.Background(), job); err != nil {
+			t.Fatalf("ProcessImport failed: %v", err)
+		}
+		errs := h.jobRepo.Errors[job.ID]
+		if job.SuccessfulCount != 1 || len(errs) != 1 || errs[0].Line != 1 || errs[0].Code != validation.CodeUnknownExternalID {
+			t.Errorf("Expected only line 1 rejected, for its unknown author, got %d ok: %+v", job.SuccessfulCount, errs)
+		}
+	})
+}
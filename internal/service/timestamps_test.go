@@ -0,0 +1,72 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+)
+
+const naiveDatesCSV = `id,email,name,role,active,created_at,updated_at
+11111111-1111-1111-1111-111111111111,ada@example.com,Ada,admin,true,2024-01-05 10:00:00,1704448800
+22222222-2222-2222-2222-222222222222,bob@example.com,Bob,viewer,true,2024-01-06,
+`
+
+func TestImport_DateLayoutsAndTimezone(t *testing.T) {
+	h := newTestHarness(t)
+	req := &models.ImportRequest{Resource: "users", Options: &models.ImportOptions{
+		DateLayouts: []string{"datetime", "date", "unix"},
+		Timezone:    "America/New_York",
+	}}
+
+	job, err := h.services.Import.StreamImport(context.Background(), req, "csv", strings.NewReader(naiveDatesCSV), nil)
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+	if job.SuccessfulCount != 2 {
+		t.Fatalf("Expected both users imported, got %d: %+v", job.SuccessfulCount, h.jobRepo.Errors[job.ID])
+	}
+
+	ada := h.userRepo.Users["11111111-1111-1111-1111-111111111111"]
+	if want := time.Date(2024, 1, 5, 15, 0, 0, 0, time.UTC); !ada.CreatedAt.Equal(want) {
+		t.Errorf("Expected the naive created_at read in New York time (%v), got %v", want, ada.CreatedAt)
+	}
+	if want := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC); ada.SourceUpdatedAt == nil || !ada.SourceUpdatedAt.Equal(want) {
+		t.Errorf("Expected the epoch updated_at %v kept as the source's, got %v", want, ada.SourceUpdatedAt)
+	}
+	bob := h.userRepo.Users["22222222-2222-2222-2222-222222222222"]
+	if want := time.Date(2024, 1, 6, 5, 0, 0, 0, time.UTC); !bob.CreatedAt.Equal(want) {
+		t.Errorf("Expected the date-only created_at at New York midnight (%v), got %v", want, bob.CreatedAt)
+	}
+}
+
+func TestImport_DefaultLayoutRejectsNaiveDates(t *testing.T) {
+	h := newTestHarness(t)
+
+	job, err := h.services.Import.StreamImport(context.Background(), &models.ImportRequest{Resource: "users"}, "csv",
+		strings.NewReader(naiveDatesCSV), nil)
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+	if job.FailedCount != 2 {
+		t.Errorf("Expected both users rejected without date options, got %d failed", job.FailedCount)
+	}
+}
+
+func TestImport_RejectsInvalidDateOptions(t *testing.T) {
+	h := newTestHarness(t)
+
+	for _, opts := range []*models.ImportOptions{
+		{DateLayouts: []string{"yyyy-mm-dd"}},
+		{Timezone: "Nowhere/Special"},
+	} {
+		_, err := h.services.Import.CreateImportJob(context.Background(), &models.ImportRequest{Resource: "users", Options: opts}, "uploads/users.csv")
+		if !errors.Is(err, service.ErrInvalidDateOptions) {
+			t.Errorf("%+v: expected ErrInvalidDateOptions, got %v", opts, err)
+		}
+	}
+}
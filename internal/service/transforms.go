@@ -0,0 +1,40 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/transform"
+	"github.com/bulk-import-export-api/internal/validation"
+)
+
+// ErrInvalidTransform is returned for import options naming a transform
+// that is unknown or does not fit the resource
+var ErrInvalidTransform = errors.New("invalid transform")
+
+// transforms builds the transform pipeline of an import's options, nil if
+// it has none
+func (s *importService) transforms(resource string, opts *models.ImportOptions) (*transform.Pipeline, error) {
+	return buildTransforms(s.rules, resource, opts)
+}
+
+// buildTransforms builds the transform pipeline of an import's options,
+// nil if it has none. Enum folding uses the validation rules in effect.
+func buildTransforms(store *validation.RuleStore, resource string, opts *models.ImportOptions) (*transform.Pipeline, error) {
+	if opts == nil || len(opts.Transforms) == 0 {
+		return nil, nil
+	}
+	schema, ok := resourceSchemas[resource]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s files take transforms per file, in manifest.json", ErrInvalidTransform, resource)
+	}
+	rules := store.Rules()
+	pipeline, err := transform.New(schema, opts.Transforms, func(field string) []string {
+		return rules.Enum(resource, field)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTransform, err)
+	}
+	return pipeline, nil
+}
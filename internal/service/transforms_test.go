@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+)
+
+const messyUsersNDJSON = `{"id":"11111111-1111-1111-1111-111111111111","email":" ADA@EXAMPLE.COM","name":"Ada","role":"Admin","active":"YES","created_at":"2024-01-01T00:00:00Z"}
+{"id":"22222222-2222-2222-2222-222222222222","email":"bob@example.com","name":"Bob","role":"viewer","active":true,"created_at":"2024-01-02T00:00:00Z"}
+`
+
+func TestImport_TransformsFixRowsAndAreAudited(t *testing.T) {
+	h := newTestHarness(t)
+	req := &models.ImportRequest{Resource: "users", Options: &models.ImportOptions{Transforms: []models.Transform{
+		{Name: "trim"},
+		{Name: "lowercase", Field: "email"},
+		{Name: "fold_enum", Field: "role"},
+		{Name: "coerce_bool", Field: "active"},
+	}}}
+
+	job, err := h.services.Import.StreamImport(context.Background(), req, "ndjson", strings.NewReader(messyUsersNDJSON), nil)
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+	if job.SuccessfulCount != 2 || job.FailedCount != 0 {
+		t.Fatalf("Expected both rows imported, got %d/%d: %+v", job.SuccessfulCount, job.FailedCount, h.jobRepo.Errors[job.ID])
+	}
+	ada := h.userRepo.Users["11111111-1111-1111-1111-111111111111"]
+	if ada == nil || ada.Email != "ada@example.com" || ada.Role != "admin" || !ada.Active {
+		t.Errorf("Expected a normalized user, got %+v", ada)
+	}
+
+	audit := h.jobRepo.Transforms[job.ID]
+	if len(audit) != 4 {
+		t.Fatalf("Expected 4 recorded changes, got %+v", audit)
+	}
+	for _, r := range audit {
+		if r.Line != 1 {
+			t.Errorf("Expected every change on line 1, got %+v", r)
+		}
+	}
+	if r := audit[1]; r.Field != "email" || r.Transform != "lowercase" || r.Before != "ADA@EXAMPLE.COM" || r.After != "ada@example.com" {
+		t.Errorf("Expected the email lowercase recorded, got %+v", r)
+	}
+}
+
+func TestImport_WithoutTransformsKeepsRowsAsIs(t *testing.T) {
+	h := newTestHarness(t)
+
+	job, err := h.services.Import.StreamImport(context.Background(), &models.ImportRequest{Resource: "users"}, "ndjson",
+		strings.NewReader(messyUsersNDJSON), nil)
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+	if job.FailedCount != 1 || len(h.jobRepo.Transforms[job.ID]) != 0 {
+		t.Errorf("Expected the messy row rejected and nothing audited, got %d failed, %d changes",
+			job.FailedCount, len(h.jobRepo.Transforms[job.ID]))
+	}
+}
+
+func TestImport_RejectsInvalidTransforms(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		resource   string
+		transforms []models.Transform
+	}{
+		{"users", []models.Transform{{Name: "uppercase", Field: "name"}}},
+		{"users", []models.Transform{{Name: "fold_enum", Field: "name"}}},
+		{"articles", []models.Transform{{Name: "default", Field: "status"}}},
+		{"bundle", []models.Transform{{Name: "trim"}}},
+	}
+	for _, tt := range tests {
+		req := &models.ImportRequest{Resource: tt.resource, Options: &models.ImportOptions{Transforms: tt.transforms}}
+		var err error
+		if tt.resource == "bundle" {
+			_, err = h.services.Import.CreateBundleImportJob(ctx, req, "uploads/bundle.zip")
+		} else {
+			_, err = h.services.Import.CreateImportJob(ctx, req, "uploads/users.csv")
+		}
+		if !errors.Is(err, service.ErrInvalidTransform) {
+			t.Errorf("%s %+v: expected ErrInvalidTransform, got %v", tt.resource, tt.transforms, err)
+		}
+	}
+	if len(h.jobRepo.Jobs) != 0 {
+		t.Errorf("Expected no jobs created, got %d", len(h.jobRepo.Jobs))
+	}
+}
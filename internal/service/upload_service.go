@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/config"
+	"github.com/bulk-import-export-api/internal/format"
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/repository"
+	"github.com/bulk-import-export-api/internal/storage"
+	"github.com/bulk-import-export-api/internal/validation"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Upload session errors, mapped to HTTP statuses by the handler
+var (
+	ErrInvalidUpload    = errors.New("invalid upload")
+	ErrUploadNotFound   = errors.New("upload not found")
+	ErrOffsetMismatch   = errors.New("upload offset mismatch")
+	ErrUploadTooLarge   = errors.New("chunk exceeds declared upload size")
+	ErrUploadIncomplete = errors.New("upload incomplete")
+)
+
+// Headers carrying an upload session's progress, as in the tus protocol
+const (
+	UploadOffsetHeader = "Upload-Offset"
+	UploadLengthHeader = "Upload-Length"
+)
+
+const (
+	// defaultUploadTTL applies when no session TTL is configured
+	defaultUploadTTL = 24 * time.Hour
+
+	// uploadSweepInterval is how often expired sessions are removed
+	uploadSweepInterval = time.Minute
+)
+
+// uploadService is the concrete implementation of UploadService
+type uploadService struct {
+	repos         *repository.Repositories
+	blobs         storage.Blob
+	importService ImportService
+	rules         *validation.RuleStore
+	cfg           *config.Config
+	log           zerolog.Logger
+
+	// locks serialises chunk writes and completion per session, so
+	// concurrent PATCHes cannot interleave bytes in the partial file
+	locks sync.Map // upload ID -> *sync.Mutex
+}
+
+// newUploadService creates a new UploadService
+func newUploadService(repos *repository.Repositories, blobs storage.Blob, importService ImportService, rules *validation.RuleStore, cfg *config.Config, log zerolog.Logger) *uploadService {
+	return &uploadService{
+		repos:         repos,
+		blobs:         blobs,
+		importService: importService,
+		rules:         rules,
+		cfg:           cfg,
+		log:           log.With().Str("service", "upload").Logger(),
+	}
+}
+
+// ttl returns how long a session lives after its last chunk
+func (s *uploadService) ttl() time.Duration {
+	if s.cfg.Import.UploadSessionTTL > 0 {
+		return s.cfg.Import.UploadSessionTTL
+	}
+	return defaultUploadTTL
+}
+
+// lock acquires the session's mutex and returns its release
+func (s *uploadService) lock(id string) func() {
+	mu, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// CreateUpload opens an upload session and creates its empty partial file.
+// A repeated idempotency key returns the existing session.
+func (s *uploadService) CreateUpload(ctx context.Context, req *models.UploadRequest) (*models.Upload, error) {
+	if req.IdempotencyKey != "" {
+		existing, err := s.repos.Upload.GetByIdempotencyKey(ctx, req.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+
+	if req.Size <= 0 {
+		return nil, fmt.Errorf("%w: size must be a positive number of bytes", ErrInvalidUpload)
+	}
+	if req.Size > s.cfg.Import.MaxUploadSize {
+		return nil, fmt.Errorf("%w: file too large, max size is %d MB", ErrInvalidUpload, s.cfg.Import.MaxUploadSize/(1024*1024))
+	}
+
+	ext, formatName, err := uploadFormat(req)
+	if err != nil {
+		return nil, err
+	}
+	// Reject bad options now rather than after the whole file is sent
+	if err := checkImportOptions(s.rules, req.Resource, req.Options); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+	}
+
+	if err := os.MkdirAll(s.cfg.Import.UploadDir, 0755); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	upload := &models.Upload{
+		ID:             uuid.New().String(),
+		Resource:       req.Resource,
+		Format:         formatName,
+		Filename:       req.Filename,
+		Size:           req.Size,
+		IdempotencyKey: req.IdempotencyKey,
+		Options:        req.Options,
+		ExpiresAt:      now.Add(s.ttl()),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	// Stored under the format's canonical extension, like a direct upload,
+	// so the completed file can be handed to the import job as is
+	upload.FilePath = filepath.Join(s.cfg.Import.UploadDir, fmt.Sprintf("%s_%s%s", req.Resource, upload.ID[:8], ext))
+
+	f, err := os.Create(upload.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	if err := s.repos.Upload.Create(ctx, upload); err != nil {
+		os.Remove(upload.FilePath)
+		return nil, err
+	}
+
+	s.log.Info().
+		Str("upload_id", upload.ID).
+		Str("resource", upload.Resource).
+		Str("format", upload.Format).
+		Int64("size_bytes", upload.Size).
+		Msg("Upload session created")
+
+	return upload, nil
+}
+
+// uploadFormat resolves the stored file extension and format name for a
+// session: bundles need an archive file name; otherwise an explicit format
+// wins over the file extension
+func uploadFormat(req *models.UploadRequest) (string, string, error) {
+	switch req.Resource {
+	case "users", "articles", "comments":
+	case models.ResourceBundle:
+		ext, ok := BundleArchiveExt(req.Filename)
+		if !ok {
+			return "", "", fmt.Errorf("%w: bundle upload requires a .zip, .tar, .tar.gz or .tgz filename", ErrInvalidUpload)
+		}
+		return ext, "bundle", nil
+	default:
+		return "", "", fmt.Errorf("%w: resource must be one of: users, articles, comments, bundle", ErrInvalidUpload)
+	}
+
+	var f *format.Format
+	var ok bool
+	if req.Format != "" {
+		f, ok = format.Lookup(req.Format)
+	} else {
+		f, ok = format.ForExtension(filepath.Ext(req.Filename))
+	}
+	if !ok || f.NewDecoder == nil {
+		return "", "", fmt.Errorf("%w: unsupported file format", ErrInvalidUpload)
+	}
+	return f.Extension(), f.Name, nil
+}
+
+// GetUpload returns a live session; expired sessions are not found even
+// before the sweeper removes them
+func (s *uploadService) GetUpload(ctx context.Context, id string) (*models.Upload, error) {
+	upload, err := s.repos.Upload.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil || time.Now().After(upload.ExpiresAt) {
+		return nil, ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// WriteChunk appends a chunk at offset, which must equal the bytes already
+// received. Whatever part of the chunk arrives is kept and recorded even if
+// the body is cut off, so the client resumes from the new offset.
+func (s *uploadService) WriteChunk(ctx context.Context, id string, offset int64, chunk io.Reader) (*models.Upload, error) {
+	unlock := s.lock(id)
+	defer unlock()
+
+	upload, err := s.GetUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset {
+		return upload, fmt.Errorf("%w: expected offset %d, got %d", ErrOffsetMismatch, upload.Offset, offset)
+	}
+
+	f, err := os.OpenFile(upload.FilePath, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Bytes past the recorded offset were never acknowledged; drop them
+	if err := f.Truncate(upload.Offset); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(upload.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	remaining := upload.Size - upload.Offset
+	n, copyErr := io.Copy(f, io.LimitReader(chunk, remaining+1))
+	if n > remaining {
+		f.Truncate(upload.Offset)
+		return upload, fmt.Errorf("%w: %d bytes remaining", ErrUploadTooLarge, remaining)
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	// Recorded even when the client has gone away mid-chunk and cancelled
+	// the request context
+	if n > 0 {
+		upload.Offset += n
+		upload.ExpiresAt = time.Now().Add(s.ttl())
+		if err := s.repos.Upload.UpdateOffset(context.WithoutCancel(ctx), upload.ID, upload.Offset, upload.ExpiresAt); err != nil {
+			return nil, err
+		}
+	}
+	if copyErr != nil {
+		s.log.Warn().Err(copyErr).Str("upload_id", id).Int64("offset", upload.Offset).Msg("Upload chunk interrupted")
+		return upload, copyErr
+	}
+
+	return upload, nil
+}
+
+// CompleteUpload turns a fully received session into an import job. It is
+// idempotent: completing again returns the same job.
+func (s *uploadService) CompleteUpload(ctx context.Context, id string) (*models.Job, error) {
+	unlock := s.lock(id)
+	defer unlock()
+
+	upload, err := s.GetUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.JobID != "" {
+		return s.repos.Job.GetByID(ctx, upload.JobID)
+	}
+	if upload.Offset < upload.Size {
+		return nil, fmt.Errorf("%w: received %d of %d bytes", ErrUploadIncomplete, upload.Offset, upload.Size)
+	}
+	if err := s.store(ctx, upload); err != nil {
+		return nil, err
+	}
+
+	req := &models.ImportRequest{Resource: upload.Resource, Options: upload.Options}
+	var job *models.Job
+	if upload.Resource == models.ResourceBundle {
+		job, err = s.importService.CreateBundleImportJob(ctx, req, upload.FilePath)
+	} else {
+		job, err = s.importService.CreateImportJob(ctx, req, upload.FilePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Upload.SetJobID(ctx, upload.ID, job.ID); err != nil {
+		return nil, err
+	}
+
+	s.log.Info().Str("upload_id", upload.ID).Str("job_id", job.ID).Msg("Upload completed")
+	return job, nil
+}
+
+// store hands the assembled file to artifact storage under the same key.
+// Chunks are staged on the local disk, which already is the store when
+// artifacts are kept locally; otherwise the file is copied and the staged
+// one removed.
+func (s *uploadService) store(ctx context.Context, upload *models.Upload) error {
+	if info, err := s.blobs.Stat(ctx, upload.FilePath); err == nil && info.Size == upload.Size {
+		return nil
+	}
+
+	f, err := os.Open(upload.FilePath)
+	if err != nil {
+		return err
+	}
+	err = s.blobs.Put(ctx, upload.FilePath, f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	os.Remove(upload.FilePath)
+	return nil
+}
+
+// DeleteUpload abandons a session. The partial file is removed unless the
+// upload was completed, in which case it belongs to the import job.
+func (s *uploadService) DeleteUpload(ctx context.Context, id string) error {
+	unlock := s.lock(id)
+	defer unlock()
+
+	upload, err := s.GetUpload(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.remove(ctx, upload)
+}
+
+// remove deletes a session and, for uncompleted ones, its partial file
+func (s *uploadService) remove(ctx context.Context, upload *models.Upload) error {
+	if upload.JobID == "" {
+		if err := os.Remove(upload.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		// A completion that failed after storing the file leaves it there
+		if err := s.blobs.Delete(ctx, upload.FilePath); err != nil {
+			return err
+		}
+	}
+	if err := s.repos.Upload.Delete(ctx, upload.ID); err != nil {
+		return err
+	}
+	s.locks.Delete(upload.ID)
+	return nil
+}
+
+// ExpireUploads removes every session past its expiry and returns how many
+// were removed
+func (s *uploadService) ExpireUploads(ctx context.Context) (int, error) {
+	expired, err := s.repos.Upload.GetExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, upload := range expired {
+		unlock := s.lock(upload.ID)
+		err := s.remove(ctx, upload)
+		unlock()
+		if err != nil {
+			s.log.Error().Err(err).Str("upload_id", upload.ID).Msg("Failed to remove expired upload")
+			continue
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		s.log.Info().Int("removed", removed).Msg("Expired upload sessions removed")
+	}
+	return removed, nil
+}
+
+// StartSweeper removes expired sessions periodically until ctx is done
+func (s *uploadService) StartSweeper(ctx context.Context) {
+	ticker := time.NewTicker(uploadSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ExpireUploads(ctx); err != nil {
+				s.log.Error().Err(err).Msg("Failed to sweep expired uploads")
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/service"
+)
+
+const uploadCSV = "id,email,name,role,active,created_at\n" +
+	"11111111-1111-1111-1111-111111111111,ada@example.com,Ada,admin,true,2024-01-01T00:00:00Z\n" +
+	"22222222-2222-2222-2222-222222222222,bob@example.com,Bob,viewer,false,2024-01-02T00:00:00Z\n"
+
+func createUpload(t *testing.T, h *testHarness, req *models.UploadRequest) *models.Upload {
+	t.Helper()
+	upload, err := h.services.Upload.CreateUpload(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateUpload failed: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(upload.FilePath) })
+	return upload
+}
+
+func TestUpload_ChunksResumeAndComplete(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	upload := createUpload(t, h, &models.UploadRequest{Resource: "users", Filename: "users.csv", Size: int64(len(uploadCSV))})
+	if upload.Format != "csv" || !strings.HasSuffix(upload.FilePath, ".csv") {
+		t.Fatalf("Expected csv format from the filename, got %q (%s)", upload.Format, upload.FilePath)
+	}
+
+	// The first chunk is cut off part way: what arrived is kept
+	cut := 40
+	got, err := h.services.Upload.WriteChunk(ctx, upload.ID, 0, io.MultiReader(strings.NewReader(uploadCSV[:cut]), errReader{}))
+	if err == nil {
+		t.Fatal("Expected the interrupted chunk to return an error")
+	}
+	if got.Offset != int64(cut) {
+		t.Fatalf("Expected offset %d after interrupted chunk, got %d", cut, got.Offset)
+	}
+
+	// Resuming from a stale offset is refused
+	if _, err := h.services.Upload.WriteChunk(ctx, upload.ID, 0, strings.NewReader(uploadCSV)); !errors.Is(err, service.ErrOffsetMismatch) {
+		t.Errorf("Expected ErrOffsetMismatch, got %v", err)
+	}
+
+	if _, err := h.services.Upload.CompleteUpload(ctx, upload.ID); !errors.Is(err, service.ErrUploadIncomplete) {
+		t.Errorf("Expected ErrUploadIncomplete, got %v", err)
+	}
+
+	got, err = h.services.Upload.WriteChunk(ctx, upload.ID, int64(cut), strings.NewReader(uploadCSV[cut:]))
+	if err != nil {
+		t.Fatalf("WriteChunk failed: %v", err)
+	}
+	if got.Offset != got.Size {
+		t.Fatalf("Expected upload complete at %d bytes, got %d", got.Size, got.Offset)
+	}
+
+	data, err := os.ReadFile(upload.FilePath)
+	if err != nil {
+		t.Fatalf("Failed to read partial file: %v", err)
+	}
+	if string(data) != uploadCSV {
+		t.Errorf("Expected reassembled file to match the upload, got %q", data)
+	}
+
+	job, err := h.services.Upload.CompleteUpload(ctx, upload.ID)
+	if err != nil {
+		t.Fatalf("CompleteUpload failed: %v", err)
+	}
+	if job.Resource != "users" || job.FilePath != upload.FilePath || job.Status != models.JobStatusPending {
+		t.Errorf("Expected pending users job on the uploaded file, got %+v", job)
+	}
+
+	if info, err := h.blobs.Stat(ctx, job.FilePath); err != nil || info.Size != upload.Size {
+		t.Errorf("Expected the completed file in artifact storage, got %+v (%v)", info, err)
+	}
+
+	again, err := h.services.Upload.CompleteUpload(ctx, upload.ID)
+	if err != nil || again.ID != job.ID {
+		t.Errorf("Expected completing again to return job %s, got %v (%v)", job.ID, again, err)
+	}
+
+	if err := h.services.Import.ProcessImport(ctx, job); err != nil {
+		t.Fatalf("ProcessImport failed: %v", err)
+	}
+	if job.SuccessfulCount != 2 {
+		t.Errorf("Expected 2 users imported, got %d", job.SuccessfulCount)
+	}
+}
+
+func TestUpload_ChunkPastDeclaredSize(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	upload := createUpload(t, h, &models.UploadRequest{Resource: "users", Format: "csv", Size: 10})
+
+	if _, err := h.services.Upload.WriteChunk(ctx, upload.ID, 0, strings.NewReader(uploadCSV)); !errors.Is(err, service.ErrUploadTooLarge) {
+		t.Fatalf("Expected ErrUploadTooLarge, got %v", err)
+	}
+
+	got, err := h.services.Upload.GetUpload(ctx, upload.ID)
+	if err != nil {
+		t.Fatalf("GetUpload failed: %v", err)
+	}
+	if got.Offset != 0 {
+		t.Errorf("Expected rejected chunk to leave offset at 0, got %d", got.Offset)
+	}
+	if info, _ := os.Stat(upload.FilePath); info == nil || info.Size() != 0 {
+		t.Errorf("Expected rejected chunk to be discarded from the partial file")
+	}
+}
+
+func TestUpload_InvalidRequests(t *testing.T) {
+	h := newTestHarness(t)
+
+	tests := []struct {
+		name string
+		req  models.UploadRequest
+	}{
+		{"unknown resource", models.UploadRequest{Resource: "posts", Format: "csv", Size: 10}},
+		{"no size", models.UploadRequest{Resource: "users", Format: "csv"}},
+		{"too large", models.UploadRequest{Resource: "users", Format: "csv", Size: 1 << 40}},
+		{"unknown format", models.UploadRequest{Resource: "users", Filename: "users.txt", Size: 10}},
+		{"bundle without archive name", models.UploadRequest{Resource: models.ResourceBundle, Filename: "backup.csv", Size: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := h.services.Upload.CreateUpload(context.Background(), &tt.req)
+			if !errors.Is(err, service.ErrInvalidUpload) {
+				t.Errorf("Expected ErrInvalidUpload, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUpload_IdempotencyKey(t *testing.T) {
+	h := newTestHarness(t)
+
+	req := &models.UploadRequest{Resource: "users", Format: "csv", Size: 10, IdempotencyKey: "upload-key"}
+	first := createUpload(t, h, req)
+	second := createUpload(t, h, req)
+
+	if first.ID != second.ID {
+		t.Errorf("Expected the same session for a repeated key, got %s and %s", first.ID, second.ID)
+	}
+}
+
+func TestUpload_Expiry(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	stale := createUpload(t, h, &models.UploadRequest{Resource: "users", Format: "csv", Size: 10})
+	live := createUpload(t, h, &models.UploadRequest{Resource: "users", Format: "csv", Size: 10})
+	h.uploadRepo.Uploads[stale.ID].ExpiresAt = time.Now().Add(-time.Minute)
+
+	if _, err := h.services.Upload.GetUpload(ctx, stale.ID); !errors.Is(err, service.ErrUploadNotFound) {
+		t.Errorf("Expected expired session to be not found, got %v", err)
+	}
+
+	removed, err := h.services.Upload.ExpireUploads(ctx)
+	if err != nil {
+		t.Fatalf("ExpireUploads failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 expired session removed, got %d", removed)
+	}
+	if _, err := os.Stat(stale.FilePath); !os.IsNotExist(err) {
+		t.Errorf("Expected expired partial file to be removed, got %v", err)
+	}
+	if _, err := h.services.Upload.GetUpload(ctx, live.ID); err != nil {
+		t.Errorf("Expected live session to survive, got %v", err)
+	}
+}
+
+// errReader fails every read, like a connection dropped mid-body
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/validation"
+)
+
+const warnArticlesNDJSON = `{"id":"aaaaaaaa-0000-0000-0000-000000000001","slug":"kept","title":"Kept","body":"b","author_id":"bbbbbbbb-0000-0000-0000-000000000001","status":"draft","created_at":"2023-05-01T00:00:00Z","updated_at":"soon"}
+{"id":"aaaaaaaa-0000-0000-0000-000000000002","slug":"Bad Slug","title":"Bad","body":"b","author_id":"bbbbbbbb-0000-0000-0000-000000000001","status":"draft","updated_at":"soon"}
+`
+
+func TestImport_WarningsDoNotRejectRows(t *testing.T) {
+	h := newTestHarness(t)
+
+	var results []models.LineResult
+	job, err := h.services.Import.StreamImport(context.Background(), &models.ImportRequest{Resource: "articles"}, "ndjson",
+		strings.NewReader(warnArticlesNDJSON), func(r models.LineResult) error {
+			results = append(results, r)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+	if job.SuccessfulCount != 1 || job.FailedCount != 1 || job.WarningCount != 2 {
+		t.Errorf("Expected 1 imported, 1 failed and 2 warnings, got %d/%d/%d", job.SuccessfulCount, job.FailedCount, job.WarningCount)
+	}
+
+	article := h.articleRepo.Articles["aaaaaaaa-0000-0000-0000-000000000001"]
+	if article == nil {
+		t.Fatal("Expected the article with a warning imported")
+	}
+	if want := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC); !article.CreatedAt.Equal(want) {
+		t.Errorf("Expected created_at %v kept from the file, got %v", want, article.CreatedAt)
+	}
+	if time.Since(article.UpdatedAt) > time.Minute || article.SourceUpdatedAt != nil {
+		t.Errorf("Expected the import time and no unreadable source updated_at, got %v and %v", article.UpdatedAt, article.SourceUpdatedAt)
+	}
+
+	if len(results) != 2 || results[0].Status != models.LineImported || len(results[0].Warnings) != 1 {
+		t.Fatalf("Expected line 1 imported with a warning, got %+v", results)
+	}
+	if w := results[0].Warnings[0]; w.Severity != models.SeverityWarning || w.Code != validation.CheckTimestamp || w.Field != "updated_at" {
+		t.Errorf("Expected an updated_at timestamp warning, got %+v", w)
+	}
+	if results[1].Status != models.LineFailed || len(results[1].Errors) != 2 || results[1].Errors[0].Code != validation.CheckPattern {
+		t.Errorf("Expected line 2 rejected for its slug with its warning, got %+v", results[1])
+	}
+
+	stored := h.jobRepo.Errors[job.ID]
+	severities := map[string]int{}
+	for _, e := range stored {
+		severities[e.Severity]++
+	}
+	if len(stored) != 3 || severities[models.SeverityError] != 1 || severities[models.SeverityWarning] != 2 {
+		t.Errorf("Expected 1 error and 2 warnings stored, got %+v", stored)
+	}
+}
+
+func TestImport_KeepsSourceUpdatedAt(t *testing.T) {
+	h := newTestHarness(t)
+	before := time.Now()
+	body := `{"id":"11111111-1111-1111-1111-111111111111","email":"ada@example.com","name":"Ada","role":"admin","active":true,"created_at":"2024-01-01T00:00:00Z","updated_at":"2024-02-01T00:00:00Z"}`
+
+	job, err := h.services.Import.StreamImport(context.Background(), &models.ImportRequest{Resource: "users"}, "ndjson",
+		strings.NewReader(body), nil)
+	if err != nil {
+		t.Fatalf("StreamImport failed: %v", err)
+	}
+	if job.WarningCount != 0 || len(h.jobRepo.Errors[job.ID]) != 0 {
+		t.Errorf("Expected no issues, got %+v", h.jobRepo.Errors[job.ID])
+	}
+	user := h.userRepo.Users["11111111-1111-1111-1111-111111111111"]
+	if want := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC); user == nil || user.SourceUpdatedAt == nil || !user.SourceUpdatedAt.Equal(want) {
+		t.Fatalf("Expected updated_at %v kept from the file, got %+v", want, user)
+	}
+	// An old source timestamp must not hide the row from incremental exports
+	if user.UpdatedAt.Before(before) {
+		t.Errorf("Expected updated_at stamped at import, got %v", user.UpdatedAt)
+	}
+}
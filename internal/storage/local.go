@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Local stores artifacts as files under a root directory. An empty root
+// takes keys as file paths as they are.
+type Local struct {
+	root string
+}
+
+// NewLocal creates a local store rooted at dir
+func NewLocal(dir string) *Local {
+	return &Local{root: dir}
+}
+
+// path maps a key to its file, refusing keys that climb out of the root
+func (l *Local) path(key string) (string, error) {
+	if l.root == "" {
+		return key, nil
+	}
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: key %q escapes the storage root", ErrInvalidLocation, key)
+	}
+	return filepath.Join(l.root, clean), nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return f, err
+}
+
+// Put writes to a temporary file beside the target and renames it into
+// place, so a failed write never leaves a partial artifact
+func (l *Local) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (*Info, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return nil, fmt.Errorf("%w: %s is a directory", ErrNotFound, key)
+	}
+	return &Info{Key: key, Size: fi.Size(), ModTime: fi.ModTime()}, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// Drop directories left empty, up to the root, so extracted bundles
+	// and dated prefixes do not accumulate
+	if l.root != "" {
+		for dir := filepath.Dir(path); dir != filepath.Clean(l.root); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// List walks the directory holding prefix. Keys are returned in the form
+// they are given: relative to the root with slashes, or as paths when the
+// store has no root.
+func (l *Local) List(ctx context.Context, prefix string) ([]Info, error) {
+	base := l.root
+	if base == "" {
+		base = filepath.Dir(prefix)
+	}
+
+	var infos []Info
+	err := filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		key := path
+		if l.root != "" {
+			rel, err := filepath.Rel(l.root, path)
+			if err != nil {
+				return err
+			}
+			key = filepath.ToSlash(rel)
+		}
+		// Temporary files of writes in progress are not artifacts yet
+		if !strings.HasPrefix(key, prefix) || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		infos = append(infos, Info{Key: key, Size: fi.Size(), ModTime: fi.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory keeps artifacts in memory. It accepts any key, including s3://
+// locations, so tests can stand it in for a Router.
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	data    []byte
+	modTime time.Time
+}
+
+// NewMemory creates an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{objects: make(map[string]memoryObject)}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	obj, ok := m.objects[key]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	// Stored slices are never written again, so readers can share them
+	return memoryReader{bytes.NewReader(obj.data)}, nil
+}
+
+func (m *Memory) Put(ctx context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.objects[key] = memoryObject{data: data, modTime: time.Now()}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) Stat(ctx context.Context, key string) (*Info, error) {
+	m.mu.RLock()
+	obj, ok := m.objects[key]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return &Info{Key: key, Size: int64(len(obj.data)), ModTime: obj.modTime}, nil
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.objects, key)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) List(ctx context.Context, prefix string) ([]Info, error) {
+	m.mu.RLock()
+	var infos []Info
+	for key, obj := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, Info{Key: key, Size: int64(len(obj.data)), ModTime: obj.modTime})
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
+
+// memoryReader is a ReadCloser that also offers random access, which the
+// zip and parquet readers use when it is available
+type memoryReader struct {
+	*bytes.Reader
+}
+
+func (memoryReader) Close() error { return nil }
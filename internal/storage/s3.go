@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/bulk-import-export-api/internal/config"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// minPartSize is the smallest multipart upload part S3 accepts
+const minPartSize = 5 * 1024 * 1024
+
+// S3 stores artifacts in an S3-compatible object store. The client is
+// created on first use, so a server without object store settings starts
+// normally and only s3:// locations fail.
+type S3 struct {
+	cfg config.StorageConfig
+
+	once   sync.Once
+	client *minio.Client
+	err    error
+}
+
+// NewS3 creates an object store client for the configured endpoint
+func NewS3(cfg config.StorageConfig) *S3 {
+	return &S3{cfg: cfg}
+}
+
+func (s *S3) connect() (*minio.Client, error) {
+	s.once.Do(func() {
+		var creds *credentials.Credentials
+		if s.cfg.S3AccessKey != "" {
+			creds = credentials.NewStaticV4(s.cfg.S3AccessKey, s.cfg.S3SecretKey, "")
+		} else {
+			// No configured keys: fall back to the usual AWS environment
+			// variables and instance credentials
+			creds = credentials.NewChainCredentials([]credentials.Provider{
+				&credentials.EnvAWS{},
+				&credentials.IAM{},
+			})
+		}
+		s.client, s.err = minio.New(s.cfg.S3Endpoint, &minio.Options{
+			Creds:  creds,
+			Secure: s.cfg.S3UseSSL,
+			Region: s.cfg.S3Region,
+		})
+		if s.err != nil {
+			s.err = fmt.Errorf("object store client: %w", s.err)
+		}
+	})
+	return s.client, s.err
+}
+
+// partSize is the multipart upload part size; each in-flight upload
+// buffers one part
+func (s *S3) partSize() uint64 {
+	if s.cfg.S3PartSize < minPartSize {
+		return minPartSize
+	}
+	return uint64(s.cfg.S3PartSize)
+}
+
+// Bucket returns a Blob for one bucket
+func (s *S3) Bucket(name string) Blob {
+	return &s3Bucket{s3: s, name: name}
+}
+
+// s3Bucket is a Blob whose keys are object names in one bucket
+type s3Bucket struct {
+	s3   *S3
+	name string
+}
+
+// notFound maps the object store's 404 onto ErrNotFound
+func (b *s3Bucket) notFound(err error, key string) error {
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s%s/%s", ErrNotFound, S3Scheme, b.name, key)
+	}
+	return err
+}
+
+func (b *s3Bucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	client, err := b.s3.connect()
+	if err != nil {
+		return nil, err
+	}
+	obj, err := client.GetObject(ctx, b.name, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject is lazy; Stat surfaces a missing object before the first
+	// read
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, b.notFound(err, key)
+	}
+	return s3Object{obj, info.Size}, nil
+}
+
+// s3Object is a ReadCloser that also offers random access through ranged
+// GETs, which the zip and parquet readers use
+type s3Object struct {
+	*minio.Object
+	size int64
+}
+
+func (o s3Object) Size() int64 { return o.size }
+
+// Put streams r as a multipart upload of unknown length. The upload is
+// aborted, leaving no object, if r fails part way.
+func (b *s3Bucket) Put(ctx context.Context, key string, r io.Reader) error {
+	client, err := b.s3.connect()
+	if err != nil {
+		return err
+	}
+	_, err = client.PutObject(ctx, b.name, key, r, -1, minio.PutObjectOptions{PartSize: b.s3.partSize()})
+	return err
+}
+
+func (b *s3Bucket) Stat(ctx context.Context, key string) (*Info, error) {
+	client, err := b.s3.connect()
+	if err != nil {
+		return nil, err
+	}
+	obj, err := client.StatObject(ctx, b.name, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, b.notFound(err, key)
+	}
+	return &Info{Key: key, Size: obj.Size, ModTime: obj.LastModified}, nil
+}
+
+func (b *s3Bucket) Delete(ctx context.Context, key string) error {
+	client, err := b.s3.connect()
+	if err != nil {
+		return err
+	}
+	// Removing a missing object succeeds, as Delete requires
+	return client.RemoveObject(ctx, b.name, key, minio.RemoveObjectOptions{})
+}
+
+// List pages through the bucket listing, which the store returns in key
+// order
+func (b *s3Bucket) List(ctx context.Context, prefix string) ([]Info, error) {
+	client, err := b.s3.connect()
+	if err != nil {
+		return nil, err
+	}
+	var infos []Info
+	for obj := range client.ListObjects(ctx, b.name, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		infos = append(infos, Info{Key: obj.Key, Size: obj.Size, ModTime: obj.LastModified})
+	}
+	return infos, nil
+}
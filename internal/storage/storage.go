@@ -0,0 +1,152 @@
+// Package storage reads and writes import and export artifacts wherever
+// they live: on the local disk or in an S3-compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/config"
+)
+
+// ErrNotFound is returned when no artifact exists at a location
+var ErrNotFound = errors.New("artifact not found")
+
+// ErrInvalidLocation is returned for a location no backend can handle
+var ErrInvalidLocation = errors.New("invalid storage location")
+
+// Blob stores artifacts by key. Readers and writers stream, so artifacts
+// are never held in memory whole (except by Memory, which exists for tests).
+type Blob interface {
+	// Get opens the artifact at key; the caller closes the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Put stores everything read from r at key, replacing any existing
+	// artifact. Nothing is stored if r returns an error.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Stat describes the artifact at key without opening it
+	Stat(ctx context.Context, key string) (*Info, error)
+	// Delete removes the artifact at key. Deleting a missing artifact is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+	// List describes every artifact whose key starts with prefix, in key
+	// order
+	List(ctx context.Context, prefix string) ([]Info, error)
+}
+
+// Info describes a stored artifact
+type Info struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// S3Scheme prefixes object store locations: s3://bucket/key
+const S3Scheme = "s3://"
+
+// IsS3 reports whether a location names an object in the object store
+func IsS3(location string) bool {
+	return strings.HasPrefix(location, S3Scheme)
+}
+
+// ParseS3 splits an s3://bucket/key location
+func ParseS3(location string) (bucket, key string, err error) {
+	if !IsS3(location) {
+		return "", "", fmt.Errorf("%w: %q is not an s3:// URL", ErrInvalidLocation, location)
+	}
+	bucket, key, _ = strings.Cut(strings.TrimPrefix(location, S3Scheme), "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("%w: %q needs a bucket and a key", ErrInvalidLocation, location)
+	}
+	return bucket, key, nil
+}
+
+// Router is a Blob whose keys are locations: s3://bucket/key goes to the
+// object store, anything else is a path on the local disk. Jobs record
+// their files as locations, so workers read them the same way wherever
+// they were stored.
+type Router struct {
+	local Blob
+	s3    *S3
+}
+
+// New creates a Router for the configured backends
+func New(cfg config.StorageConfig) *Router {
+	return NewRouter(NewLocal(""), NewS3(cfg))
+}
+
+// NewRouter creates a Router that keeps non-s3:// locations in local
+func NewRouter(local Blob, s3 *S3) *Router {
+	return &Router{local: local, s3: s3}
+}
+
+// backend returns the Blob holding location and the key within it
+func (r *Router) backend(location string) (Blob, string, error) {
+	if !IsS3(location) {
+		return r.local, location, nil
+	}
+	bucket, key, err := ParseS3(location)
+	if err != nil {
+		return nil, "", err
+	}
+	return r.s3.Bucket(bucket), key, nil
+}
+
+func (r *Router) Get(ctx context.Context, location string) (io.ReadCloser, error) {
+	b, key, err := r.backend(location)
+	if err != nil {
+		return nil, err
+	}
+	return b.Get(ctx, key)
+}
+
+func (r *Router) Put(ctx context.Context, location string, src io.Reader) error {
+	b, key, err := r.backend(location)
+	if err != nil {
+		return err
+	}
+	return b.Put(ctx, key, src)
+}
+
+func (r *Router) Stat(ctx context.Context, location string) (*Info, error) {
+	b, key, err := r.backend(location)
+	if err != nil {
+		return nil, err
+	}
+	info, err := b.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	info.Key = location
+	return info, nil
+}
+
+func (r *Router) Delete(ctx context.Context, location string) error {
+	b, key, err := r.backend(location)
+	if err != nil {
+		return err
+	}
+	return b.Delete(ctx, key)
+}
+
+// List takes a location prefix; an s3:// prefix must name its bucket
+func (r *Router) List(ctx context.Context, prefix string) ([]Info, error) {
+	if !IsS3(prefix) {
+		return r.local.List(ctx, prefix)
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(prefix, S3Scheme), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("%w: %q needs a bucket", ErrInvalidLocation, prefix)
+	}
+	infos, err := r.s3.Bucket(bucket).List(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	for i := range infos {
+		infos[i].Key = S3Scheme + bucket + "/" + infos[i].Key
+	}
+	return infos, nil
+}
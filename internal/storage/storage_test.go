@@ -0,0 +1,217 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bulk-import-export-api/internal/config"
+	"github.com/bulk-import-export-api/internal/storage"
+)
+
+func TestLocal_PutGet(t *testing.T) {
+	ctx := context.Background()
+	local := storage.NewLocal(t.TempDir())
+
+	if err := local.Put(ctx, "exports/users.csv", strings.NewReader("id\n1\n")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	r, err := local.Get(ctx, "exports/users.csv")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	defer r.Close()
+	data, _ := io.ReadAll(r)
+	if string(data) != "id\n1\n" {
+		t.Errorf("Expected stored content, got %q", data)
+	}
+}
+
+// Both backends honour the same contract
+func blobs(t *testing.T) map[string]storage.Blob {
+	return map[string]storage.Blob{
+		"local":  storage.NewLocal(t.TempDir()),
+		"memory": storage.NewMemory(),
+	}
+}
+
+func TestBlob_StatDeleteList(t *testing.T) {
+	for name, b := range blobs(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, key := range []string{"exports/b.csv", "exports/a.csv", "imports/c.csv"} {
+				if err := b.Put(ctx, key, strings.NewReader(key)); err != nil {
+					t.Fatalf("Put %s failed: %v", key, err)
+				}
+			}
+
+			info, err := b.Stat(ctx, "exports/a.csv")
+			if err != nil {
+				t.Fatalf("Stat failed: %v", err)
+			}
+			if info.Key != "exports/a.csv" || info.Size != int64(len("exports/a.csv")) || info.ModTime.IsZero() {
+				t.Errorf("Expected key, size and time of exports/a.csv, got %+v", info)
+			}
+
+			infos, err := b.List(ctx, "exports/")
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if len(infos) != 2 || infos[0].Key != "exports/a.csv" || infos[1].Key != "exports/b.csv" {
+				t.Errorf("Expected exports/a.csv and exports/b.csv in order, got %+v", infos)
+			}
+
+			if err := b.Delete(ctx, "exports/a.csv"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if err := b.Delete(ctx, "exports/a.csv"); err != nil {
+				t.Errorf("Expected deleting a missing artifact to succeed, got %v", err)
+			}
+			if _, err := b.Stat(ctx, "exports/a.csv"); !errors.Is(err, storage.ErrNotFound) {
+				t.Errorf("Expected ErrNotFound after Delete, got %v", err)
+			}
+			if _, err := b.Get(ctx, "exports/a.csv"); !errors.Is(err, storage.ErrNotFound) {
+				t.Errorf("Expected ErrNotFound from Get after Delete, got %v", err)
+			}
+
+			infos, _ = b.List(ctx, "")
+			if len(infos) != 2 {
+				t.Errorf("Expected 2 artifacts left, got %+v", infos)
+			}
+		})
+	}
+}
+
+func TestBlob_FailedPutKeepsPrevious(t *testing.T) {
+	for name, b := range blobs(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			b.Put(ctx, "users.csv", strings.NewReader("v1"))
+
+			if err := b.Put(ctx, "users.csv", io.MultiReader(strings.NewReader("v2"), errReader{})); err == nil {
+				t.Fatal("Expected Put to fail with the reader")
+			}
+
+			r, err := b.Get(ctx, "users.csv")
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			defer r.Close()
+			if data, _ := io.ReadAll(r); string(data) != "v1" {
+				t.Errorf("Expected the previous artifact to survive, got %q", data)
+			}
+		})
+	}
+}
+
+func TestBlob_ReadersSupportRandomAccess(t *testing.T) {
+	for name, b := range blobs(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			b.Put(ctx, "archive.zip", strings.NewReader("0123456789"))
+
+			r, err := b.Get(ctx, "archive.zip")
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			defer r.Close()
+			ra, ok := r.(io.ReaderAt)
+			if !ok {
+				t.Fatal("Expected the reader to implement io.ReaderAt")
+			}
+			buf := make([]byte, 3)
+			if _, err := ra.ReadAt(buf, 7); err != nil || string(buf) != "789" {
+				t.Errorf("Expected 789 at offset 7, got %q (%v)", buf, err)
+			}
+		})
+	}
+}
+
+func TestLocal_FailedPutLeavesNothing(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	local := storage.NewLocal(dir)
+
+	failing := io.MultiReader(strings.NewReader("partial"), errReader{})
+	if err := local.Put(ctx, "users.csv", failing); err == nil {
+		t.Fatal("Expected Put to fail with the reader")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected no files after a failed Put, got %d", len(entries))
+	}
+	if _, err := local.Get(ctx, "users.csv"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestLocal_RefusesEscapingKeys(t *testing.T) {
+	local := storage.NewLocal(t.TempDir())
+
+	for _, key := range []string{"../outside.csv", "a/../../outside.csv", "/etc/passwd"} {
+		if _, err := local.Get(context.Background(), key); !errors.Is(err, storage.ErrInvalidLocation) {
+			t.Errorf("Expected ErrInvalidLocation for %q, got %v", key, err)
+		}
+	}
+}
+
+func TestParseS3(t *testing.T) {
+	tests := []struct {
+		location string
+		bucket   string
+		key      string
+		valid    bool
+	}{
+		{"s3://imports/users.csv", "imports", "users.csv", true},
+		{"s3://imports/2024/01/users.csv", "imports", "2024/01/users.csv", true},
+		{"s3://imports", "", "", false},
+		{"s3://imports/", "", "", false},
+		{"s3:///users.csv", "", "", false},
+		{"/tmp/users.csv", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			bucket, key, err := storage.ParseS3(tt.location)
+			if !tt.valid {
+				if !errors.Is(err, storage.ErrInvalidLocation) {
+					t.Errorf("Expected ErrInvalidLocation, got %v", err)
+				}
+				return
+			}
+			if err != nil || bucket != tt.bucket || key != tt.key {
+				t.Errorf("Expected %s/%s, got %s/%s (%v)", tt.bucket, tt.key, bucket, key, err)
+			}
+		})
+	}
+}
+
+func TestRouter_LocalPaths(t *testing.T) {
+	ctx := context.Background()
+	router := storage.New(config.StorageConfig{})
+	path := filepath.Join(t.TempDir(), "users.ndjson")
+
+	if err := router.Put(ctx, path, strings.NewReader("{}\n")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	r, err := router.Get(ctx, path)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	r.Close()
+
+	if _, err := router.Get(ctx, "s3://bucket-only"); !errors.Is(err, storage.ErrInvalidLocation) {
+		t.Errorf("Expected ErrInvalidLocation for a location without a key, got %v", err)
+	}
+}
+
+// errReader fails every read, like a connection dropped mid-body
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
@@ -0,0 +1,213 @@
+// Package transform normalizes decoded records before they are validated,
+// fixing the values imports would otherwise reject for trivial reasons:
+// stray whitespace, case, boolean spellings, missing slugs and IDs. Every
+// change is returned so it can be audited.
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bulk-import-export-api/internal/format"
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// Names of the built-in transforms
+const (
+	Trim         = "trim"          // strip surrounding whitespace
+	Lowercase    = "lowercase"     // lowercase the value
+	FoldEnum     = "fold_enum"     // match an allowed value case-insensitively
+	CoerceBool   = "coerce_bool"   // TRUE, 1, yes, on, ... to true; FALSE, 0, no, off, ... to false
+	Slugify      = "slugify"       // fill an empty slug from another field
+	Default      = "default"       // fill an empty field with a value
+	GenerateUUID = "generate_uuid" // fill an empty ID with a new UUID
+)
+
+var boolSpellings = map[string]string{
+	"true": "true", "t": "true", "yes": "true", "y": "true", "1": "true", "on": "true",
+	"false": "false", "f": "false", "no": "false", "n": "false", "0": "false", "off": "false",
+}
+
+// EnumLookup returns the allowed values of a field, or none if it is not
+// an enum
+type EnumLookup func(field string) []string
+
+// Pipeline applies a job's transforms to records in order. It is not safe
+// for concurrent use.
+type Pipeline struct {
+	steps []step
+}
+
+type step struct {
+	name  string
+	field string
+	apply func(rec format.Record) (string, bool)
+}
+
+// New checks specs against the resource schema and builds their pipeline.
+// It returns nil when there are no transforms.
+func New(schema *format.Schema, specs []models.Transform, enums EnumLookup) (*Pipeline, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	p := &Pipeline{}
+	for i, spec := range specs {
+		steps, err := compile(schema, spec, enums)
+		if err != nil {
+			return nil, fmt.Errorf("transform %d (%s): %w", i+1, spec.Name, err)
+		}
+		p.steps = append(p.steps, steps...)
+	}
+	return p, nil
+}
+
+func compile(schema *format.Schema, spec models.Transform, enums EnumLookup) ([]step, error) {
+	field := spec.Field
+	switch spec.Name {
+	case Slugify:
+		if field == "" {
+			field = "slug"
+		}
+	case GenerateUUID:
+		if field == "" {
+			field = "id"
+		}
+	case Trim:
+		if field == "" {
+			// Every text field, in schema order
+			var steps []step
+			for _, f := range schema.Fields {
+				if f.Type == format.String || f.Type == format.StringList {
+					steps = append(steps, step{name: Trim, field: f.Name, apply: text(f.Name, strings.TrimSpace)})
+				}
+			}
+			return steps, nil
+		}
+	case Lowercase, FoldEnum, CoerceBool, Default:
+	default:
+		return nil, fmt.Errorf("unknown transform")
+	}
+	if field == "" {
+		return nil, fmt.Errorf("field is required")
+	}
+	if _, ok := schema.Field(field); !ok {
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+
+	s := step{name: spec.Name, field: field}
+	switch spec.Name {
+	case Trim:
+		s.apply = text(field, strings.TrimSpace)
+	case Lowercase:
+		s.apply = text(field, strings.ToLower)
+	case FoldEnum:
+		allowed := enums(field)
+		if len(allowed) == 0 {
+			return nil, fmt.Errorf("field %q has no allowed values to fold onto", field)
+		}
+		s.apply = text(field, func(v string) string {
+			for _, a := range allowed {
+				if strings.EqualFold(strings.TrimSpace(v), a) {
+					return a
+				}
+			}
+			return v
+		})
+	case CoerceBool:
+		s.apply = text(field, func(v string) string {
+			if b, ok := boolSpellings[strings.ToLower(strings.TrimSpace(v))]; ok {
+				return b
+			}
+			return v
+		})
+	case Slugify:
+		from := spec.From
+		if from == "" {
+			from = "title"
+		}
+		if _, ok := schema.Field(from); !ok {
+			return nil, fmt.Errorf("unknown field %q", from)
+		}
+		s.apply = fill(field, func(rec format.Record) string { return slug(rec.Text(from)) })
+	case Default:
+		if spec.Value == "" {
+			return nil, fmt.Errorf("value is required")
+		}
+		s.apply = fill(field, func(format.Record) string { return spec.Value })
+	case GenerateUUID:
+		s.apply = fill(field, func(format.Record) string { return uuid.New().String() })
+	}
+	return []step{s}, nil
+}
+
+// text rewrites a field's text value. Typed values (booleans, timestamps
+// from Parquet, Arrow or XLSX) are already normalized and left alone.
+func text(field string, fn func(string) string) func(format.Record) (string, bool) {
+	return func(rec format.Record) (string, bool) {
+		v, ok := rec[field].(string)
+		if !ok {
+			return "", false
+		}
+		out := fn(v)
+		if out == v {
+			return "", false
+		}
+		rec[field] = out
+		return out, true
+	}
+}
+
+// fill sets a field that has no value
+func fill(field string, fn func(format.Record) string) func(format.Record) (string, bool) {
+	return func(rec format.Record) (string, bool) {
+		if rec.Text(field) != "" {
+			return "", false
+		}
+		out := fn(rec)
+		if out == "" {
+			return "", false
+		}
+		rec[field] = out
+		return out, true
+	}
+}
+
+// Apply transforms rec in place and returns what changed
+func (p *Pipeline) Apply(rec format.Record, line int) []models.TransformRecord {
+	var changes []models.TransformRecord
+	for _, s := range p.steps {
+		before := rec.Text(s.field)
+		after, changed := s.apply(rec)
+		if !changed {
+			continue
+		}
+		changes = append(changes, models.TransformRecord{
+			Line:      line,
+			Field:     s.field,
+			Transform: s.name,
+			Before:    before,
+			After:     after,
+		})
+	}
+	return changes
+}
+
+// slug turns text into a kebab-case slug: lowercase letters and digits,
+// with every other run of characters replaced by one hyphen
+func slug(text string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(text) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package transform_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bulk-import-export-api/internal/format"
+	"github.com/bulk-import-export-api/internal/models"
+	"github.com/bulk-import-export-api/internal/transform"
+	"github.com/google/uuid"
+)
+
+var testSchema = &format.Schema{
+	Name: "articles",
+	Fields: []format.Field{
+		{Name: "id", Type: format.String},
+		{Name: "slug", Type: format.String},
+		{Name: "title", Type: format.String},
+		{Name: "status", Type: format.String},
+		{Name: "active", Type: format.Bool},
+		{Name: "tags", Type: format.StringList},
+	},
+}
+
+func testEnums(field string) []string {
+	if field == "status" {
+		return []string{"draft", "published", "archived"}
+	}
+	return nil
+}
+
+func TestPipeline_BuiltIns(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  models.Transform
+		in    format.Record
+		field string
+		want  string
+	}{
+		{"trim", models.Transform{Name: transform.Trim, Field: "title"}, format.Record{"title": "  Hello "}, "title", "Hello"},
+		{"lowercase", models.Transform{Name: transform.Lowercase, Field: "status"}, format.Record{"status": "DRAFT"}, "status", "draft"},
+		{"fold enum", models.Transform{Name: transform.FoldEnum, Field: "status"}, format.Record{"status": "Published"}, "status", "published"},
+		{"fold enum leaves unknown values", models.Transform{Name: transform.FoldEnum, Field: "status"}, format.Record{"status": "pending"}, "status", "pending"},
+		{"coerce bool yes", models.Transform{Name: transform.CoerceBool, Field: "active"}, format.Record{"active": "YES"}, "active", "true"},
+		{"coerce bool zero", models.Transform{Name: transform.CoerceBool, Field: "active"}, format.Record{"active": "0"}, "active", "false"},
+		{"slugify", models.Transform{Name: transform.Slugify}, format.Record{"title": "Hello, World! 2024"}, "slug", "hello-world-2024"},
+		{"slugify keeps a slug", models.Transform{Name: transform.Slugify}, format.Record{"slug": "mine", "title": "Other"}, "slug", "mine"},
+		{"default", models.Transform{Name: transform.Default, Field: "status", Value: "draft"}, format.Record{}, "status", "draft"},
+		{"default keeps a value", models.Transform{Name: transform.Default, Field: "status", Value: "draft"}, format.Record{"status": "archived"}, "status", "archived"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := transform.New(testSchema, []models.Transform{tt.spec}, testEnums)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			p.Apply(tt.in, 1)
+			if got := tt.in.Text(tt.field); got != tt.want {
+				t.Errorf("Expected %s %q, got %q", tt.field, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPipeline_RecordsChanges(t *testing.T) {
+	p, err := transform.New(testSchema, []models.Transform{
+		{Name: transform.Trim},
+		{Name: transform.FoldEnum, Field: "status"},
+		{Name: transform.GenerateUUID},
+	}, testEnums)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	rec := format.Record{"title": " T ", "status": " DRAFT", "active": true}
+	changes := p.Apply(rec, 7)
+	if len(changes) != 4 {
+		t.Fatalf("Expected 4 changes, got %+v", changes)
+	}
+	if c := changes[0]; c.Line != 7 || c.Field != "title" || c.Transform != transform.Trim || c.Before != " T " || c.After != "T" {
+		t.Errorf("Expected title trim recorded, got %+v", c)
+	}
+	if c := changes[2]; c.Field != "status" || c.Transform != transform.FoldEnum || c.Before != "DRAFT" || c.After != "draft" {
+		t.Errorf("Expected status fold recorded after trim, got %+v", c)
+	}
+	if _, err := uuid.Parse(rec.Text("id")); err != nil || changes[3].Before != "" {
+		t.Errorf("Expected a generated id, got %q (%+v)", rec.Text("id"), changes[3])
+	}
+	if rec["active"] != true {
+		t.Errorf("Expected typed values left alone, got %v", rec["active"])
+	}
+
+	if again := p.Apply(format.Record{"id": "x", "title": "T", "status": "draft"}, 8); len(again) != 0 {
+		t.Errorf("Expected no changes to a clean record, got %+v", again)
+	}
+}
+
+func TestNew_RejectsInvalidSpecs(t *testing.T) {
+	tests := []struct {
+		spec models.Transform
+		want string
+	}{
+		{models.Transform{Name: "uppercase", Field: "title"}, "unknown transform"},
+		{models.Transform{Name: transform.Lowercase}, "field is required"},
+		{models.Transform{Name: transform.Trim, Field: "nope"}, `unknown field "nope"`},
+		{models.Transform{Name: transform.FoldEnum, Field: "title"}, "no allowed values"},
+		{models.Transform{Name: transform.Slugify, From: "nope"}, `unknown field "nope"`},
+		{models.Transform{Name: transform.Default, Field: "status"}, "value is required"},
+	}
+	for _, tt := range tests {
+		_, err := transform.New(testSchema, []models.Transform{{Name: transform.Trim}, tt.spec}, testEnums)
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "transform 2") {
+			t.Errorf("%+v: expected error containing %q, got %v", tt.spec, tt.want, err)
+		}
+	}
+
+	if p, err := transform.New(testSchema, nil, testEnums); p != nil || err != nil {
+		t.Errorf("Expected no pipeline without transforms, got %v, %v", p, err)
+	}
+}
@@ -0,0 +1,513 @@
+package validation
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bulk-import-export-api/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// RuleSet holds the declarative field rules of each resource, keyed by
+// resource name. It is what a rules file contains.
+type RuleSet map[string][]FieldRule
+
+// FieldRule is a set of checks on one field of a record. Unset checks are
+// skipped; checks other than required and forbidden only apply to values
+// that are present.
+type FieldRule struct {
+	Field string `json:"field" yaml:"field"`
+
+	// When limits the rule to records matching a condition on another field
+	When *Condition `json:"when,omitempty" yaml:"when,omitempty"`
+
+	Required  *bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Forbidden *bool    `json:"forbidden,omitempty" yaml:"forbidden,omitempty"`
+	Pattern   *string  `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Enum      []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+	MinLength *int     `json:"min_length,omitempty" yaml:"min_length,omitempty"` // in characters
+	MaxLength *int     `json:"max_length,omitempty" yaml:"max_length,omitempty"`
+	MinWords  *int     `json:"min_words,omitempty" yaml:"min_words,omitempty"`
+	MaxWords  *int     `json:"max_words,omitempty" yaml:"max_words,omitempty"`
+	Timestamp *bool    `json:"timestamp,omitempty" yaml:"timestamp,omitempty"` // ISO 8601 timestamp
+	NotBefore *string  `json:"not_before,omitempty" yaml:"not_before,omitempty"`
+	NotAfter  *string  `json:"not_after,omitempty" yaml:"not_after,omitempty"`
+
+	// Messages replaces the error message of a check, keyed by its name
+	Messages map[string]string `json:"messages,omitempty" yaml:"messages,omitempty"`
+
+	// Severity of the rule's failures: error (the default) rejects the
+	// record, warning and info only report it
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+}
+
+// Condition matches records by the value of one field
+type Condition struct {
+	Field   string   `json:"field" yaml:"field"`
+	Equals  *string  `json:"equals,omitempty" yaml:"equals,omitempty"`
+	In      []string `json:"in,omitempty" yaml:"in,omitempty"`
+	Present *bool    `json:"present,omitempty" yaml:"present,omitempty"`
+}
+
+// Check names, as used in FieldRule.Messages
+const (
+	CheckRequired  = "required"
+	CheckForbidden = "forbidden"
+	CheckPattern   = "pattern"
+	CheckEnum      = "enum"
+	CheckMinLength = "min_length"
+	CheckMaxLength = "max_length"
+	CheckMinWords  = "min_words"
+	CheckMaxWords  = "max_words"
+	CheckTimestamp = "timestamp"
+	CheckNotBefore = "not_before"
+	CheckNotAfter  = "not_after"
+)
+
+var checkNames = map[string]bool{
+	CheckRequired: true, CheckForbidden: true, CheckPattern: true, CheckEnum: true,
+	CheckMinLength: true, CheckMaxLength: true, CheckMinWords: true, CheckMaxWords: true,
+	CheckTimestamp: true, CheckNotBefore: true, CheckNotAfter: true,
+}
+
+// resourceFields lists the fields rules may name, in the order records are
+// checked
+var resourceFields = map[string][]string{
+	"users":    {"id", "email", "name", "role", "active", "created_at", "updated_at"},
+	"articles": {"id", "slug", "title", "body", "author_id", "tags", "status", "published_at", "created_at", "updated_at"},
+	"comments": {"id", "article_id", "user_id", "body", "created_at", "updated_at"},
+}
+
+const (
+	emailPattern = `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
+	slugPattern  = `^[a-z0-9]+(?:-[a-z0-9]+)*$`
+)
+
+// DefaultRuleSet returns the built-in rules. A rules file is applied on
+// top of them.
+func DefaultRuleSet() RuleSet {
+	return RuleSet{
+		"users": {
+			{Field: "id", Required: boolPtr(true)},
+			{Field: "email", Required: boolPtr(true), Pattern: stringPtr(emailPattern)},
+			{Field: "name", Required: boolPtr(true)},
+			{Field: "role", Required: boolPtr(true), Enum: sortedKeys(models.ValidRoles)},
+			{Field: "active", Enum: []string{"true", "false"},
+				Messages: map[string]string{CheckEnum: "active must be 'true' or 'false'"}},
+			{Field: "created_at", Required: boolPtr(true), Timestamp: boolPtr(true)},
+			importTimeFallback("updated_at"),
+		},
+		"articles": {
+			{Field: "id", Required: boolPtr(true)},
+			{Field: "slug", Required: boolPtr(true), Pattern: stringPtr(slugPattern),
+				Messages: map[string]string{CheckPattern: "slug must be kebab-case (lowercase letters, numbers, hyphens)"}},
+			{Field: "title", Required: boolPtr(true)},
+			{Field: "body", Required: boolPtr(true)},
+			{Field: "author_id", Required: boolPtr(true)},
+			{Field: "status", Enum: sortedKeys(models.ValidStatuses)},
+			{Field: "published_at", When: &Condition{Field: "status", Equals: stringPtr("draft")}, Forbidden: boolPtr(true),
+				Messages: map[string]string{CheckForbidden: "draft articles must not have published_at"}},
+			{Field: "published_at", Timestamp: boolPtr(true)},
+			importTimeFallback("created_at"),
+			importTimeFallback("updated_at"),
+		},
+		"comments": {
+			{Field: "id", Required: boolPtr(true)},
+			{Field: "article_id", Required: boolPtr(true)},
+			{Field: "user_id", Required: boolPtr(true)},
+			{Field: "body", Required: boolPtr(true), MaxWords: intPtr(models.MaxCommentWords)},
+			{Field: "created_at", Required: boolPtr(true), Timestamp: boolPtr(true)},
+			importTimeFallback("updated_at"),
+		},
+	}
+}
+
+// importTimeFallback is the rule of an optional timestamp that is replaced
+// by the import time when missing or unreadable: a bad value is a warning,
+// not a reason to reject the record
+func importTimeFallback(field string) FieldRule {
+	return FieldRule{Field: field, Timestamp: boolPtr(true), Severity: models.SeverityWarning,
+		Messages: map[string]string{CheckTimestamp: "invalid ISO 8601 date format, import time used instead"}}
+}
+
+// Merge applies the rules of other on top of s. A rule for the same field
+// and condition as an existing one overrides the checks it sets; any other
+// rule is added.
+func (s RuleSet) Merge(other RuleSet) RuleSet {
+	merged := make(RuleSet, len(s))
+	for resource, rules := range s {
+		merged[resource] = append([]FieldRule(nil), rules...)
+	}
+	for resource, rules := range other {
+		for _, rule := range rules {
+			merged[resource] = mergeRule(merged[resource], rule)
+		}
+	}
+	return merged
+}
+
+func mergeRule(rules []FieldRule, rule FieldRule) []FieldRule {
+	for i, existing := range rules {
+		if existing.Field != rule.Field || !reflect.DeepEqual(existing.When, rule.When) {
+			continue
+		}
+		if rule.Required != nil {
+			existing.Required = rule.Required
+		}
+		if rule.Forbidden != nil {
+			existing.Forbidden = rule.Forbidden
+		}
+		if rule.Pattern != nil {
+			existing.Pattern = rule.Pattern
+		}
+		if rule.Enum != nil {
+			existing.Enum = rule.Enum
+		}
+		if rule.MinLength != nil {
+			existing.MinLength = rule.MinLength
+		}
+		if rule.MaxLength != nil {
+			existing.MaxLength = rule.MaxLength
+		}
+		if rule.MinWords != nil {
+			existing.MinWords = rule.MinWords
+		}
+		if rule.MaxWords != nil {
+			existing.MaxWords = rule.MaxWords
+		}
+		if rule.Timestamp != nil {
+			existing.Timestamp = rule.Timestamp
+		}
+		if rule.NotBefore != nil {
+			existing.NotBefore = rule.NotBefore
+		}
+		if rule.NotAfter != nil {
+			existing.NotAfter = rule.NotAfter
+		}
+		if rule.Severity != "" {
+			existing.Severity = rule.Severity
+		}
+		if len(rule.Messages) > 0 {
+			messages := make(map[string]string, len(existing.Messages)+len(rule.Messages))
+			for check, message := range existing.Messages {
+				messages[check] = message
+			}
+			for check, message := range rule.Messages {
+				messages[check] = message
+			}
+			existing.Messages = messages
+		}
+		rules[i] = existing
+		return rules
+	}
+	return append(rules, rule)
+}
+
+// Rules is a compiled RuleSet, ready to check records. It is immutable
+// and safe for concurrent use.
+type Rules struct {
+	set   RuleSet
+	rules map[string]map[string][]compiledRule // by resource, then field
+}
+
+type compiledRule struct {
+	FieldRule
+	pattern   *regexp.Regexp
+	enum      map[string]bool
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+var defaultRules = mustCompileRules(DefaultRuleSet())
+
+// DefaultRules returns the compiled built-in rules
+func DefaultRules() *Rules {
+	return defaultRules
+}
+
+// CompileRules checks set and compiles it. Every resource, field, check
+// name, pattern and date must be valid.
+func CompileRules(set RuleSet) (*Rules, error) {
+	r := &Rules{set: set, rules: make(map[string]map[string][]compiledRule)}
+	for resource, rules := range set {
+		if _, ok := resourceFields[resource]; !ok {
+			return nil, fmt.Errorf("unknown resource %q", resource)
+		}
+		byField := make(map[string][]compiledRule)
+		for i, rule := range rules {
+			compiled, err := compileRule(resource, rule)
+			if err != nil {
+				return nil, fmt.Errorf("%s rule %d: %w", resource, i+1, err)
+			}
+			byField[rule.Field] = append(byField[rule.Field], compiled)
+		}
+		r.rules[resource] = byField
+	}
+	return r, nil
+}
+
+func mustCompileRules(set RuleSet) *Rules {
+	r, err := CompileRules(set)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func compileRule(resource string, rule FieldRule) (compiledRule, error) {
+	c := compiledRule{FieldRule: rule}
+	if !isField(resource, rule.Field) {
+		return c, fmt.Errorf("unknown field %q", rule.Field)
+	}
+	if rule.When != nil && !isField(resource, rule.When.Field) {
+		return c, fmt.Errorf("unknown condition field %q", rule.When.Field)
+	}
+	switch rule.Severity {
+	case "", models.SeverityError, models.SeverityWarning, models.SeverityInfo:
+	default:
+		return c, fmt.Errorf("field %s: severity must be error, warning or info", rule.Field)
+	}
+	for check := range rule.Messages {
+		if !checkNames[check] {
+			return c, fmt.Errorf("unknown check %q in messages", check)
+		}
+	}
+	if rule.Pattern != nil && *rule.Pattern != "" {
+		pattern, err := regexp.Compile(*rule.Pattern)
+		if err != nil {
+			return c, fmt.Errorf("field %s: invalid pattern: %w", rule.Field, err)
+		}
+		c.pattern = pattern
+	}
+	if len(rule.Enum) > 0 {
+		c.enum = make(map[string]bool, len(rule.Enum))
+		for _, value := range rule.Enum {
+			c.enum[value] = true
+		}
+	}
+	var err error
+	if rule.NotBefore != nil {
+		if c.notBefore, err = time.Parse(time.RFC3339, *rule.NotBefore); err != nil {
+			return c, fmt.Errorf("field %s: not_before must be an RFC3339 timestamp", rule.Field)
+		}
+	}
+	if rule.NotAfter != nil {
+		if c.notAfter, err = time.Parse(time.RFC3339, *rule.NotAfter); err != nil {
+			return c, fmt.Errorf("field %s: not_after must be an RFC3339 timestamp", rule.Field)
+		}
+	}
+	return c, nil
+}
+
+func isField(resource, field string) bool {
+	for _, f := range resourceFields[resource] {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadRules reads a YAML or JSON rules file and compiles it on top of the
+// default rules
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML, so one decoder reads both
+	var set RuleSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	rules, err := CompileRules(DefaultRuleSet().Merge(set))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// Set returns the rules in effect, defaults included
+func (r *Rules) Set() RuleSet {
+	return r.set
+}
+
+// Enum returns the allowed values of a resource's field, from its first
+// unconditional rule that lists them
+func (r *Rules) Enum(resource, field string) []string {
+	for _, rule := range r.rules[resource][field] {
+		if rule.When == nil && len(rule.Enum) > 0 {
+			return rule.Enum
+		}
+	}
+	return nil
+}
+
+// fieldCheck is a check of a field that rules cannot express, such as ID
+// formats, references and duplicates. It runs unless one of the field's
+// rules failed with an error, only when the field has a value.
+type fieldCheck func(field, value string) *ValidationError
+
+// check applies the rules of resource to a record's field values, field by
+// field, calling builtin for each field the rules accept. Timestamps are
+// read with times.
+func (r *Rules) check(resource string, values map[string]string, times *TimeParser, builtin fieldCheck) []ValidationError {
+	var errs []ValidationError
+	for _, field := range resourceFields[resource] {
+		failed := false
+		for _, rule := range r.rules[resource][field] {
+			if rule.When != nil && !rule.When.matches(values) {
+				continue
+			}
+			if err := rule.check(values[field], times); err != nil {
+				errs = append(errs, *err)
+				failed = failed || err.IsError()
+			}
+		}
+		if !failed && values[field] != "" && builtin != nil {
+			if err := builtin(field, values[field]); err != nil {
+				errs = append(errs, *err)
+			}
+		}
+	}
+	return errs
+}
+
+// matches reports whether the condition holds for a record's values
+func (c *Condition) matches(values map[string]string) bool {
+	value := values[c.Field]
+	if c.Present != nil && (value != "") != *c.Present {
+		return false
+	}
+	if c.Equals != nil && value != *c.Equals {
+		return false
+	}
+	if len(c.In) > 0 {
+		for _, v := range c.In {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// check returns the first check of the rule that value fails
+func (r *compiledRule) check(value string, times *TimeParser) *ValidationError {
+	field := r.Field
+	if value == "" {
+		if isTrue(r.Required) {
+			return r.fail(CheckRequired, fmt.Sprintf("%s is required", field), nil)
+		}
+		return nil
+	}
+	if isTrue(r.Forbidden) {
+		return r.fail(CheckForbidden, fmt.Sprintf("%s must be empty", field), nil)
+	}
+	if r.pattern != nil && !r.pattern.MatchString(value) {
+		return r.fail(CheckPattern, fmt.Sprintf("invalid %s format", field), value)
+	}
+	if r.enum != nil && !r.enum[value] {
+		return r.fail(CheckEnum, fmt.Sprintf("invalid %s, must be one of: %s", field, strings.Join(r.Enum, ", ")), value)
+	}
+	if length := utf8.RuneCountInString(value); r.MinLength != nil && length < *r.MinLength {
+		return r.fail(CheckMinLength, fmt.Sprintf("%s must be at least %d characters", field, *r.MinLength), value)
+	} else if r.MaxLength != nil && length > *r.MaxLength {
+		return r.fail(CheckMaxLength, fmt.Sprintf("%s must be at most %d characters", field, *r.MaxLength), value)
+	}
+	if r.MinWords != nil || r.MaxWords != nil {
+		words := len(strings.Fields(value))
+		if r.MinWords != nil && words < *r.MinWords {
+			return r.fail(CheckMinWords, fmt.Sprintf("%s must have at least %d words (has %d)", field, *r.MinWords, words), nil)
+		}
+		if r.MaxWords != nil && words > *r.MaxWords {
+			return r.fail(CheckMaxWords, fmt.Sprintf("%s exceeds maximum of %d words (has %d)", field, *r.MaxWords, words), nil)
+		}
+	}
+	if isTrue(r.Timestamp) || r.NotBefore != nil || r.NotAfter != nil {
+		t, err := times.Parse(value)
+		if err != nil {
+			return r.fail(CheckTimestamp, times.invalidMessage(), value)
+		}
+		if r.NotBefore != nil && t.Before(r.notBefore) {
+			return r.fail(CheckNotBefore, fmt.Sprintf("%s must not be before %s", field, *r.NotBefore), value)
+		}
+		if r.NotAfter != nil && t.After(r.notAfter) {
+			return r.fail(CheckNotAfter, fmt.Sprintf("%s must not be after %s", field, *r.NotAfter), value)
+		}
+	}
+	return nil
+}
+
+// fail builds the error of a failed check, with the rule's message for it
+// if it has one
+func (r *compiledRule) fail(check, message string, value interface{}) *ValidationError {
+	if custom, ok := r.Messages[check]; ok {
+		message = custom
+	}
+	return &ValidationError{Field: r.Field, Message: message, Value: value, Severity: r.Severity, Code: check}
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+func boolPtr(b bool) *bool       { return &b }
+func intPtr(n int) *int          { return &n }
+func stringPtr(s string) *string { return &s }
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RuleStore holds the rules in effect and reloads them from a rules file.
+// Until the file is loaded, and without one, it serves the default rules.
+type RuleStore struct {
+	path  string
+	rules atomic.Pointer[Rules]
+}
+
+// NewRuleStore creates a store for the rules file at path ("" for the
+// default rules only)
+func NewRuleStore(path string) *RuleStore {
+	s := &RuleStore{path: path}
+	s.rules.Store(DefaultRules())
+	return s
+}
+
+// Path returns the rules file of the store
+func (s *RuleStore) Path() string {
+	return s.path
+}
+
+// Rules returns the rules in effect
+func (s *RuleStore) Rules() *Rules {
+	return s.rules.Load()
+}
+
+// Reload reads the rules file again. On error the rules in effect are kept.
+// Validators already created keep the rules they were created with.
+func (s *RuleStore) Reload() (*Rules, error) {
+	if s.path == "" {
+		return s.Rules(), nil
+	}
+	rules, err := LoadRules(s.path)
+	if err != nil {
+		return nil, err
+	}
+	s.rules.Store(rules)
+	return rules, nil
+}
@@ -0,0 +1,191 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bulk-import-export-api/internal/models"
+)
+
+const testRulesYAML = `
+users:
+  - field: role
+    enum: [admin, editor, viewer, guest]
+  - field: created_at
+    not_before: "2020-01-01T00:00:00Z"
+articles:
+  - field: title
+    max_length: 10
+  - field: published_at
+    when: {field: status, equals: published}
+    required: true
+    messages:
+      required: published articles need published_at
+comments:
+  - field: body
+    max_words: 3
+    min_words: 2
+`
+
+func writeRules(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func fieldsOf(errs []ValidationError) string {
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field + ": " + e.Message
+	}
+	return strings.Join(fields, "; ")
+}
+
+func TestDefaultRules_MatchBuiltInChecks(t *testing.T) {
+	v := NewValidator()
+	errs := v.ValidateArticle(&models.ArticleNDJSON{
+		ID: "550e8400-e29b-41d4-a716-446655440000", Slug: "Not Kebab", Title: "T", Body: "B",
+		AuthorID: "550e8400-e29b-41d4-a716-446655440001", Status: "draft", PublishedAt: "yesterday",
+	}, 1)
+	want := "slug: slug must be kebab-case (lowercase letters, numbers, hyphens); " +
+		"published_at: draft articles must not have published_at; published_at: invalid ISO 8601 date format"
+	if got := fieldsOf(errs); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestLoadRules_OverridesAndAdds(t *testing.T) {
+	rules, err := LoadRules(writeRules(t, "rules.yaml", testRulesYAML))
+	if err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	v := NewValidator()
+	v.SetRules(rules)
+
+	user := &models.UserCSV{ID: "550e8400-e29b-41d4-a716-446655440000", Email: "g@example.com", Name: "G", Role: "guest", CreatedAt: "2024-01-01T00:00:00Z"}
+	if errs := v.ValidateUser(user, 1); len(errs) != 0 {
+		t.Errorf("Expected the overridden role enum to accept guest, got %s", fieldsOf(errs))
+	}
+	user.CreatedAt = "2019-12-31T23:59:59Z"
+	if errs := v.ValidateUser(user, 1); fieldsOf(errs) != "created_at: created_at must not be before 2020-01-01T00:00:00Z" {
+		t.Errorf("Expected the date range rule added to the default, got %s", fieldsOf(errs))
+	}
+
+	article := &models.ArticleNDJSON{ID: "550e8400-e29b-41d4-a716-446655440000", Slug: "s", Title: "Far too long a title", Body: "B",
+		AuthorID: "550e8400-e29b-41d4-a716-446655440001", Status: "published"}
+	want := "title: title must be at most 10 characters; published_at: published articles need published_at"
+	if got := fieldsOf(v.ValidateArticle(article, 1)); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	comment := &models.CommentNDJSON{ID: "550e8400-e29b-41d4-a716-446655440000", ArticleID: "550e8400-e29b-41d4-a716-446655440001",
+		UserID: "550e8400-e29b-41d4-a716-446655440002", Body: "one two three four", CreatedAt: "2024-01-01T00:00:00Z"}
+	if got := fieldsOf(v.ValidateComment(comment, 1)); got != "body: body exceeds maximum of 3 words (has 4)" {
+		t.Errorf("Expected the overridden word cap, got %q", got)
+	}
+	comment.Body = "one"
+	if got := fieldsOf(v.ValidateComment(comment, 1)); got != "body: body must have at least 2 words (has 1)" {
+		t.Errorf("Expected the added minimum, got %q", got)
+	}
+}
+
+func TestLoadRules_JSON(t *testing.T) {
+	rules, err := LoadRules(writeRules(t, "rules.json", `{"users": [{"field": "name", "min_length": 3}]}`))
+	if err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	v := NewValidator()
+	v.SetRules(rules)
+	user := &models.UserCSV{ID: "550e8400-e29b-41d4-a716-446655440000", Email: "a@example.com", Name: "Al", Role: "admin", CreatedAt: "2024-01-01T00:00:00Z"}
+	if got := fieldsOf(v.ValidateUser(user, 1)); got != "name: name must be at least 3 characters" {
+		t.Errorf("Expected the JSON rule applied, got %q", got)
+	}
+}
+
+func TestRules_SeverityAndCodes(t *testing.T) {
+	rules, err := LoadRules(writeRules(t, "rules.yaml", "users: [{field: name, min_length: 3, severity: warning}]"))
+	if err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	v := NewValidator()
+	v.SetRules(rules)
+
+	user := &models.UserCSV{ID: "550e8400-e29b-41d4-a716-446655440000", Email: "a@example.com", Name: "Al", Role: "admin",
+		CreatedAt: "2024-01-01T00:00:00Z", UpdatedAt: "last week"}
+	errs := v.ValidateUser(user, 1)
+	if len(errs) != 2 || HasErrors(errs) {
+		t.Fatalf("Expected two warnings and no errors, got %+v", errs)
+	}
+	if errs[0].Code != CheckMinLength || errs[0].Severity != models.SeverityWarning {
+		t.Errorf("Expected a min_length warning, got %+v", errs[0])
+	}
+	if errs[1].Field != "updated_at" || errs[1].Code != CheckTimestamp || errs[1].Message != "invalid ISO 8601 date format, import time used instead" {
+		t.Errorf("Expected the default updated_at warning, got %+v", errs[1])
+	}
+
+	user.ID = "not-a-uuid"
+	user.Role = "owner"
+	errs = v.ValidateUser(user, 1)
+	if !HasErrors(errs) {
+		t.Fatalf("Expected errors, got %+v", errs)
+	}
+	if errs[0].Code != CodeInvalidID || !errs[0].IsError() || errs[2].Code != CheckEnum {
+		t.Errorf("Expected invalid_id and enum errors among the warnings, got %+v", errs)
+	}
+}
+
+func TestLoadRules_RejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown resource", "widgets: [{field: id}]", `unknown resource "widgets"`},
+		{"unknown field", "users: [{field: nickname, required: true}]", `unknown field "nickname"`},
+		{"unknown condition field", "articles: [{field: body, when: {field: mood}}]", `unknown condition field "mood"`},
+		{"bad pattern", "users: [{field: name, pattern: '('}]", "invalid pattern"},
+		{"bad date", "users: [{field: created_at, not_after: tomorrow}]", "not_after must be an RFC3339 timestamp"},
+		{"unknown check", "users: [{field: name, messages: {shout: hey}}]", `unknown check "shout"`},
+		{"unknown severity", "users: [{field: name, severity: fatal}]", "severity must be error, warning or info"},
+		{"not yaml", "users: [", "parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadRules(writeRules(t, "rules.yaml", tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRuleStore_Reload(t *testing.T) {
+	path := writeRules(t, "rules.yaml", "comments: [{field: body, max_words: 3}]")
+	store := NewRuleStore(path)
+	if store.Rules() != DefaultRules() {
+		t.Error("Expected the default rules before the file is loaded")
+	}
+
+	loaded, err := store.Reload()
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if store.Rules() != loaded {
+		t.Error("Expected the loaded rules in effect")
+	}
+
+	if err := os.WriteFile(path, []byte("comments: [{field: bogus}]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Reload(); err == nil {
+		t.Error("Expected an invalid file to be rejected")
+	}
+	if store.Rules() != loaded {
+		t.Error("Expected the previous rules kept after a rejected reload")
+	}
+}
@@ -0,0 +1,112 @@
+package validation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezones must resolve on hosts without a zoneinfo database
+)
+
+// Named date layouts an import may accept besides RFC 3339. Any other
+// layout is a Go reference layout such as "02/01/2006 15:04".
+const (
+	LayoutRFC3339  = "rfc3339"  // 2024-01-05T10:00:00Z, always accepted
+	LayoutDateTime = "datetime" // 2024-01-05 10:00:00
+	LayoutDate     = "date"     // 2024-01-05
+	LayoutUnix     = "unix"     // seconds since the epoch
+	LayoutUnixMs   = "unix_ms"  // milliseconds since the epoch
+)
+
+var namedLayouts = map[string]string{
+	LayoutRFC3339:  time.RFC3339,
+	LayoutDateTime: time.DateTime,
+	LayoutDate:     time.DateOnly,
+}
+
+// TimeParser reads the timestamps of one import. RFC 3339 is always
+// accepted; further layouts are tried in order, and timestamps without an
+// offset are read in the parser's timezone. Validation and conversion
+// share one parser, so a value that passes validation converts to the
+// same instant.
+type TimeParser struct {
+	layouts []string // Go layouts, or LayoutUnix/LayoutUnixMs
+	names   []string
+	loc     *time.Location
+}
+
+var defaultTimeParser = &TimeParser{layouts: []string{time.RFC3339}, names: []string{LayoutRFC3339}, loc: time.UTC}
+
+// DefaultTimeParser returns the parser of imports without date options:
+// RFC 3339 only
+func DefaultTimeParser() *TimeParser {
+	return defaultTimeParser
+}
+
+// NewTimeParser creates a parser accepting RFC 3339 and layouts, which are
+// named layouts or Go reference layouts. timezone is an IANA name such as
+// "Europe/Berlin" for timestamps without an offset ("" for UTC).
+func NewTimeParser(layouts []string, timezone string) (*TimeParser, error) {
+	if len(layouts) == 0 && timezone == "" {
+		return defaultTimeParser, nil
+	}
+
+	p := &TimeParser{layouts: []string{time.RFC3339}, names: []string{LayoutRFC3339}, loc: time.UTC}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown timezone %q", timezone)
+		}
+		p.loc = loc
+	}
+	for _, layout := range layouts {
+		switch {
+		case layout == LayoutRFC3339:
+			continue
+		case layout == LayoutUnix || layout == LayoutUnixMs:
+			p.layouts = append(p.layouts, layout)
+		case namedLayouts[layout] != "":
+			p.layouts = append(p.layouts, namedLayouts[layout])
+		case strings.Contains(layout, "2006"):
+			p.layouts = append(p.layouts, layout)
+		default:
+			return nil, fmt.Errorf("unknown date layout %q: use %s, %s, %s, %s or a Go reference layout with the year 2006",
+				layout, LayoutDateTime, LayoutDate, LayoutUnix, LayoutUnixMs)
+		}
+		p.names = append(p.names, layout)
+	}
+	return p, nil
+}
+
+// Parse reads value with the first layout that fits it
+func (p *TimeParser) Parse(value string) (time.Time, error) {
+	for _, layout := range p.layouts {
+		var t time.Time
+		var err error
+		switch layout {
+		case LayoutUnix, LayoutUnixMs:
+			var n int64
+			if n, err = strconv.ParseInt(value, 10, 64); err == nil {
+				if layout == LayoutUnix {
+					t = time.Unix(n, 0).UTC()
+				} else {
+					t = time.UnixMilli(n).UTC()
+				}
+			}
+		default:
+			t, err = time.ParseInLocation(layout, value, p.loc)
+		}
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q does not match any of: %s", value, strings.Join(p.names, ", "))
+}
+
+// invalidMessage is the error message of a value Parse rejects
+func (p *TimeParser) invalidMessage() string {
+	if p == defaultTimeParser {
+		return "invalid ISO 8601 date format"
+	}
+	return "invalid date format, expected one of: " + strings.Join(p.names, ", ")
+}
@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bulk-import-export-api/internal/models"
+)
+
+func TestTimeParser_Layouts(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewTimeParser([]string{LayoutDateTime, LayoutDate, LayoutUnix, "02/01/2006 15:04"}, "Europe/Berlin")
+	if err != nil {
+		t.Fatalf("NewTimeParser failed: %v", err)
+	}
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-01-05T10:00:00Z", time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)},
+		{"2024-01-05T10:00:00+02:00", time.Date(2024, 1, 5, 8, 0, 0, 0, time.UTC)},
+		{"2024-01-05 10:00:00", time.Date(2024, 1, 5, 10, 0, 0, 0, berlin)},
+		{"2024-07-05", time.Date(2024, 7, 5, 0, 0, 0, 0, berlin)},
+		{"1704448800", time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)},
+		{"05/01/2024 10:00", time.Date(2024, 1, 5, 10, 0, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		got, err := p.Parse(tt.value)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v, got %v (%v)", tt.value, tt.want, got, err)
+		}
+	}
+
+	if _, err := p.Parse("yesterday"); err == nil || !strings.Contains(err.Error(), "rfc3339, datetime, date, unix, 02/01/2006 15:04") {
+		t.Errorf("Expected an error listing the layouts, got %v", err)
+	}
+}
+
+func TestTimeParser_DefaultIsRFC3339InUTC(t *testing.T) {
+	p, err := NewTimeParser(nil, "")
+	if err != nil || p != DefaultTimeParser() {
+		t.Fatalf("Expected the default parser without options, got %v, %v", p, err)
+	}
+	if _, err := p.Parse("2024-01-05 10:00:00"); err == nil {
+		t.Error("Expected a naive timestamp rejected by default")
+	}
+
+	ms, _ := NewTimeParser([]string{LayoutUnixMs}, "")
+	if got, err := ms.Parse("1704448800123"); err != nil || got.UnixMilli() != 1704448800123 {
+		t.Errorf("Expected epoch milliseconds parsed, got %v (%v)", got, err)
+	}
+}
+
+func TestNewTimeParser_RejectsInvalidOptions(t *testing.T) {
+	if _, err := NewTimeParser([]string{"dd/mm/yyyy"}, ""); err == nil || !strings.Contains(err.Error(), `unknown date layout "dd/mm/yyyy"`) {
+		t.Errorf("Expected an unknown layout error, got %v", err)
+	}
+	if _, err := NewTimeParser(nil, "Mars/Olympus"); err == nil || !strings.Contains(err.Error(), `unknown timezone "Mars/Olympus"`) {
+		t.Errorf("Expected an unknown timezone error, got %v", err)
+	}
+}
+
+func TestValidator_UsesTimeParser(t *testing.T) {
+	v := NewValidator()
+	user := &models.UserCSV{ID: "550e8400-e29b-41d4-a716-446655440000", Email: "a@example.com", Name: "Al", Role: "admin",
+		CreatedAt: "2024-01-05 10:00:00"}
+	if got := fieldsOf(v.ValidateUser(user, 1)); got != "created_at: invalid ISO 8601 date format" {
+		t.Errorf("Expected the naive timestamp rejected by default, got %q", got)
+	}
+
+	times, _ := NewTimeParser([]string{LayoutDateTime}, "")
+	v.SetTimeParser(times)
+	if errs := v.ValidateUser(user, 1); len(errs) != 0 {
+		t.Errorf("Expected the datetime layout accepted, got %s", fieldsOf(errs))
+	}
+	user.CreatedAt = "5 Jan 2024"
+	if got := fieldsOf(v.ValidateUser(user, 1)); got != "created_at: invalid date format, expected one of: rfc3339, datetime" {
+		t.Errorf("Expected the accepted layouts in the message, got %q", got)
+	}
+}
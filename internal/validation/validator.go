@@ -1,38 +1,100 @@
 package validation
 
 import (
-	"fmt"
-	"regexp"
+	"context"
 	"strings"
-	"time"
+	"unicode"
 
 	"github.com/bulk-import-export-api/internal/models"
 	"github.com/google/uuid"
 )
 
-var (
-	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	slugRegex  = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+// ValidationError represents a single issue found in a record
+type ValidationError struct {
+	Field    string      `json:"field"`
+	Message  string      `json:"message"`
+	Value    interface{} `json:"value,omitempty"`
+	Severity string      `json:"severity,omitempty"` // error when empty
+	Code     string      `json:"code,omitempty"`
+}
+
+// IsError reports whether the issue rejects its record
+func (e ValidationError) IsError() bool {
+	return e.Severity == "" || e.Severity == models.SeverityError
+}
+
+// HasErrors reports whether any of errs rejects its record. Warnings and
+// info do not.
+func HasErrors(errs []ValidationError) bool {
+	for _, e := range errs {
+		if e.IsError() {
+			return true
+		}
+	}
+	return false
+}
+
+// Codes of the issues found by built-in checks. Rule checks use their
+// check name (required, pattern, enum, ...) as code.
+const (
+	CodeInvalidID         = "invalid_id"
+	CodeDuplicate         = "duplicate"
+	CodeAlreadyExists     = "already_exists"
+	CodeMissingReference  = "missing_reference"
+	CodeUnknownExternalID = "unknown_external_id"
+	CodeMalformedRecord   = "malformed_record"
+	CodeInvalidValue      = "invalid_value"
+	CodeInsertFailed      = "insert_failed"
+	CodeCheckFailed       = "check_failed"
 )
 
-// ValidationError represents a single validation error
-type ValidationError struct {
-	Field   string      `json:"field"`
-	Message string      `json:"message"`
-	Value   interface{} `json:"value,omitempty"`
+// Retryable reports whether an issue may clear without the record
+// changing: its parent rows or external ID mapping may be imported later,
+// and a failed write or check may succeed on another attempt. A clash with
+// a stored row does not go away by itself.
+func Retryable(code string) bool {
+	switch code {
+	case CodeMissingReference, CodeUnknownExternalID, CodeInsertFailed, CodeCheckFailed:
+		return true
+	}
+	return false
 }
 
+// StoredLookup returns those of values already stored in the database
+type StoredLookup func(ctx context.Context, values []string) ([]string, error)
+
 // Validator provides validation methods
 type Validator struct {
+	// rules are the declarative field checks; ID formats, references and
+	// duplicates are checked in code
+	rules *Rules
+	// times reads timestamps, as the import's converters do
+	times *TimeParser
+
 	userEmailCache   map[string]bool
 	articleSlugCache map[string]bool
 	userIDCache      map[string]bool
 	articleIDCache   map[string]bool
+
+	// Lookups for uniqueness against stored rows, made once per batch
+	storedEmails StoredLookup
+	storedSlugs  StoredLookup
+
+	// Lookups for FK validation, made once per batch, used instead of the
+	// ID caches when there are too many parents to hold in memory
+	storedUserIDs    StoredLookup
+	storedArticleIDs StoredLookup
+
+	// externalIDs accepts foreign systems' identifiers in id and reference
+	// fields, for imports that map them onto UUIDs
+	externalIDs bool
 }
 
 // NewValidator creates a new validator instance
 func NewValidator() *Validator {
 	return &Validator{
+		rules:            DefaultRules(),
+		times:            DefaultTimeParser(),
 		userEmailCache:   make(map[string]bool),
 		articleSlugCache: make(map[string]bool),
 		userIDCache:      make(map[string]bool),
@@ -40,6 +102,16 @@ func NewValidator() *Validator {
 	}
 }
 
+// SetRules sets the declarative rules records are checked against
+func (v *Validator) SetRules(rules *Rules) {
+	v.rules = rules
+}
+
+// SetTimeParser sets how timestamps are read
+func (v *Validator) SetTimeParser(times *TimeParser) {
+	v.times = times
+}
+
 // SetUserIDCache sets the cache of existing user IDs for FK validation
 func (v *Validator) SetUserIDCache(ids []string) {
 	for _, id := range ids {
@@ -54,6 +126,35 @@ func (v *Validator) SetArticleIDCache(ids []string) {
 	}
 }
 
+// SetEmailLookup sets how ValidateUserBatch finds emails already stored
+func (v *Validator) SetEmailLookup(lookup StoredLookup) {
+	v.storedEmails = lookup
+}
+
+// SetSlugLookup sets how ValidateArticleBatch finds slugs already stored
+func (v *Validator) SetSlugLookup(lookup StoredLookup) {
+	v.storedSlugs = lookup
+}
+
+// SetUserIDLookup sets how batch validation finds referenced users that
+// are stored, for FK validation without a cache
+func (v *Validator) SetUserIDLookup(lookup StoredLookup) {
+	v.storedUserIDs = lookup
+}
+
+// SetArticleIDLookup sets how batch validation finds referenced articles
+// that are stored, for FK validation without a cache
+func (v *Validator) SetArticleIDLookup(lookup StoredLookup) {
+	v.storedArticleIDs = lookup
+}
+
+// AllowExternalIDs accepts identifiers from a foreign system, not only
+// UUIDs, in id and reference fields. References are then checked by batch
+// validation, once the import has mapped them onto UUIDs.
+func (v *Validator) AllowExternalIDs() {
+	v.externalIDs = true
+}
+
 // AddUserEmail adds an email to the uniqueness cache
 func (v *Validator) AddUserEmail(email string) {
 	v.userEmailCache[strings.ToLower(email)] = true
@@ -74,182 +175,296 @@ func (v *Validator) AddArticleID(id string) {
 	v.articleIDCache[id] = true
 }
 
-// ValidateUser validates a user record
-func (v *Validator) ValidateUser(user *models.UserCSV, lineNum int) []ValidationError {
-	var errors []ValidationError
-
-	// Validate ID
-	if user.ID == "" {
-		errors = append(errors, ValidationError{Field: "id", Message: "id is required"})
-	} else if !isValidUUID(user.ID) {
-		errors = append(errors, ValidationError{Field: "id", Message: "invalid UUID format", Value: user.ID})
-	}
-
-	// Validate email
-	if user.Email == "" {
-		errors = append(errors, ValidationError{Field: "email", Message: "email is required"})
-	} else if !emailRegex.MatchString(user.Email) {
-		errors = append(errors, ValidationError{Field: "email", Message: "invalid email format", Value: user.Email})
-	} else {
-		// Check for duplicate email in current batch
-		emailLower := strings.ToLower(user.Email)
-		if v.userEmailCache[emailLower] {
-			errors = append(errors, ValidationError{Field: "email", Message: "duplicate email", Value: user.Email})
-		}
-	}
+// RemoveUserEmail takes back an email whose row was not stored
+func (v *Validator) RemoveUserEmail(email string) {
+	delete(v.userEmailCache, strings.ToLower(email))
+}
 
-	// Validate name
-	if user.Name == "" {
-		errors = append(errors, ValidationError{Field: "name", Message: "name is required"})
-	}
+// RemoveArticleSlug takes back a slug whose row was not stored
+func (v *Validator) RemoveArticleSlug(slug string) {
+	delete(v.articleSlugCache, slug)
+}
 
-	// Validate role
-	if user.Role == "" {
-		errors = append(errors, ValidationError{Field: "role", Message: "role is required"})
-	} else if !models.ValidRoles[user.Role] {
-		errors = append(errors, ValidationError{
-			Field:   "role",
-			Message: fmt.Sprintf("invalid role, must be one of: admin, editor, viewer"),
-			Value:   user.Role,
-		})
-	}
+// RemoveUserID takes back a user ID whose row was not stored
+func (v *Validator) RemoveUserID(id string) {
+	delete(v.userIDCache, id)
+}
 
-	// Validate active (boolean)
-	if user.Active != "" && user.Active != "true" && user.Active != "false" {
-		errors = append(errors, ValidationError{Field: "active", Message: "active must be 'true' or 'false'", Value: user.Active})
-	}
+// RemoveArticleID takes back an article ID whose row was not stored
+func (v *Validator) RemoveArticleID(id string) {
+	delete(v.articleIDCache, id)
+}
 
-	// Validate created_at
-	if user.CreatedAt == "" {
-		errors = append(errors, ValidationError{Field: "created_at", Message: "created_at is required"})
-	} else if _, err := time.Parse(time.RFC3339, user.CreatedAt); err != nil {
-		errors = append(errors, ValidationError{Field: "created_at", Message: "invalid ISO 8601 date format", Value: user.CreatedAt})
+// ValidateUser validates a user record
+func (v *Validator) ValidateUser(user *models.UserCSV, lineNum int) []ValidationError {
+	values := map[string]string{
+		"id":         user.ID,
+		"email":      user.Email,
+		"name":       user.Name,
+		"role":       user.Role,
+		"active":     user.Active,
+		"created_at": user.CreatedAt,
+		"updated_at": user.UpdatedAt,
 	}
+	return v.rules.check("users", values, v.times, func(field, value string) *ValidationError {
+		switch field {
+		case "id":
+			if !v.validID(value) {
+				return &ValidationError{Field: "id", Message: "invalid UUID format", Value: value, Code: CodeInvalidID}
+			}
+		case "email":
+			// Check for duplicate email in current batch
+			if v.userEmailCache[strings.ToLower(value)] {
+				return &ValidationError{Field: "email", Message: "duplicate email", Value: value, Code: CodeDuplicate}
+			}
+		}
+		return nil
+	})
+}
 
-	return errors
+// ValidateUserBatch checks users that passed ValidateUser against the
+// database, with one lookup for the whole batch. Errors are keyed by index
+// in users.
+func (v *Validator) ValidateUserBatch(ctx context.Context, users []*models.User) (map[int][]ValidationError, error) {
+	if v.storedEmails == nil {
+		return nil, nil
+	}
+	emails := make([]string, len(users))
+	for i, user := range users {
+		emails[i] = user.Email
+	}
+	return storedConflicts(ctx, v.storedEmails, emails, "email", "email already exists")
 }
 
 // ValidateArticle validates an article record
 func (v *Validator) ValidateArticle(article *models.ArticleNDJSON, lineNum int) []ValidationError {
-	var errors []ValidationError
-
-	// Validate ID
-	if article.ID == "" {
-		errors = append(errors, ValidationError{Field: "id", Message: "id is required"})
-	} else if !isValidUUID(article.ID) {
-		errors = append(errors, ValidationError{Field: "id", Message: "invalid UUID format", Value: article.ID})
-	}
-
-	// Validate slug
-	if article.Slug == "" {
-		errors = append(errors, ValidationError{Field: "slug", Message: "slug is required"})
-	} else if !slugRegex.MatchString(article.Slug) {
-		errors = append(errors, ValidationError{Field: "slug", Message: "slug must be kebab-case (lowercase letters, numbers, hyphens)", Value: article.Slug})
-	} else {
-		// Check for duplicate slug in current batch
-		if v.articleSlugCache[article.Slug] {
-			errors = append(errors, ValidationError{Field: "slug", Message: "duplicate slug", Value: article.Slug})
-		}
+	values := map[string]string{
+		"id":           article.ID,
+		"slug":         article.Slug,
+		"title":        article.Title,
+		"body":         article.Body,
+		"author_id":    article.AuthorID,
+		"tags":         strings.Join(article.Tags, ","),
+		"status":       article.Status,
+		"published_at": article.PublishedAt,
+		"created_at":   article.CreatedAt,
+		"updated_at":   article.UpdatedAt,
 	}
+	return v.rules.check("articles", values, v.times, func(field, value string) *ValidationError {
+		switch field {
+		case "id":
+			if !v.validID(value) {
+				return &ValidationError{Field: "id", Message: "invalid UUID format", Value: value, Code: CodeInvalidID}
+			}
+		case "slug":
+			// Check for duplicate slug in current batch
+			if v.articleSlugCache[value] {
+				return &ValidationError{Field: "slug", Message: "duplicate slug", Value: value, Code: CodeDuplicate}
+			}
+		case "author_id":
+			return v.checkReference(field, value, v.userIDCache, "referenced user does not exist")
+		}
+		return nil
+	})
+}
 
-	// Validate title
-	if article.Title == "" {
-		errors = append(errors, ValidationError{Field: "title", Message: "title is required"})
+// ValidateArticleBatch checks articles that passed ValidateArticle against
+// the database, with one lookup per check for the whole batch. Errors are
+// keyed by index in articles.
+func (v *Validator) ValidateArticleBatch(ctx context.Context, articles []*models.Article) (map[int][]ValidationError, error) {
+	slugs := make([]string, len(articles))
+	authorIDs := make([]string, len(articles))
+	for i, article := range articles {
+		slugs[i] = article.Slug
+		authorIDs[i] = article.AuthorID
 	}
 
-	// Validate body
-	if article.Body == "" {
-		errors = append(errors, ValidationError{Field: "body", Message: "body is required"})
+	errs := make(map[int][]ValidationError)
+	if v.storedSlugs != nil {
+		conflicts, err := storedConflicts(ctx, v.storedSlugs, slugs, "slug", "slug already exists")
+		if err != nil {
+			return nil, err
+		}
+		mergeErrors(errs, conflicts)
 	}
+	if v.storedUserIDs != nil {
+		missing, err := missingReferences(ctx, v.storedUserIDs, authorIDs, "author_id", "referenced user does not exist")
+		if err != nil {
+			return nil, err
+		}
+		mergeErrors(errs, missing)
+	} else if len(v.userIDCache) > 0 {
+		mergeErrors(errs, missingFromCache(v.userIDCache, authorIDs, "author_id", "referenced user does not exist"))
+	}
+	return errs, nil
+}
 
-	// Validate author_id (FK)
-	if article.AuthorID == "" {
-		errors = append(errors, ValidationError{Field: "author_id", Message: "author_id is required"})
-	} else if !isValidUUID(article.AuthorID) {
-		errors = append(errors, ValidationError{Field: "author_id", Message: "invalid UUID format", Value: article.AuthorID})
-	} else if len(v.userIDCache) > 0 && !v.userIDCache[article.AuthorID] {
-		errors = append(errors, ValidationError{Field: "author_id", Message: "referenced user does not exist", Value: article.AuthorID})
+// ValidateCommentBatch checks the references of comments that passed
+// ValidateComment against the database, with one lookup per referenced
+// resource for the whole batch. Errors are keyed by index in comments.
+func (v *Validator) ValidateCommentBatch(ctx context.Context, comments []*models.Comment) (map[int][]ValidationError, error) {
+	articleIDs := make([]string, len(comments))
+	userIDs := make([]string, len(comments))
+	for i, comment := range comments {
+		articleIDs[i] = comment.ArticleID
+		userIDs[i] = comment.UserID
 	}
 
-	// Validate status
-	if article.Status != "" && !models.ValidStatuses[article.Status] {
-		errors = append(errors, ValidationError{
-			Field:   "status",
-			Message: "invalid status, must be one of: draft, published",
-			Value:   article.Status,
-		})
+	errs := make(map[int][]ValidationError)
+	if v.storedArticleIDs != nil {
+		missing, err := missingReferences(ctx, v.storedArticleIDs, articleIDs, "article_id", "referenced article does not exist")
+		if err != nil {
+			return nil, err
+		}
+		mergeErrors(errs, missing)
+	} else if len(v.articleIDCache) > 0 {
+		mergeErrors(errs, missingFromCache(v.articleIDCache, articleIDs, "article_id", "referenced article does not exist"))
+	}
+	if v.storedUserIDs != nil {
+		missing, err := missingReferences(ctx, v.storedUserIDs, userIDs, "user_id", "referenced user does not exist")
+		if err != nil {
+			return nil, err
+		}
+		mergeErrors(errs, missing)
+	} else if len(v.userIDCache) > 0 {
+		mergeErrors(errs, missingFromCache(v.userIDCache, userIDs, "user_id", "referenced user does not exist"))
 	}
+	return errs, nil
+}
 
-	// Validate draft must not have published_at
-	if article.Status == "draft" && article.PublishedAt != "" {
-		errors = append(errors, ValidationError{Field: "published_at", Message: "draft articles must not have published_at"})
+// storedConflicts reports an error for every value that lookup finds stored
+func storedConflicts(ctx context.Context, lookup StoredLookup, values []string, field, message string) (map[int][]ValidationError, error) {
+	stored, err := lookup(ctx, values)
+	if err != nil || len(stored) == 0 {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(stored))
+	for _, value := range stored {
+		exists[value] = true
 	}
 
-	// Validate published_at format if present
-	if article.PublishedAt != "" {
-		if _, err := time.Parse(time.RFC3339, article.PublishedAt); err != nil {
-			errors = append(errors, ValidationError{Field: "published_at", Message: "invalid ISO 8601 date format", Value: article.PublishedAt})
+	conflicts := make(map[int][]ValidationError)
+	for i, value := range values {
+		if exists[value] {
+			conflicts[i] = []ValidationError{{Field: field, Message: message, Value: value, Code: CodeAlreadyExists}}
 		}
 	}
-
-	return errors
+	return conflicts, nil
 }
 
 // ValidateComment validates a comment record
 func (v *Validator) ValidateComment(comment *models.CommentNDJSON, lineNum int) []ValidationError {
-	var errors []ValidationError
-
-	// Validate ID
-	if comment.ID == "" {
-		errors = append(errors, ValidationError{Field: "id", Message: "id is required"})
-	} else if !isValidUUID(comment.ID) && !strings.HasPrefix(comment.ID, "cm_") {
-		errors = append(errors, ValidationError{Field: "id", Message: "invalid ID format", Value: comment.ID})
-	}
-
-	// Validate article_id (FK)
-	if comment.ArticleID == "" {
-		errors = append(errors, ValidationError{Field: "article_id", Message: "article_id is required"})
-	} else if !isValidUUID(comment.ArticleID) {
-		errors = append(errors, ValidationError{Field: "article_id", Message: "invalid UUID format", Value: comment.ArticleID})
-	} else if len(v.articleIDCache) > 0 && !v.articleIDCache[comment.ArticleID] {
-		errors = append(errors, ValidationError{Field: "article_id", Message: "referenced article does not exist", Value: comment.ArticleID})
-	}
-
-	// Validate user_id (FK)
-	if comment.UserID == "" {
-		errors = append(errors, ValidationError{Field: "user_id", Message: "user_id is required"})
-	} else if !isValidUUID(comment.UserID) {
-		errors = append(errors, ValidationError{Field: "user_id", Message: "invalid UUID format", Value: comment.UserID})
-	} else if len(v.userIDCache) > 0 && !v.userIDCache[comment.UserID] {
-		errors = append(errors, ValidationError{Field: "user_id", Message: "referenced user does not exist", Value: comment.UserID})
-	}
-
-	// Validate body
-	if comment.Body == "" {
-		errors = append(errors, ValidationError{Field: "body", Message: "body is required"})
-	} else {
-		// Check word count (max 500 words)
-		wordCount := len(strings.Fields(comment.Body))
-		if wordCount > models.MaxCommentWords {
-			errors = append(errors, ValidationError{
-				Field:   "body",
-				Message: fmt.Sprintf("body exceeds maximum of %d words (has %d)", models.MaxCommentWords, wordCount),
-			})
-		}
+	values := map[string]string{
+		"id":         comment.ID,
+		"article_id": comment.ArticleID,
+		"user_id":    comment.UserID,
+		"body":       comment.Body,
+		"created_at": comment.CreatedAt,
+		"updated_at": comment.UpdatedAt,
 	}
+	return v.rules.check("comments", values, v.times, func(field, value string) *ValidationError {
+		switch field {
+		case "id":
+			if !v.validID(value) {
+				return &ValidationError{Field: "id", Message: "invalid UUID format", Value: value, Code: CodeInvalidID}
+			}
+		case "article_id":
+			return v.checkReference(field, value, v.articleIDCache, "referenced article does not exist")
+		case "user_id":
+			return v.checkReference(field, value, v.userIDCache, "referenced user does not exist")
+		}
+		return nil
+	})
+}
 
-	// Validate created_at
-	if comment.CreatedAt == "" {
-		errors = append(errors, ValidationError{Field: "created_at", Message: "created_at is required"})
-	} else if _, err := time.Parse(time.RFC3339, comment.CreatedAt); err != nil {
-		errors = append(errors, ValidationError{Field: "created_at", Message: "invalid ISO 8601 date format", Value: comment.CreatedAt})
+// checkReference checks the format of a reference and, for a UUID, that
+// the ID cache holds it. External IDs are checked once mapped, by batch
+// validation.
+func (v *Validator) checkReference(field, value string, cache map[string]bool, message string) *ValidationError {
+	if !v.validID(value) {
+		return &ValidationError{Field: field, Message: "invalid UUID format", Value: value, Code: CodeInvalidID}
+	}
+	if isValidUUID(value) && len(cache) > 0 && !cache[value] {
+		return &ValidationError{Field: field, Message: message, Value: value, Code: CodeMissingReference}
 	}
+	return nil
+}
+
+// validID reports whether s may identify a row: a UUID or, when allowed,
+// an external ID
+func (v *Validator) validID(s string) bool {
+	return isValidUUID(s) || (v.externalIDs && isValidExternalID(s))
+}
 
-	return errors
+// isValidExternalID checks that a foreign system's identifier fits the
+// mapping table: at most 255 bytes, without whitespace or control characters
+func isValidExternalID(s string) bool {
+	if len(s) > maxExternalIDLength {
+		return false
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
 }
 
+// maxExternalIDLength is the size of external_ids.external_id
+const maxExternalIDLength = 255
+
 // isValidUUID checks if a string is a valid UUID
 func isValidUUID(s string) bool {
 	_, err := uuid.Parse(s)
 	return err == nil
 }
+
+// missingReferences reports an error for every ID that lookup does not find
+// stored. Each distinct ID is looked up once; UUIDs compare case-insensitively.
+func missingReferences(ctx context.Context, lookup StoredLookup, ids []string, field, message string) (map[int][]ValidationError, error) {
+	seen := make(map[string]bool, len(ids))
+	var distinct []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			distinct = append(distinct, id)
+		}
+	}
+
+	stored, err := lookup(ctx, distinct)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(stored))
+	for _, id := range stored {
+		exists[strings.ToLower(id)] = true
+	}
+
+	missing := make(map[int][]ValidationError)
+	for i, id := range ids {
+		if !exists[strings.ToLower(id)] {
+			missing[i] = []ValidationError{{Field: field, Message: message, Value: id, Code: CodeMissingReference}}
+		}
+	}
+	return missing, nil
+}
+
+// missingFromCache reports an error for every ID not in an ID cache. Rows
+// already passed the same check unless their reference was mapped from an
+// external ID after row validation.
+func missingFromCache(cache map[string]bool, ids []string, field, message string) map[int][]ValidationError {
+	missing := make(map[int][]ValidationError)
+	for i, id := range ids {
+		if !cache[id] {
+			missing[i] = []ValidationError{{Field: field, Message: message, Value: id, Code: CodeMissingReference}}
+		}
+	}
+	return missing
+}
+
+// mergeErrors appends the errors in src to dst by index
+func mergeErrors(dst, src map[int][]ValidationError) {
+	for i, errs := range src {
+		dst[i] = append(dst[i], errs...)
+	}
+}
@@ -1,6 +1,8 @@
 package validation
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
 
@@ -257,7 +259,7 @@ func TestValidateComment(t *testing.T) {
 		{
 			name: "valid comment",
 			comment: &models.CommentNDJSON{
-				ID:        "cm_550e8400-e29b-41d4-a716-446655440000",
+				ID:        "990e8400-e29b-41d4-a716-446655440000",
 				ArticleID: "550e8400-e29b-41d4-a716-446655440001",
 				UserID:    "550e8400-e29b-41d4-a716-446655440002",
 				Body:      "This is a valid comment",
@@ -268,7 +270,7 @@ func TestValidateComment(t *testing.T) {
 		{
 			name: "missing body",
 			comment: &models.CommentNDJSON{
-				ID:        "cm_550e8400-e29b-41d4-a716-446655440000",
+				ID:        "990e8400-e29b-41d4-a716-446655440000",
 				ArticleID: "550e8400-e29b-41d4-a716-446655440001",
 				UserID:    "550e8400-e29b-41d4-a716-446655440002",
 				Body:      "",
@@ -280,7 +282,7 @@ func TestValidateComment(t *testing.T) {
 		{
 			name: "invalid article_id format",
 			comment: &models.CommentNDJSON{
-				ID:        "cm_550e8400-e29b-41d4-a716-446655440000",
+				ID:        "990e8400-e29b-41d4-a716-446655440000",
 				ArticleID: "not-a-uuid",
 				UserID:    "550e8400-e29b-41d4-a716-446655440002",
 				Body:      "This is a comment",
@@ -290,11 +292,23 @@ func TestValidateComment(t *testing.T) {
 			wantFields: []string{"article_id"},
 		},
 		{
-			name: "body exceeds word limit",
+			name: "prefixed id without a source",
 			comment: &models.CommentNDJSON{
 				ID:        "cm_550e8400-e29b-41d4-a716-446655440000",
 				ArticleID: "550e8400-e29b-41d4-a716-446655440001",
 				UserID:    "550e8400-e29b-41d4-a716-446655440002",
+				Body:      "This is a comment",
+				CreatedAt: "2024-01-01T00:00:00Z",
+			},
+			wantErrors: 1,
+			wantFields: []string{"id"},
+		},
+		{
+			name: "body exceeds word limit",
+			comment: &models.CommentNDJSON{
+				ID:        "990e8400-e29b-41d4-a716-446655440000",
+				ArticleID: "550e8400-e29b-41d4-a716-446655440001",
+				UserID:    "550e8400-e29b-41d4-a716-446655440002",
 				Body:      strings.Repeat("word ", 600), // 600 words
 				CreatedAt: "2024-01-01T00:00:00Z",
 			},
@@ -408,6 +422,125 @@ func TestDuplicateSlugDetection(t *testing.T) {
 	}
 }
 
+// storedSet returns a lookup over a fixed set of stored values that counts
+// its calls
+func storedSet(calls *int, stored ...string) StoredLookup {
+	return func(ctx context.Context, values []string) ([]string, error) {
+		*calls++
+		var found []string
+		for _, value := range values {
+			for _, s := range stored {
+				if value == s {
+					found = append(found, value)
+				}
+			}
+		}
+		return found, nil
+	}
+}
+
+func TestValidateUserBatch_StoredEmails(t *testing.T) {
+	validator := NewValidator()
+
+	// Without a lookup nothing is checked
+	conflicts, err := validator.ValidateUserBatch(context.Background(), []*models.User{{Email: "taken@example.com"}})
+	if err != nil || conflicts != nil {
+		t.Errorf("Expected no check without a lookup, got %v, %v", conflicts, err)
+	}
+
+	calls := 0
+	validator.SetEmailLookup(storedSet(&calls, "taken@example.com"))
+	users := []*models.User{
+		{Email: "new@example.com"},
+		{Email: "taken@example.com"},
+		{Email: "other@example.com"},
+	}
+
+	conflicts, err = validator.ValidateUserBatch(context.Background(), users)
+	if err != nil {
+		t.Fatalf("ValidateUserBatch failed: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected one lookup per batch, got %d", calls)
+	}
+	if len(conflicts) != 1 || len(conflicts[1]) != 1 {
+		t.Fatalf("Expected one conflict at index 1, got %v", conflicts)
+	}
+	if e := conflicts[1][0]; e.Field != "email" || e.Message != "email already exists" || e.Value != "taken@example.com" {
+		t.Errorf("Expected 'email already exists' error, got %+v", e)
+	}
+}
+
+func TestValidateArticleBatch_StoredSlugs(t *testing.T) {
+	validator := NewValidator()
+	calls := 0
+	validator.SetSlugLookup(storedSet(&calls, "hello-world"))
+
+	conflicts, err := validator.ValidateArticleBatch(context.Background(), []*models.Article{{Slug: "hello-world"}, {Slug: "fresh"}})
+	if err != nil {
+		t.Fatalf("ValidateArticleBatch failed: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0][0].Message != "slug already exists" {
+		t.Errorf("Expected 'slug already exists' at index 0, got %v", conflicts)
+	}
+
+	validator.SetSlugLookup(func(ctx context.Context, values []string) ([]string, error) {
+		return nil, errors.New("connection refused")
+	})
+	if _, err := validator.ValidateArticleBatch(context.Background(), []*models.Article{{Slug: "fresh"}}); err == nil {
+		t.Error("Expected lookup error to be returned")
+	}
+}
+
+func TestValidateCommentBatch_ReferenceLookups(t *testing.T) {
+	validator := NewValidator()
+	userCalls, articleCalls := 0, 0
+	validator.SetUserIDLookup(storedSet(&userCalls, "550e8400-e29b-41d4-a716-446655440001"))
+	validator.SetArticleIDLookup(storedSet(&articleCalls, "550e8400-e29b-41d4-a716-446655440010"))
+
+	comments := []*models.Comment{
+		{ArticleID: "550e8400-e29b-41d4-a716-446655440010", UserID: "550e8400-e29b-41d4-a716-446655440001"},
+		{ArticleID: "550e8400-e29b-41d4-a716-446655440099", UserID: "550e8400-e29b-41d4-a716-446655440001"},
+		{ArticleID: "550e8400-e29b-41d4-a716-446655440099", UserID: "550e8400-e29b-41d4-a716-446655440098"},
+	}
+	errs, err := validator.ValidateCommentBatch(context.Background(), comments)
+	if err != nil {
+		t.Fatalf("ValidateCommentBatch failed: %v", err)
+	}
+	if userCalls != 1 || articleCalls != 1 {
+		t.Errorf("Expected one lookup per referenced resource, got %d user and %d article", userCalls, articleCalls)
+	}
+	if len(errs[0]) != 0 {
+		t.Errorf("Expected comment 0 valid, got %v", errs[0])
+	}
+	if len(errs[1]) != 1 || errs[1][0].Field != "article_id" {
+		t.Errorf("Expected missing article on comment 1, got %v", errs[1])
+	}
+	if len(errs[2]) != 2 || errs[2][0].Field != "article_id" || errs[2][1].Field != "user_id" {
+		t.Errorf("Expected missing article and user on comment 2, got %v", errs[2])
+	}
+}
+
+func TestMissingReferences_CaseInsensitiveAndDistinct(t *testing.T) {
+	var looked []string
+	lookup := func(ctx context.Context, ids []string) ([]string, error) {
+		looked = ids
+		return []string{"550e8400-e29b-41d4-a716-44665544000a"}, nil
+	}
+
+	ids := []string{"550E8400-E29B-41D4-A716-44665544000A", "550E8400-E29B-41D4-A716-44665544000A"}
+	missing, err := missingReferences(context.Background(), lookup, ids, "author_id", "referenced user does not exist")
+	if err != nil {
+		t.Fatalf("missingReferences failed: %v", err)
+	}
+	if len(looked) != 1 {
+		t.Errorf("Expected each distinct ID looked up once, got %v", looked)
+	}
+	if len(missing) != 0 {
+		t.Errorf("Expected upper-case UUID to match the stored one, got %v", missing)
+	}
+}
+
 func TestValidationErrorMessages(t *testing.T) {
 	validator := NewValidator()
 
@@ -436,6 +569,31 @@ func TestValidationErrorMessages(t *testing.T) {
 	}
 }
 
+// An invalid id reads the same for every resource, so error summaries
+// group it as one failure
+func TestInvalidIDMessageSharedAcrossResources(t *testing.T) {
+	validator := NewValidator()
+	errs := [][]ValidationError{
+		validator.ValidateUser(&models.UserCSV{ID: "bad"}, 1),
+		validator.ValidateArticle(&models.ArticleNDJSON{ID: "bad"}, 1),
+		validator.ValidateComment(&models.CommentNDJSON{ID: "bad"}, 1),
+	}
+	for i, resourceErrs := range errs {
+		found := false
+		for _, err := range resourceErrs {
+			if err.Field == "id" {
+				found = true
+				if err.Code != CodeInvalidID || err.Message != "invalid UUID format" {
+					t.Errorf("Resource %d: expected invalid_id \"invalid UUID format\", got %s %q", i, err.Code, err.Message)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Resource %d: expected an id error, got %+v", i, resourceErrs)
+		}
+	}
+}
+
 func TestKebabCaseValidation(t *testing.T) {
 	validator := NewValidator()
 
@@ -491,7 +649,7 @@ func TestCommentBodyWordBoundary(t *testing.T) {
 	// Exactly 500 words - should pass
 	words500 := strings.Repeat("word ", 500)
 	comment500 := &models.CommentNDJSON{
-		ID:        "cm_550e8400-e29b-41d4-a716-446655440000",
+		ID:        "990e8400-e29b-41d4-a716-446655440000",
 		ArticleID: "550e8400-e29b-41d4-a716-446655440001",
 		UserID:    "550e8400-e29b-41d4-a716-446655440002",
 		Body:      strings.TrimSpace(words500),
@@ -507,7 +665,7 @@ func TestCommentBodyWordBoundary(t *testing.T) {
 	// 501 words - should fail
 	words501 := strings.Repeat("word ", 501)
 	comment501 := &models.CommentNDJSON{
-		ID:        "cm_550e8400-e29b-41d4-a716-446655440003",
+		ID:        "990e8400-e29b-41d4-a716-446655440003",
 		ArticleID: "550e8400-e29b-41d4-a716-446655440001",
 		UserID:    "550e8400-e29b-41d4-a716-446655440002",
 		Body:      strings.TrimSpace(words501),
@@ -611,7 +769,7 @@ func TestValidateArticle_ForeignKeyValidation(t *testing.T) {
 func BenchmarkValidateComment(b *testing.B) {
 	validator := NewValidator()
 	comment := &models.CommentNDJSON{
-		ID:        "cm_550e8400-e29b-41d4-a716-446655440000",
+		ID:        "990e8400-e29b-41d4-a716-446655440000",
 		ArticleID: "550e8400-e29b-41d4-a716-446655440001",
 		UserID:    "550e8400-e29b-41d4-a716-446655440002",
 		Body:      "This is a benchmark test comment body",
@@ -658,3 +816,33 @@ func BenchmarkValidateArticle(b *testing.B) {
 		validator.ValidateArticle(article, i)
 	}
 }
+
+func TestAllowExternalIDs(t *testing.T) {
+	user := func(id string) *models.UserCSV {
+		return &models.UserCSV{ID: id, Email: "n@example.com", Name: "N", Role: "viewer", CreatedAt: "2024-01-01T00:00:00Z"}
+	}
+
+	strict := NewValidator()
+	if errs := strict.ValidateUser(user("crm-1"), 1); len(errs) != 1 || errs[0].Field != "id" {
+		t.Errorf("Expected non-UUID id rejected by default, got %+v", errs)
+	}
+
+	v := NewValidator()
+	v.AllowExternalIDs()
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"crm-1", true},
+		{"550e8400-e29b-41d4-a716-446655440000", true},
+		{"has space", false},
+		{"tab\there", false},
+		{strings.Repeat("x", 256), false},
+	}
+	for _, tt := range tests {
+		errs := v.ValidateUser(user(tt.id), 1)
+		if (len(errs) == 0) != tt.valid {
+			t.Errorf("ValidateUser(id=%.20q) errors = %+v, want valid=%v", tt.id, errs, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+-- Per-job import options (e.g. XLSX sheet name), stored as JSON so new
+-- options do not require a schema change
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS options JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_jobs_pending;
+CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs(created_at) WHERE status = 'pending';
+
+DELETE FROM jobs WHERE resource = 'bundle';
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_resource_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_resource_check
+    CHECK (resource IN ('users', 'articles', 'comments'));
+
+DROP INDEX IF EXISTS idx_jobs_parent_job_id;
+ALTER TABLE jobs DROP COLUMN IF EXISTS parent_job_id;
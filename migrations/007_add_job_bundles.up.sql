@@ -0,0 +1,13 @@
+-- Bundle imports: a parent job (resource 'bundle') owns one child job per
+-- resource in the archive. Children are run by their parent, never picked
+-- up by the job processor directly.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS parent_job_id UUID REFERENCES jobs(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_jobs_parent_job_id ON jobs(parent_job_id);
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_resource_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_resource_check
+    CHECK (resource IN ('users', 'articles', 'comments', 'bundle'));
+
+-- Only top-level jobs are polled
+DROP INDEX IF EXISTS idx_jobs_pending;
+CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs(created_at) WHERE status = 'pending' AND parent_job_id IS NULL;
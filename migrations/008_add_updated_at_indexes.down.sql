@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_comments_updated_at_id;
+DROP INDEX IF EXISTS idx_articles_updated_at_id;
+DROP INDEX IF EXISTS idx_users_updated_at_id;
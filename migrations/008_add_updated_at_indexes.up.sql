@@ -0,0 +1,4 @@
+-- Keyset indexes for incremental exports: WHERE (updated_at, id) > cursor ORDER BY updated_at, id
+CREATE INDEX IF NOT EXISTS idx_users_updated_at_id ON users(updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_articles_updated_at_id ON articles(updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_comments_updated_at_id ON comments(updated_at, id);
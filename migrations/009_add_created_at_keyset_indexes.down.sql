@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_comments_created_at_id;
+DROP INDEX IF EXISTS idx_articles_created_at_id;
+DROP INDEX IF EXISTS idx_users_created_at_id;
@@ -0,0 +1,5 @@
+-- Keyset indexes for the resource page API and full exports:
+-- WHERE (created_at, id) > cursor ORDER BY created_at, id
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_articles_created_at_id ON articles(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_comments_created_at_id ON comments(created_at, id);
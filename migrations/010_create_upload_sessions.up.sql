@@ -0,0 +1,22 @@
+-- Resumable upload sessions: chunks are appended to file_path until
+-- "offset" reaches size, then the session is completed into an import job
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    resource VARCHAR(50) NOT NULL CHECK (resource IN ('users', 'articles', 'comments', 'bundle')),
+    format VARCHAR(50) NOT NULL,
+    filename TEXT,
+    size BIGINT NOT NULL CHECK (size > 0),
+    "offset" BIGINT NOT NULL DEFAULT 0 CHECK ("offset" >= 0 AND "offset" <= size),
+    idempotency_key VARCHAR(255) UNIQUE,
+    job_id UUID REFERENCES jobs(id) ON DELETE SET NULL,
+    file_path TEXT NOT NULL,
+    options JSONB NOT NULL DEFAULT '{}',
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Expired sessions are swept periodically; completed ones are kept for
+-- idempotent completion until they expire
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions(expires_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_external_ids_internal;
+DROP TABLE IF EXISTS external_ids;
@@ -0,0 +1,15 @@
+-- Identifiers from foreign systems, mapped onto the UUIDs their rows are
+-- stored under. Imports mint a mapping for each new non-UUID id and resolve
+-- references through them.
+CREATE TABLE IF NOT EXISTS external_ids (
+    resource VARCHAR(50) NOT NULL CHECK (resource IN ('users', 'articles', 'comments')),
+    source VARCHAR(100) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    internal_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (resource, source, external_id)
+);
+
+-- Reverse lookup: every external ID of a stored row
+CREATE INDEX IF NOT EXISTS idx_external_ids_internal ON external_ids(resource, internal_id);
@@ -0,0 +1,13 @@
+-- Audit of the transforms applied to import records before validation
+CREATE TABLE IF NOT EXISTS job_transforms (
+    id BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    line_number INTEGER NOT NULL,
+    field VARCHAR(255) NOT NULL,
+    transform VARCHAR(50) NOT NULL,
+    before_value TEXT NOT NULL,
+    after_value TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_transforms_job_line ON job_transforms(job_id, line_number);
@@ -0,0 +1,4 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS warning_count;
+
+ALTER TABLE job_errors DROP COLUMN IF EXISTS code;
+ALTER TABLE job_errors DROP COLUMN IF EXISTS severity;
@@ -0,0 +1,7 @@
+-- Issues found in import records carry a severity and a stable code. Only
+-- errors reject a record; warnings and info are reported alongside.
+ALTER TABLE job_errors ADD COLUMN IF NOT EXISTS severity VARCHAR(10) NOT NULL DEFAULT 'error'
+    CHECK (severity IN ('error', 'warning', 'info'));
+ALTER TABLE job_errors ADD COLUMN IF NOT EXISTS code VARCHAR(50) NOT NULL DEFAULT '';
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS warning_count INTEGER DEFAULT 0;
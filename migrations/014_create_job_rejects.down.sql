@@ -0,0 +1,3 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS source_header;
+
+DROP TABLE IF EXISTS job_rejects CASCADE;
@@ -0,0 +1,15 @@
+-- Records an import rejected, kept as read from the file so they can be
+-- downloaded, fixed and uploaded again
+CREATE TABLE IF NOT EXISTS job_rejects (
+    id BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    line_number INTEGER NOT NULL,
+    record JSONB NOT NULL,
+    errors TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_rejects_job_line ON job_rejects(job_id, line_number);
+
+-- Header of a tabular source file as written, repeated in its rejects file
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS source_header TEXT[];
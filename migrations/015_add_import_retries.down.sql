@@ -0,0 +1,4 @@
+ALTER TABLE job_rejects DROP COLUMN IF EXISTS retryable;
+
+DROP INDEX IF EXISTS idx_jobs_pending;
+CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs(created_at) WHERE status = 'pending' AND parent_job_id IS NULL;
@@ -0,0 +1,8 @@
+-- Retries of an import are its child jobs (parent_job_id), polled like
+-- top-level jobs; only a bundle runs its children itself
+DROP INDEX IF EXISTS idx_jobs_pending;
+CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs(created_at) WHERE status = 'pending';
+
+-- Rejected records whose errors all came from the state of the database
+-- (missing references, existing rows, failed inserts) are retried as they are
+ALTER TABLE job_rejects ADD COLUMN IF NOT EXISTS retryable BOOLEAN NOT NULL DEFAULT false;
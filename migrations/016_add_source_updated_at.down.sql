@@ -0,0 +1,3 @@
+ALTER TABLE comments DROP COLUMN IF EXISTS source_updated_at;
+ALTER TABLE articles DROP COLUMN IF EXISTS source_updated_at;
+ALTER TABLE users DROP COLUMN IF EXISTS source_updated_at;
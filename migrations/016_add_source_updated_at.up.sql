@@ -0,0 +1,5 @@
+-- updated_at is the time a row was written, which incremental exports page
+-- by; the updated_at an import read from its file is kept alongside
+ALTER TABLE users ADD COLUMN IF NOT EXISTS source_updated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS source_updated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS source_updated_at TIMESTAMP WITH TIME ZONE;